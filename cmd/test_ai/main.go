@@ -27,22 +27,32 @@ func (m *MockCache) Exists(ctx context.Context, key string) (bool, error) {
 }
 
 func main() {
-	// Check for required environment variables
-	openAIKey := os.Getenv("OPENAI_API_KEY")
-	if openAIKey == "" {
-		log.Fatal("OPENAI_API_KEY environment variable is required")
+	// Run offline against the fake provider unless LLM_PROVIDER picks a real
+	// one
+	providerName := os.Getenv("LLM_PROVIDER")
+	if providerName == "" {
+		providerName = ai.ProviderFake
 	}
-
-	openAIBaseURL := os.Getenv("OPENAI_BASE_URL")
-	if openAIBaseURL == "" {
-		openAIBaseURL = "https://api.openai.com/v1"
+	providerConfig := ai.ProviderConfig{Provider: providerName, Model: os.Getenv("LLM_MODEL")}
+	switch providerName {
+	case ai.ProviderOpenAI:
+		providerConfig.APIKey, providerConfig.BaseURL = os.Getenv("OPENAI_API_KEY"), os.Getenv("OPENAI_BASE_URL")
+		providerConfig.StructuredOutput = os.Getenv("LLM_STRUCTURED_OUTPUT")
+	case ai.ProviderAnthropic:
+		providerConfig.APIKey, providerConfig.BaseURL = os.Getenv("ANTHROPIC_API_KEY"), os.Getenv("ANTHROPIC_BASE_URL")
+	case ai.ProviderOllama:
+		providerConfig.BaseURL = os.Getenv("OLLAMA_BASE_URL")
+	}
+	provider, err := ai.NewLLMProvider(providerConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize LLM provider %q: %v", providerName, err)
 	}
 
 	// Initialize mock cache
 	mockCache := &MockCache{}
 
 	// Initialize AI prompt generator
-	promptGenerator := ai.NewPromptGenerator(openAIKey, openAIBaseURL, mockCache)
+	promptGenerator := ai.NewPromptGenerator(provider, mockCache)
 
 	// Test match data
 	testMatchData := ai.MatchData{
@@ -96,7 +106,7 @@ func main() {
 
 	ctx := context.Background()
 
-	fmt.Println("🤖 Testing AI Prompt Generation")
+	fmt.Printf("🤖 Testing AI Prompt Generation (%s)\n", provider.Name())
 	fmt.Println("===============================")
 
 	// Test pre-match prompt
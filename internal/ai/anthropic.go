@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+	defaultAnthropicModel   = "claude-3-5-haiku-latest"
+	anthropicVersion        = "2023-06-01"
+)
+
+// AnthropicProvider calls the Anthropic Messages API
+type AnthropicProvider struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+	Client  *http.Client
+}
+
+type anthropicRequest struct {
+	Model       string    `json:"model"`
+	System      string    `json:"system,omitempty"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+	MaxTokens   int       `json:"max_tokens"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func NewAnthropicProvider(apiKey, baseURL, model string) *AnthropicProvider {
+	if baseURL == "" {
+		baseURL = defaultAnthropicBaseURL
+	}
+	if model == "" {
+		model = defaultAnthropicModel
+	}
+	return &AnthropicProvider{APIKey: apiKey, BaseURL: baseURL, Model: model}
+}
+
+func (p *AnthropicProvider) Name() string {
+	return ProviderAnthropic
+}
+
+func (p *AnthropicProvider) Complete(ctx context.Context, request CompletionRequest) (Completion, error) {
+	body := anthropicRequest{
+		Model:       request.Model,
+		Temperature: request.Temperature,
+		MaxTokens:   request.MaxTokens,
+	}
+	if body.Model == "" {
+		body.Model = p.Model
+	}
+	// The Messages API takes the system prompt separately and requires
+	// max_tokens
+	if body.MaxTokens == 0 {
+		body.MaxTokens = 1024
+	}
+	var system []string
+	for _, message := range request.Messages {
+		if message.Role == "system" {
+			system = append(system, message.Content)
+			continue
+		}
+		body.Messages = append(body.Messages, message)
+	}
+	body.System = strings.Join(system, "\n\n")
+
+	var response anthropicResponse
+	err := postJSON(ctx, p.Client, p.BaseURL+"/messages", map[string]string{
+		"x-api-key":         p.APIKey,
+		"anthropic-version": anthropicVersion,
+	}, body, &response)
+	completion := Completion{Model: body.Model}
+	if err != nil {
+		return completion, fmt.Errorf("Anthropic API call failed: %w", err)
+	}
+	completion.Usage = Usage{
+		PromptTokens:     response.Usage.InputTokens,
+		CompletionTokens: response.Usage.OutputTokens,
+	}
+
+	var text strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return completion, fmt.Errorf("no text returned from Anthropic")
+	}
+	completion.Text = text.String()
+	return completion, nil
+}
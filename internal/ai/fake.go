@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FakeProvider answers without calling a model, for tests and offline runs.
+// It fails with Err when set, then works through Replies in order, then
+// replies with Reply when set and otherwise with a debate prompt built from
+// the teams named in the request, so the same request always gets the same
+// reply. Its completions are from the model "fake" and use no tokens.
+type FakeProvider struct {
+	Replies []string
+	Reply   string
+	Err     error
+
+	mu       sync.Mutex
+	requests []CompletionRequest
+}
+
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+func (p *FakeProvider) Complete(ctx context.Context, request CompletionRequest) (Completion, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, request)
+
+	completion := Completion{Model: ProviderFake}
+	switch {
+	case p.Err != nil:
+		return completion, p.Err
+	case len(p.Replies) > 0:
+		completion.Text = p.Replies[0]
+		p.Replies = p.Replies[1:]
+	case p.Reply != "":
+		completion.Text = p.Reply
+	default:
+		completion.Text = fakeDebateReply(request)
+	}
+	return completion, nil
+}
+
+// Requests returns the requests the provider has received
+func (p *FakeProvider) Requests() []CompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]CompletionRequest(nil), p.requests...)
+}
+
+func fakeDebateReply(request CompletionRequest) string {
+	home, away := "the home side", "the away side"
+	postMatch := false
+	for _, message := range request.Messages {
+		if message.Role != "user" {
+			continue
+		}
+		// A translation is asked for with the debate as JSON, which is
+		// returned as it is
+		var source DebatePrompt
+		if json.Unmarshal([]byte(message.Content), &source) == nil && len(source.Cards) > 0 {
+			return message.Content
+		}
+		postMatch = postMatch || strings.Contains(message.Content, "post_match")
+		for _, line := range strings.Split(message.Content, "\n") {
+			if teams, ok := strings.CutPrefix(line, "Match: "); ok {
+				if h, a, ok := strings.Cut(teams, " vs "); ok {
+					home, away = strings.TrimSpace(h), strings.TrimSpace(a)
+				}
+			}
+		}
+	}
+
+	prompt := DebatePrompt{
+		Headline:    fmt.Sprintf("Will %s beat %s?", home, away),
+		Description: fmt.Sprintf("%s host %s. Who comes out on top?", home, away),
+		Cards: []DebateCard{
+			{Stance: "agree", Title: fmt.Sprintf("%s win", home), Description: fmt.Sprintf("%s are too strong at home.", home)},
+			{Stance: "disagree", Title: fmt.Sprintf("%s win", away), Description: fmt.Sprintf("%s have the quality to win away.", away)},
+			{Stance: "wildcard", Title: "A draw", Description: "Neither side will find a way through."},
+		},
+	}
+	if postMatch {
+		prompt.Headline = fmt.Sprintf("Did %s deserve the result against %s?", home, away)
+		prompt.Description = fmt.Sprintf("%s and %s have played. Was the result fair?", home, away)
+		prompt.Cards = []DebateCard{
+			{Stance: "agree", Title: "The result was fair", Description: "The better side got what they deserved."},
+			{Stance: "disagree", Title: "The result was unfair", Description: "Key decisions went the wrong way."},
+			{Stance: "wildcard", Title: "The referee decided it", Description: "Officiating was the real story."},
+		}
+	}
+
+	reply, _ := json.Marshal(prompt)
+	return string(reply)
+}
@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// LLM provider names, as set in LLM_PROVIDER
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderOllama    = "ollama"
+	ProviderFake      = "fake"
+)
+
+// How an OpenAI-compatible provider asks for JSON output, as set in
+// LLM_STRUCTURED_OUTPUT
+const (
+	// StructuredOutputJSONMode sets response_format to a JSON object
+	StructuredOutputJSONMode = "json_mode"
+	// StructuredOutputTools has the model call a function whose parameters
+	// are the output schema, for servers without JSON mode
+	StructuredOutputTools = "tools"
+	// StructuredOutputNone relies on the prompt alone
+	StructuredOutputNone = "none"
+)
+
+// ErrNoAPIKey is returned by NewLLMProvider for a hosted provider configured
+// without an API key
+var ErrNoAPIKey = errors.New("no API key configured")
+
+// LLMProvider sends chat completion requests to a language model
+type LLMProvider interface {
+	// Complete returns the model's reply. The completion names the model the
+	// request was sent to even when the call fails, so failed calls can be
+	// accounted for.
+	Complete(ctx context.Context, request CompletionRequest) (Completion, error)
+	Name() string
+}
+
+// Completion is a model's reply and the tokens it took
+type Completion struct {
+	Text  string
+	Model string
+	Usage Usage
+}
+
+// Usage counts the tokens a completion request used, as the provider
+// reported them
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// CompletionRequest is a chat completion request in a form every provider
+// understands. Each provider passes system messages the way its API expects.
+type CompletionRequest struct {
+	Model       string // The provider's default model when empty
+	Messages    []Message
+	Temperature float64
+	MaxTokens   int
+	// Output asks for a JSON reply matching a schema. Providers enforce it as
+	// far as their API allows, so the prompt should still ask for JSON and
+	// the reply still be validated.
+	Output *JSONOutput
+}
+
+// JSONOutput describes the JSON object a completion should reply with
+type JSONOutput struct {
+	Name        string
+	Description string
+	Schema      json.RawMessage
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ProviderConfig selects and configures an LLM provider. BaseURL and Model
+// fall back to the provider's defaults when empty.
+type ProviderConfig struct {
+	Provider string
+	APIKey   string
+	BaseURL  string
+	Model    string
+	// StructuredOutput is how OpenAI-compatible providers ask for JSON,
+	// defaulting to StructuredOutputJSONMode
+	StructuredOutput string
+}
+
+// NewLLMProvider creates the provider named by config.Provider, defaulting to
+// OpenAI
+func NewLLMProvider(config ProviderConfig) (LLMProvider, error) {
+	switch config.Provider {
+	case ProviderOpenAI, "":
+		if config.APIKey == "" {
+			return nil, ErrNoAPIKey
+		}
+		provider := NewOpenAIProvider(config.APIKey, config.BaseURL, config.Model)
+		switch config.StructuredOutput {
+		case StructuredOutputJSONMode, StructuredOutputTools, StructuredOutputNone:
+			provider.StructuredOutput = config.StructuredOutput
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown structured output mode %q", config.StructuredOutput)
+		}
+		return provider, nil
+	case ProviderAnthropic:
+		if config.APIKey == "" {
+			return nil, ErrNoAPIKey
+		}
+		return NewAnthropicProvider(config.APIKey, config.BaseURL, config.Model), nil
+	case ProviderOllama:
+		return NewOllamaProvider(config.BaseURL, config.Model), nil
+	case ProviderFake:
+		return &FakeProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", config.Provider)
+	}
+}
+
+var defaultLLMClient = &http.Client{Timeout: 30 * time.Second}
+
+// postJSON sends body as JSON to url and decodes the JSON response into out
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	if client == nil {
+		client = defaultLLMClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// The start of the body usually says what was wrong with the request
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("returned status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// recordingServer replies with reply and records the path, headers and JSON
+// body of the request it receives
+func recordingServer(t *testing.T, reply string) (*httptest.Server, *http.Request, map[string]interface{}) {
+	t.Helper()
+	var got http.Request
+	body := map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = *r
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+	return server, &got, body
+}
+
+var testRequest = CompletionRequest{
+	Messages: []Message{
+		{Role: "system", Content: "Be brief"},
+		{Role: "user", Content: "Hello"},
+	},
+	Temperature: 0.5,
+	MaxTokens:   50,
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	server, got, body := recordingServer(t, `{"choices": [{"message": {"content": "Hi"}}], "usage": {"prompt_tokens": 12, "completion_tokens": 3}}`)
+
+	reply, err := NewOpenAIProvider("key", server.URL, "").Complete(context.Background(), testRequest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reply.Text != "Hi" || reply.Model != defaultOpenAIModel || reply.Usage != (Usage{PromptTokens: 12, CompletionTokens: 3}) {
+		t.Errorf("Expected reply Hi from %s using 12+3 tokens, got %+v", defaultOpenAIModel, reply)
+	}
+	if got.URL.Path != "/chat/completions" || got.Header.Get("Authorization") != "Bearer key" {
+		t.Errorf("Unexpected request %s with Authorization %q", got.URL.Path, got.Header.Get("Authorization"))
+	}
+	if body["model"] != defaultOpenAIModel || len(body["messages"].([]interface{})) != 2 {
+		t.Errorf("Unexpected request body %v", body)
+	}
+}
+
+func TestAnthropicProvider(t *testing.T) {
+	server, got, body := recordingServer(t, `{"content": [{"type": "text", "text": "Hi"}], "usage": {"input_tokens": 20, "output_tokens": 4}}`)
+
+	request := testRequest
+	request.Model = "claude-test"
+	reply, err := NewAnthropicProvider("key", server.URL, "").Complete(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reply.Text != "Hi" || reply.Usage != (Usage{PromptTokens: 20, CompletionTokens: 4}) {
+		t.Errorf("Expected reply Hi using 20+4 tokens, got %+v", reply)
+	}
+	if got.URL.Path != "/messages" || got.Header.Get("x-api-key") != "key" || got.Header.Get("anthropic-version") == "" {
+		t.Errorf("Unexpected request %s with headers %v", got.URL.Path, got.Header)
+	}
+	// The system prompt moves out of the messages
+	if body["model"] != "claude-test" || body["system"] != "Be brief" || len(body["messages"].([]interface{})) != 1 {
+		t.Errorf("Unexpected request body %v", body)
+	}
+}
+
+func TestOllamaProvider(t *testing.T) {
+	server, got, body := recordingServer(t, `{"message": {"role": "assistant", "content": "Hi"}, "prompt_eval_count": 9, "eval_count": 2}`)
+
+	reply, err := NewOllamaProvider(server.URL, "").Complete(context.Background(), testRequest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reply.Text != "Hi" || reply.Usage != (Usage{PromptTokens: 9, CompletionTokens: 2}) {
+		t.Errorf("Expected reply Hi using 9+2 tokens, got %+v", reply)
+	}
+	if got.URL.Path != "/api/chat" {
+		t.Errorf("Unexpected request path %s", got.URL.Path)
+	}
+	if body["model"] != defaultOllamaModel || body["stream"] != false {
+		t.Errorf("Unexpected request body %v", body)
+	}
+}
+
+func TestProviderErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad model", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIProvider("key", server.URL, "").Complete(context.Background(), testRequest)
+	if err == nil || err.Error() != "OpenAI API call failed: returned status 400: bad model" {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
+
+func TestNewLLMProvider(t *testing.T) {
+	tests := []struct {
+		config ProviderConfig
+		name   string
+		err    bool
+	}{
+		{ProviderConfig{APIKey: "key"}, ProviderOpenAI, false},
+		{ProviderConfig{Provider: ProviderAnthropic, APIKey: "key"}, ProviderAnthropic, false},
+		{ProviderConfig{Provider: ProviderOllama}, ProviderOllama, false},
+		{ProviderConfig{Provider: ProviderFake}, ProviderFake, false},
+		{ProviderConfig{Provider: "gemini"}, "", true},
+	}
+	for _, tt := range tests {
+		provider, err := NewLLMProvider(tt.config)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Expected %q to be rejected", tt.config.Provider)
+			}
+			continue
+		}
+		if err != nil || provider.Name() != tt.name {
+			t.Errorf("Expected %s provider, got %v, %v", tt.name, provider, err)
+		}
+	}
+
+	if _, err := NewLLMProvider(ProviderConfig{Provider: ProviderAnthropic}); !errors.Is(err, ErrNoAPIKey) {
+		t.Errorf("Expected ErrNoAPIKey without a key, got %v", err)
+	}
+}
+
+type noCache struct{}
+
+func (noCache) Get(ctx context.Context, key string, value interface{}) error {
+	return errors.New("cache miss")
+}
+
+func (noCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return nil
+}
+
+func (noCache) Exists(ctx context.Context, key string) (bool, error) {
+	return false, nil
+}
+
+func TestPromptGeneratorWithFakeProvider(t *testing.T) {
+	provider := &FakeProvider{}
+	generator := NewPromptGenerator(provider, noCache{})
+	matchData := MatchData{MatchID: "1", HomeTeam: "Arsenal", AwayTeam: "Chelsea", Status: "NS"}
+
+	prompt, err := generator.GeneratePreMatchPrompt(context.Background(), matchData)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prompt.Headline != "Will Arsenal beat Chelsea?" || len(prompt.Cards) != 3 {
+		t.Errorf("Unexpected prompt %+v", prompt)
+	}
+
+	// The same request gets the same reply
+	again, _ := generator.GeneratePreMatchPrompt(context.Background(), matchData)
+	if again.Headline != prompt.Headline {
+		t.Errorf("Expected the fake to be deterministic, got %q and %q", prompt.Headline, again.Headline)
+	}
+	if requests := provider.Requests(); len(requests) != 2 || requests[0].Messages[0].Role != "system" {
+		t.Errorf("Unexpected requests %+v", requests)
+	}
+}
@@ -0,0 +1,26 @@
+package ai
+
+import "fmt"
+
+// DefaultLocale is the language debates are generated in unless another is
+// asked for
+const DefaultLocale = "en"
+
+// Languages maps the locales debates can be generated in to the names of
+// their languages, as used in prompts
+var Languages = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"it": "Italian",
+	"nl": "Dutch",
+	"pt": "Portuguese",
+}
+
+// languageInstruction tells the model which language to write in. The JSON
+// keys and stances stay in English so replies still match the schema.
+func languageInstruction(locale string) string {
+	return fmt.Sprintf(`Write the headline, the description and every card title and description in %s. Keep the JSON keys and the stance values ("agree", "disagree", "wildcard") in English.`, Languages[locale])
+}
@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGenerateVariantPromptInLocale(t *testing.T) {
+	provider := &FakeProvider{}
+	generator := NewPromptGenerator(provider, noCache{})
+
+	if _, err := generator.GenerateVariantPrompt(context.Background(), MatchData{MatchID: "1"}, "pre_match", PromptVariant{Locale: "es"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := generator.GenerateVariantPrompt(context.Background(), MatchData{MatchID: "1"}, "pre_match", PromptVariant{Locale: DefaultLocale}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	requests := provider.Requests()
+	if system := requests[0].Messages[0].Content; !strings.Contains(system, "in Spanish") {
+		t.Errorf("Expected the system prompt to ask for Spanish, got %q", system)
+	}
+	if system := requests[1].Messages[0].Content; strings.Contains(system, "in English") {
+		t.Errorf("Expected no language instruction for the default locale, got %q", system)
+	}
+}
+
+func TestTranslateDebatePrompt(t *testing.T) {
+	provider := &FakeProvider{Reply: `{"headline": "Quem vence o clássico?", "description": "", "cards": [
+		{"stance": "agree", "title": "Arsenal", "description": ""},
+		{"stance": "wildcard", "title": "Empate", "description": ""}
+	]}`}
+	generator := NewPromptGenerator(provider, noCache{})
+	source := DebatePrompt{
+		Headline:        "Who wins the derby?",
+		Cards:           []DebateCard{{Stance: "agree", Title: "Arsenal"}, {Stance: "wildcard", Title: "A draw"}},
+		TemplateVersion: 4,
+	}
+
+	translation, err := generator.TranslateDebatePrompt(context.Background(), source, "pt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if translation.TemplateVersion != 4 {
+		t.Errorf("Expected the source's template version, got %d", translation.TemplateVersion)
+	}
+
+	messages := provider.Requests()[0].Messages
+	if !strings.Contains(messages[0].Content, "into Portuguese") {
+		t.Errorf("Expected a translation into Portuguese, got %q", messages[0].Content)
+	}
+	if !strings.Contains(messages[1].Content, `"headline":"Who wins the derby?"`) {
+		t.Errorf("Expected the source prompt as JSON, got %q", messages[1].Content)
+	}
+	// The translation is held to the source's stances, not all three
+	if !strings.Contains(messages[0].Content, `exactly two cards: one "agree" and one "wildcard"`) {
+		t.Errorf("Expected the output rules for the source's cards, got %q", messages[0].Content)
+	}
+	if schema := string(provider.Requests()[0].Output.Schema); !strings.Contains(schema, `"maxItems": 2`) || strings.Contains(schema, "disagree") {
+		t.Errorf("Expected a schema for the source's cards, got %s", schema)
+	}
+
+	// A reply that adds a card is not a translation
+	provider.Reply = validPromptJSON
+	if _, err := generator.TranslateDebatePrompt(context.Background(), source, "pt"); err == nil {
+		t.Error("Expected an error for a translation with a card the source does not have")
+	}
+
+	if _, err := generator.TranslateDebatePrompt(context.Background(), source, "xx"); err == nil {
+		t.Error("Expected an error for an unsupported locale")
+	}
+
+	// A stance may have more than one card
+	source.Cards = append(source.Cards, DebateCard{Stance: "agree", Title: "Arsenal again"})
+	provider.Reply = `{"headline": "Quem vence o clássico?", "description": "", "cards": [
+		{"stance": "agree", "title": "Arsenal", "description": ""},
+		{"stance": "wildcard", "title": "Empate", "description": ""},
+		{"stance": "agree", "title": "Arsenal outra vez", "description": ""}
+	]}`
+	translation, err = generator.TranslateDebatePrompt(context.Background(), source, "pt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(translation.Cards) != 3 {
+		t.Errorf("Expected three translated cards, got %+v", translation.Cards)
+	}
+	requests := provider.Requests()
+	if system := requests[len(requests)-1].Messages[0].Content; !strings.Contains(system, `exactly three cards: two "agree" and one "wildcard"`) {
+		t.Errorf("Expected the output rules for the source's cards, got %q", system)
+	}
+}
@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultOllamaBaseURL = "http://localhost:11434"
+	defaultOllamaModel   = "llama3.1"
+)
+
+// OllamaProvider calls the chat API of a local Ollama server
+type OllamaProvider struct {
+	BaseURL string
+	Model   string
+	Client  *http.Client
+}
+
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []Message     `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Format   string        `json:"format,omitempty"`
+	Options  ollamaOptions `json:"options"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message         Message `json:"message"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+}
+
+func NewOllamaProvider(baseURL, model string) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	if model == "" {
+		model = defaultOllamaModel
+	}
+	// Local models are much slower than hosted ones
+	return &OllamaProvider{BaseURL: baseURL, Model: model, Client: &http.Client{Timeout: 2 * time.Minute}}
+}
+
+func (p *OllamaProvider) Name() string {
+	return ProviderOllama
+}
+
+func (p *OllamaProvider) Complete(ctx context.Context, request CompletionRequest) (Completion, error) {
+	model := request.Model
+	if model == "" {
+		model = p.Model
+	}
+
+	body := ollamaRequest{
+		Model:    model,
+		Messages: request.Messages,
+		Options: ollamaOptions{
+			Temperature: request.Temperature,
+			NumPredict:  request.MaxTokens,
+		},
+	}
+	if request.Output != nil {
+		body.Format = "json"
+	}
+
+	var response ollamaResponse
+	err := postJSON(ctx, p.Client, p.BaseURL+"/api/chat", nil, body, &response)
+	completion := Completion{Model: model}
+	if err != nil {
+		return completion, fmt.Errorf("Ollama API call failed: %w", err)
+	}
+	completion.Usage = Usage{
+		PromptTokens:     response.PromptEvalCount,
+		CompletionTokens: response.EvalCount,
+	}
+
+	if response.Message.Content == "" {
+		return completion, fmt.Errorf("no message returned from Ollama")
+	}
+	completion.Text = response.Message.Content
+	return completion, nil
+}
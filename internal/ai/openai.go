@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// OpenAIProvider calls the chat completions API of OpenAI or any server
+// compatible with it
+type OpenAIProvider struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+	Client  *http.Client
+	// StructuredOutput is how requests with an Output schema ask for JSON
+	StructuredOutput string
+}
+
+type OpenAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []Message             `json:"messages"`
+	Temperature    float64               `json:"temperature"`
+	MaxTokens      int                   `json:"max_tokens"`
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+	Tools          []OpenAITool          `json:"tools,omitempty"`
+	ToolChoice     *OpenAIToolChoice     `json:"tool_choice,omitempty"`
+}
+
+type OpenAIResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type OpenAITool struct {
+	Type     string             `json:"type"`
+	Function OpenAIToolFunction `json:"function"`
+}
+
+type OpenAIToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+type OpenAIToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+type OpenAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func NewOpenAIProvider(apiKey, baseURL, model string) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	return &OpenAIProvider{APIKey: apiKey, BaseURL: baseURL, Model: model, StructuredOutput: StructuredOutputJSONMode}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return ProviderOpenAI
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, request CompletionRequest) (Completion, error) {
+	model := request.Model
+	if model == "" {
+		model = p.Model
+	}
+
+	body := OpenAIRequest{
+		Model:       model,
+		Messages:    request.Messages,
+		Temperature: request.Temperature,
+		MaxTokens:   request.MaxTokens,
+	}
+	useTool := request.Output != nil && p.StructuredOutput == StructuredOutputTools
+	if request.Output != nil {
+		switch p.StructuredOutput {
+		case StructuredOutputJSONMode:
+			body.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
+		case StructuredOutputTools:
+			// Forcing the one function call makes its arguments the reply
+			body.Tools = []OpenAITool{{
+				Type: "function",
+				Function: OpenAIToolFunction{
+					Name:        request.Output.Name,
+					Description: request.Output.Description,
+					Parameters:  request.Output.Schema,
+				},
+			}}
+			body.ToolChoice = &OpenAIToolChoice{Type: "function"}
+			body.ToolChoice.Function.Name = request.Output.Name
+		}
+	}
+
+	var response OpenAIResponse
+	err := postJSON(ctx, p.Client, p.BaseURL+"/chat/completions", map[string]string{
+		"Authorization": "Bearer " + p.APIKey,
+	}, body, &response)
+	completion := Completion{Model: model}
+	if err != nil {
+		return completion, fmt.Errorf("OpenAI API call failed: %w", err)
+	}
+	completion.Usage = Usage{
+		PromptTokens:     response.Usage.PromptTokens,
+		CompletionTokens: response.Usage.CompletionTokens,
+	}
+
+	if len(response.Choices) == 0 {
+		return completion, fmt.Errorf("no choices returned from OpenAI")
+	}
+	message := response.Choices[0].Message
+	completion.Text = message.Content
+	if useTool {
+		for _, call := range message.ToolCalls {
+			if call.Function.Name == request.Output.Name {
+				completion.Text = call.Function.Arguments
+				break
+			}
+		}
+		// Some compatible servers ignore tool_choice and answer in the content
+	}
+	return completion, nil
+}
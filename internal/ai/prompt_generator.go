@@ -3,16 +3,30 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 )
 
+// maxRepairAttempts is how many times an invalid debate prompt is sent back
+// to the model to be fixed
+const maxRepairAttempts = 2
+
+// DefaultTemperature is the sampling temperature debate prompts are
+// generated with unless a variant overrides it
+const DefaultTemperature = 0.7
+
+// translationTemperature is kept low so translations stay close to the
+// original
+const translationTemperature = 0.3
+
 type PromptGenerator struct {
-	OpenAIKey     string
-	OpenAIBaseURL string
-	Cache         CacheInterface
+	Provider LLMProvider
+	Cache    CacheInterface
+	// Templates holds admin-edited prompt templates. The built-in templates
+	// are used when it is nil or has none active.
+	Templates TemplateStore
 }
 
 type CacheInterface interface {
@@ -79,6 +93,18 @@ type DebatePrompt struct {
 	Headline    string       `json:"headline"`
 	Description string       `json:"description"`
 	Cards       []DebateCard `json:"cards"`
+	// TemplateVersion is the version of the prompt template that produced
+	// the prompt; it is not part of the model's reply
+	TemplateVersion int `json:"template_version"`
+}
+
+// PromptVariant overrides how a debate prompt is generated, for comparing
+// prompts, models and temperatures in experiments
+type PromptVariant struct {
+	Template    *PromptTemplate // nil uses the active template
+	Model       string          // Empty uses the provider's model
+	Temperature *float64        // nil uses DefaultTemperature
+	Locale      string          // Empty writes the debate in DefaultLocale
 }
 
 type DebateCard struct {
@@ -87,35 +113,10 @@ type DebateCard struct {
 	Description string `json:"description"`
 }
 
-type OpenAIRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Temperature float64   `json:"temperature"`
-	MaxTokens   int       `json:"max_tokens"`
-}
-
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type OpenAIResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-}
-
-func NewPromptGenerator(openAIKey, openAIBaseURL string, cache CacheInterface) *PromptGenerator {
-	if openAIBaseURL == "" {
-		openAIBaseURL = "https://api.openai.com/v1"
-	}
-
+func NewPromptGenerator(provider LLMProvider, cache CacheInterface) *PromptGenerator {
 	return &PromptGenerator{
-		OpenAIKey:     openAIKey,
-		OpenAIBaseURL: openAIBaseURL,
-		Cache:         cache,
+		Provider: provider,
+		Cache:    cache,
 	}
 }
 
@@ -127,7 +128,8 @@ func (pg *PromptGenerator) GeneratePreMatchPrompt(ctx context.Context, matchData
 	exists, err := pg.Cache.Exists(ctx, cacheKey)
 	if err == nil && exists {
 		err = pg.Cache.Get(ctx, cacheKey, &cachedPrompt)
-		if err == nil {
+		// Prompts cached before validation was added may not pass it
+		if err == nil && ValidateDebatePrompt(cachedPrompt) == nil {
 			return &cachedPrompt, nil
 		}
 	}
@@ -155,7 +157,7 @@ func (pg *PromptGenerator) GeneratePostMatchPrompt(ctx context.Context, matchDat
 	exists, err := pg.Cache.Exists(ctx, cacheKey)
 	if err == nil && exists {
 		err = pg.Cache.Get(ctx, cacheKey, &cachedPrompt)
-		if err == nil {
+		if err == nil && ValidateDebatePrompt(cachedPrompt) == nil {
 			return &cachedPrompt, nil
 		}
 	}
@@ -173,247 +175,158 @@ func (pg *PromptGenerator) GeneratePostMatchPrompt(ctx context.Context, matchDat
 }
 
 func (pg *PromptGenerator) generatePrompt(ctx context.Context, matchData MatchData, promptType string) (*DebatePrompt, error) {
-	systemPrompt := pg.buildSystemPrompt(promptType)
-	userPrompt := pg.buildUserPrompt(matchData, promptType)
-
-	request := OpenAIRequest{
-		Model: "gpt-4o-mini",
-		Messages: []Message{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
-		},
-		Temperature: 0.7,
-		MaxTokens:   1000,
-	}
-
-	response, err := pg.callOpenAI(ctx, request)
-	if err != nil {
-		return nil, fmt.Errorf("OpenAI API call failed: %w", err)
-	}
-
-	// Parse the response
-	var prompt DebatePrompt
-	err = json.Unmarshal([]byte(response.Choices[0].Message.Content), &prompt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse OpenAI response: %w", err)
-	}
-
-	return &prompt, nil
-}
-
-func (pg *PromptGenerator) buildSystemPrompt(promptType string) string {
-	if promptType == "pre_match" {
-		return `You are a football debate prompt generator. Create engaging, controversial debate topics for PRE-MATCH discussions.
-
-IMPORTANT: This is a PRE-MATCH debate. The match has NOT happened yet. Focus on predictions, expectations, and pre-match analysis.
-
-Generate a JSON response with this structure:
-{
-  "headline": "A compelling, controversial headline that will spark debate",
-  "description": "A brief description providing context for the debate",
-  "cards": [
-    {
-      "stance": "agree",
-      "title": "Title for the agree stance",
-      "description": "Brief description supporting this stance"
-    },
-    {
-      "stance": "disagree", 
-      "title": "Title for the disagree stance",
-      "description": "Brief description supporting this stance"
-    },
-    {
-      "stance": "wildcard",
-      "title": "Title for a wildcard/unexpected stance",
-      "description": "Brief description for an unexpected perspective"
-    }
-  ]
+	return pg.GenerateVariantPrompt(ctx, matchData, promptType, PromptVariant{})
 }
 
-Focus on:
-- Lineup decisions and tactical choices
-- Player form and selection controversies
-- Managerial decisions
-- Bold predictions about what will happen
-- Historical context and rivalries
-- Pre-match expectations and concerns
-
-DO NOT reference match results, final scores, or post-match analysis since the match hasn't happened yet.
-
-Make the debate engaging and controversial but respectful.`
-	} else {
-		return `You are a football debate prompt generator. Create engaging, controversial debate topics for POST-MATCH discussions.
-
-IMPORTANT: This is a POST-MATCH debate. The match has already happened. Focus on analysis of what occurred.
-
-Generate a JSON response with this structure:
-{
-  "headline": "A compelling, controversial headline that will spark debate",
-  "description": "A brief description providing context for the debate",
-  "cards": [
-    {
-      "stance": "agree",
-      "title": "Title for the agree stance", 
-      "description": "Brief description supporting this stance"
-    },
-    {
-      "stance": "disagree",
-      "title": "Title for the disagree stance",
-      "description": "Brief description supporting this stance"
-    },
-    {
-      "stance": "wildcard",
-      "title": "Title for a wildcard/unexpected stance",
-      "description": "Brief description for an unexpected perspective"
-    }
-  ]
-}
-
-Focus on:
-- Key moments and turning points from the match
-- Controversial decisions (refereeing, VAR)
-- Player performances and impact
-- Tactical changes and their effectiveness
-- Social media reactions and fan sentiment
-- Post-match analysis and what-ifs
-- Analysis of the final result
-
-Make the debate engaging and controversial but respectful.`
+// activeTemplate is the admin-edited template for a debate type if one is
+// active, and the built-in one otherwise
+func (pg *PromptGenerator) activeTemplate(ctx context.Context, debateType string) (*PromptTemplate, error) {
+	if pg.Templates != nil {
+		tmpl, err := pg.Templates.ActivePromptTemplate(ctx, debateType)
+		if err != nil {
+			// Generation carries on with the built-in template
+			fmt.Printf("Failed to load %s prompt template: %v\n", debateType, err)
+		} else if tmpl != nil {
+			return tmpl, nil
+		}
 	}
+	return DefaultPromptTemplate(debateType)
 }
 
-func (pg *PromptGenerator) buildUserPrompt(matchData MatchData, promptType string) string {
-	var prompt strings.Builder
-
-	prompt.WriteString(fmt.Sprintf("Generate a %s debate prompt for this match:\n\n", promptType))
-	prompt.WriteString(fmt.Sprintf("Match: %s vs %s\n", matchData.HomeTeam, matchData.AwayTeam))
-	prompt.WriteString(fmt.Sprintf("Date: %s\n", matchData.Date))
-	prompt.WriteString(fmt.Sprintf("Status: %s\n", matchData.Status))
+// GenerateWithTemplate generates a debate prompt from the given template,
+// bypassing the cache. Admins use it to preview a template before
+// activating it.
+func (pg *PromptGenerator) GenerateWithTemplate(ctx context.Context, tmpl *PromptTemplate, matchData MatchData) (*DebatePrompt, error) {
+	return pg.GenerateVariantPrompt(ctx, matchData, tmpl.DebateType, PromptVariant{Template: tmpl})
+}
 
-	// Add venue, league, and season information if available
-	if matchData.Venue != "" {
-		prompt.WriteString(fmt.Sprintf("Venue: %s\n", matchData.Venue))
-	}
-	if matchData.League != "" {
-		prompt.WriteString(fmt.Sprintf("League: %s\n", matchData.League))
-	}
-	if matchData.Season != "" {
-		prompt.WriteString(fmt.Sprintf("Season: %s\n", matchData.Season))
-	}
-	prompt.WriteString("\n")
-
-	if matchData.Lineups != nil {
-		prompt.WriteString("LINEUPS:\n")
-		prompt.WriteString("Home Starters: ")
-		for i, player := range matchData.Lineups.HomeStarters {
-			if i > 0 {
-				prompt.WriteString(", ")
-			}
-			prompt.WriteString(fmt.Sprintf("%s (%s)", player.Name, player.Pos))
+// GenerateVariantPrompt generates a debate prompt with a variant's template,
+// model and temperature, bypassing the cache so that every variant's
+// settings take effect
+func (pg *PromptGenerator) GenerateVariantPrompt(ctx context.Context, matchData MatchData, debateType string, variant PromptVariant) (*DebatePrompt, error) {
+	tmpl := variant.Template
+	if tmpl == nil {
+		var err error
+		if tmpl, err = pg.activeTemplate(ctx, debateType); err != nil {
+			return nil, err
 		}
-		prompt.WriteString("\n")
-
-		prompt.WriteString("Away Starters: ")
-		for i, player := range matchData.Lineups.AwayStarters {
-			if i > 0 {
-				prompt.WriteString(", ")
-			}
-			prompt.WriteString(fmt.Sprintf("%s (%s)", player.Name, player.Pos))
-		}
-		prompt.WriteString("\n\n")
 	}
-
-	if matchData.Stats != nil {
-		prompt.WriteString("MATCH STATS:\n")
-		if promptType == "post_match" {
-			// For post-match debates, show final scores and stats
-			prompt.WriteString(fmt.Sprintf("Final Score: %d-%d\n", matchData.Stats.HomeScore, matchData.Stats.AwayScore))
-			prompt.WriteString(fmt.Sprintf("Shots: %d-%d\n", matchData.Stats.HomeShots, matchData.Stats.AwayShots))
-			prompt.WriteString(fmt.Sprintf("Possession: %d%%-%d%%\n", matchData.Stats.HomePossession, matchData.Stats.AwayPossession))
-			prompt.WriteString(fmt.Sprintf("Fouls: %d-%d\n", matchData.Stats.HomeFouls, matchData.Stats.AwayFouls))
-			prompt.WriteString(fmt.Sprintf("Cards: Yellow(%d-%d) Red(%d-%d)\n\n",
-				matchData.Stats.HomeYellowCards, matchData.Stats.AwayYellowCards,
-				matchData.Stats.HomeRedCards, matchData.Stats.AwayRedCards))
-		} else {
-			// For pre-match debates, show current form or recent stats if available
-			if matchData.Stats.HomeShots > 0 || matchData.Stats.AwayShots > 0 {
-				prompt.WriteString(fmt.Sprintf("Recent Form - Shots: %d-%d\n", matchData.Stats.HomeShots, matchData.Stats.AwayShots))
-			}
-			if matchData.Stats.HomePossession > 0 || matchData.Stats.AwayPossession > 0 {
-				prompt.WriteString(fmt.Sprintf("Recent Form - Possession: %d%%-%d%%\n", matchData.Stats.HomePossession, matchData.Stats.AwayPossession))
-			}
-			prompt.WriteString("\n")
-		}
+	temperature := DefaultTemperature
+	if variant.Temperature != nil {
+		temperature = *variant.Temperature
 	}
 
-	if len(matchData.NewsHeadlines) > 0 {
-		prompt.WriteString("NEWS HEADLINES:\n")
-		for _, headline := range matchData.NewsHeadlines {
-			prompt.WriteString(fmt.Sprintf("- %s\n", headline))
-		}
-		prompt.WriteString("\n")
+	systemPrompt, userPrompt, err := tmpl.Render(matchData)
+	if err != nil {
+		return nil, err
 	}
-
-	if matchData.SocialSentiment != nil {
-		prompt.WriteString("SOCIAL SENTIMENT:\n")
-		prompt.WriteString(fmt.Sprintf("Twitter Sentiment: %.2f\n", matchData.SocialSentiment.TwitterSentiment))
-		prompt.WriteString(fmt.Sprintf("Reddit Sentiment: %.2f\n", matchData.SocialSentiment.RedditSentiment))
-
-		if len(matchData.SocialSentiment.TopTopics) > 0 {
-			prompt.WriteString("Top Topics: ")
-			prompt.WriteString(strings.Join(matchData.SocialSentiment.TopTopics, ", "))
-			prompt.WriteString("\n")
-		}
-
-		if len(matchData.SocialSentiment.ControversialMoments) > 0 {
-			prompt.WriteString("Controversial Moments:\n")
-			for _, moment := range matchData.SocialSentiment.ControversialMoments {
-				prompt.WriteString(fmt.Sprintf("- %s\n", moment))
-			}
-		}
-		prompt.WriteString("\n")
+	// The output rules follow the schema, so they are not part of the
+	// editable template
+	systemPrompt += "\n\n" + debatePromptRules
+	if variant.Locale != "" && variant.Locale != DefaultLocale {
+		systemPrompt += "\n\n" + languageInstruction(variant.Locale)
 	}
 
-	prompt.WriteString("Generate a compelling debate prompt based on this information. Return only valid JSON.")
-
-	return prompt.String()
-}
-
-func (pg *PromptGenerator) callOpenAI(ctx context.Context, request OpenAIRequest) (*OpenAIResponse, error) {
-	jsonData, err := json.Marshal(request)
+	prompt, err := pg.completeDebatePrompt(ctx, debateStances, CompletionRequest{
+		Model: variant.Model,
+		Messages: []Message{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Temperature: temperature,
+	})
 	if err != nil {
 		return nil, err
 	}
+	prompt.TemplateVersion = tmpl.Version
+	return prompt, nil
+}
 
-	apiURL := fmt.Sprintf("%s/chat/completions", pg.OpenAIBaseURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(string(jsonData)))
+// TranslateDebatePrompt translates a debate prompt into the language of a
+// locale, keeping each card's stance and the order of the cards so the
+// translated cards can be matched to the originals. The prompt may have any
+// of the stances, any number of times; the translation has as many cards of
+// each.
+func (pg *PromptGenerator) TranslateDebatePrompt(ctx context.Context, prompt DebatePrompt, locale string) (*DebatePrompt, error) {
+	language, ok := Languages[locale]
+	if !ok {
+		return nil, fmt.Errorf("unsupported locale %q", locale)
+	}
+	if len(prompt.Cards) == 0 {
+		return nil, errors.New("the debate to translate has no cards")
+	}
+	stances := make([]string, len(prompt.Cards))
+	for i, card := range prompt.Cards {
+		stances[i] = card.Stance
+	}
+	// Only the parts the model writes are sent
+	source, err := json.Marshal(struct {
+		Headline    string       `json:"headline"`
+		Description string       `json:"description"`
+		Cards       []DebateCard `json:"cards"`
+	}{prompt.Headline, prompt.Description, prompt.Cards})
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+pg.OpenAIKey)
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	systemPrompt := fmt.Sprintf(`You translate football debate prompts for fans. Translate the debate you are given into %s so that it reads naturally to football fans who speak it, keeping its meaning, tone and the names of players, teams and competitions. Keep every card's stance exactly as it is, and the cards in the same order.`, language)
+	translation, err := pg.completeDebatePrompt(ctx, stances, CompletionRequest{
+		Messages: []Message{
+			{Role: "system", Content: systemPrompt + "\n\n" + debatePromptRulesFor(stances) + "\n\n" + languageInstruction(locale)},
+			{Role: "user", Content: string(source)},
+		},
+		Temperature: translationTemperature,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	translation.TemplateVersion = prompt.TemplateVersion
+	return translation, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OpenAI API returned status %d", resp.StatusCode)
+// completeDebatePrompt asks the model for a debate prompt with one card for
+// each of the stances, in the schema's structured output format. An invalid
+// reply is sent back with what was wrong with it, up to maxRepairAttempts
+// times.
+func (pg *PromptGenerator) completeDebatePrompt(ctx context.Context, stances []string, request CompletionRequest) (*DebatePrompt, error) {
+	request.MaxTokens = 1000
+	request.Output = &JSONOutput{
+		Name:        "debate_prompt",
+		Description: "A debate headline and description with one card per stance",
+		Schema:      debatePromptSchema(stances),
 	}
 
-	var response OpenAIResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		return nil, err
-	}
+	for attempt := 0; ; attempt++ {
+		completion, err := pg.Provider.Complete(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("LLM call failed: %w", err)
+		}
+		reply := completion.Text
+
+		prompt, err := parseDebatePrompt(reply, stances)
+		if err == nil {
+			return prompt, nil
+		}
+		var invalid *ValidationError
+		if !errors.As(err, &invalid) || attempt == maxRepairAttempts {
+			return nil, fmt.Errorf("failed to parse %s response: %w", pg.Provider.Name(), err)
+		}
 
-	if len(response.Choices) == 0 {
-		return nil, fmt.Errorf("no choices returned from OpenAI")
+		request.Messages = append(request.Messages,
+			Message{Role: "assistant", Content: reply},
+			Message{Role: "user", Content: repairPrompt(invalid, stances)},
+		)
 	}
+}
 
-	return &response, nil
+// repairPrompt asks the model to fix the problems found in its last reply,
+// which should have had a card for each of the stances
+func repairPrompt(invalid *ValidationError, stances []string) string {
+	var prompt strings.Builder
+	prompt.WriteString("Your response did not match the required format:\n")
+	for _, problem := range invalid.Problems {
+		prompt.WriteString(fmt.Sprintf("- %s\n", problem))
+	}
+	prompt.WriteString(fmt.Sprintf("\nReturn the corrected debate prompt as a single JSON object with exactly %s. Return only valid JSON.", describeCards(stances)))
+	return prompt.String()
 }
@@ -0,0 +1,136 @@
+package ai
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplateVersion is the version of the templates built into the
+// binary. Admin-edited templates are numbered from 1 for each debate type.
+const DefaultTemplateVersion = 0
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// PromptTemplate is one version of the text/template sources that build the
+// system and user prompts for a debate type. Both are executed with the match
+// data and the debate type, e.g. {{.HomeTeam}} and {{.DebateType}}.
+type PromptTemplate struct {
+	DebateType string
+	Version    int
+	System     string
+	User       string
+}
+
+// TemplateStore holds admin-edited prompt templates.
+// ActivePromptTemplate returns nil when a debate type has none active.
+type TemplateStore interface {
+	ActivePromptTemplate(ctx context.Context, debateType string) (*PromptTemplate, error)
+}
+
+// promptTemplateData is what templates are executed with
+type promptTemplateData struct {
+	MatchData
+	DebateType string
+}
+
+var promptTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+	// players lists players as "Name (Pos), ..."
+	"players": func(players []Player) string {
+		names := make([]string, len(players))
+		for i, player := range players {
+			names[i] = fmt.Sprintf("%s (%s)", player.Name, player.Pos)
+		}
+		return strings.Join(names, ", ")
+	},
+}
+
+// DefaultPromptTemplate returns the built-in templates for a debate type
+func DefaultPromptTemplate(debateType string) (*PromptTemplate, error) {
+	if debateType != "pre_match" && debateType != "post_match" {
+		return nil, fmt.Errorf("unknown debate type %q", debateType)
+	}
+	system, err := defaultTemplates.ReadFile("templates/" + debateType + "_system.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	user, err := defaultTemplates.ReadFile("templates/user.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	return &PromptTemplate{
+		DebateType: debateType,
+		Version:    DefaultTemplateVersion,
+		System:     string(system),
+		User:       string(user),
+	}, nil
+}
+
+// Render executes the templates with a match's data, returning the system
+// and user prompts
+func (t *PromptTemplate) Render(matchData MatchData) (string, string, error) {
+	data := promptTemplateData{MatchData: matchData, DebateType: t.DebateType}
+	system, err := executePromptTemplate("system", t.System, data)
+	if err != nil {
+		return "", "", err
+	}
+	user, err := executePromptTemplate("user", t.User, data)
+	if err != nil {
+		return "", "", err
+	}
+	return system, user, nil
+}
+
+// sampleMatchData has every field filled in, so that templates reaching into
+// the lineups, stats or sentiment can be validated
+var sampleMatchData = MatchData{
+	MatchID:  "0",
+	HomeTeam: "Home",
+	AwayTeam: "Away",
+	Date:     "2026-01-01T15:00:00Z",
+	Status:   "FT",
+	Lineups: &LineupData{
+		HomeStarters:    []Player{{Name: "Home Starter", Number: 9, Pos: "F"}},
+		HomeSubstitutes: []Player{{Name: "Home Substitute", Number: 12, Pos: "M"}},
+		AwayStarters:    []Player{{Name: "Away Starter", Number: 10, Pos: "M"}},
+		AwaySubstitutes: []Player{{Name: "Away Substitute", Number: 14, Pos: "D"}},
+	},
+	Stats:         &MatchStats{HomeScore: 1, AwayScore: 1, HomeGoals: 1, AwayGoals: 1},
+	NewsHeadlines: []string{"Headline"},
+	SocialSentiment: &SocialSentiment{
+		TopTopics:            []string{"Topic"},
+		ControversialMoments: []string{"Moment"},
+	},
+	Venue:  "Stadium",
+	League: "League",
+	Season: "2026",
+}
+
+// Validate checks that the templates parse and run against sample match
+// data, catching misspelt fields before a template is saved
+func (t *PromptTemplate) Validate() error {
+	system, user, err := t.Render(sampleMatchData)
+	if err != nil {
+		return err
+	}
+	if system == "" || user == "" {
+		return fmt.Errorf("system and user templates must not be empty")
+	}
+	return nil
+}
+
+func executePromptTemplate(name, source string, data promptTemplateData) (string, error) {
+	tmpl, err := template.New(name).Funcs(promptTemplateFuncs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return strings.TrimSpace(prompt.String()), nil
+}
@@ -0,0 +1,143 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type staticTemplateStore struct {
+	template *PromptTemplate
+}
+
+func (s staticTemplateStore) ActivePromptTemplate(ctx context.Context, debateType string) (*PromptTemplate, error) {
+	return s.template, nil
+}
+
+func TestDefaultPromptTemplateRenders(t *testing.T) {
+	matchData := MatchData{
+		MatchID:  "1",
+		HomeTeam: "Arsenal",
+		AwayTeam: "Chelsea",
+		Status:   "FT",
+		Lineups: &LineupData{
+			HomeStarters: []Player{{Name: "Saka", Pos: "F"}, {Name: "Rice", Pos: "M"}},
+		},
+		Stats:         &MatchStats{HomeScore: 2, AwayScore: 1},
+		NewsHeadlines: []string{"Arsenal edge the derby"},
+	}
+
+	for _, debateType := range []string{"pre_match", "post_match"} {
+		tmpl, err := DefaultPromptTemplate(debateType)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := tmpl.Validate(); err != nil {
+			t.Errorf("Expected the %s template to be valid, got %v", debateType, err)
+		}
+
+		system, user, err := tmpl.Render(matchData)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if system == "" {
+			t.Errorf("Expected a %s system prompt", debateType)
+		}
+		for _, want := range []string{"Match: Arsenal vs Chelsea", "Saka (F), Rice (M)", "Arsenal edge the derby", debateType} {
+			if !strings.Contains(user, want) {
+				t.Errorf("Expected the %s user prompt to contain %q, got %q", debateType, want, user)
+			}
+		}
+	}
+
+	if _, err := DefaultPromptTemplate("half_time"); err == nil {
+		t.Error("Expected an error for an unknown debate type")
+	}
+}
+
+func TestPromptTemplateValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl PromptTemplate
+	}{
+		{"parse error", PromptTemplate{System: "{{.HomeTeam", User: "Match"}},
+		{"unknown field", PromptTemplate{System: "System", User: "{{.Referee}}"}},
+		{"empty", PromptTemplate{System: "System", User: "  "}},
+	}
+	for _, tt := range tests {
+		if err := tt.tmpl.Validate(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	// Templates may reach into optional match data
+	tmpl := PromptTemplate{
+		System: "System",
+		User:   "{{players .Lineups.HomeStarters}} {{.Stats.HomeScore}}-{{.Stats.AwayScore}} {{join .SocialSentiment.TopTopics \", \"}}",
+	}
+	if err := tmpl.Validate(); err != nil {
+		t.Errorf("Expected a template using lineups, stats and sentiment to be valid, got %v", err)
+	}
+}
+
+func TestGeneratePromptUsesActiveTemplate(t *testing.T) {
+	provider := &FakeProvider{}
+	generator := NewPromptGenerator(provider, noCache{})
+	generator.Templates = staticTemplateStore{template: &PromptTemplate{
+		DebateType: "pre_match",
+		Version:    3,
+		System:     "Be bold.",
+		User:       "Match: {{.HomeTeam}} vs {{.AwayTeam}}",
+	}}
+
+	prompt, err := generator.GeneratePreMatchPrompt(context.Background(), MatchData{MatchID: "1", HomeTeam: "Arsenal", AwayTeam: "Chelsea"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prompt.TemplateVersion != 3 {
+		t.Errorf("Expected template version 3, got %d", prompt.TemplateVersion)
+	}
+
+	messages := provider.Requests()[0].Messages
+	if !strings.HasPrefix(messages[0].Content, "Be bold.") || !strings.Contains(messages[0].Content, debatePromptRules) {
+		t.Errorf("Expected the template's system prompt with the output rules, got %q", messages[0].Content)
+	}
+	if messages[1].Content != "Match: Arsenal vs Chelsea" {
+		t.Errorf("Expected the template's user prompt, got %q", messages[1].Content)
+	}
+
+	generator.Templates = staticTemplateStore{}
+	prompt, err = generator.GeneratePreMatchPrompt(context.Background(), MatchData{MatchID: "2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prompt.TemplateVersion != DefaultTemplateVersion {
+		t.Errorf("Expected the built-in template, got version %d", prompt.TemplateVersion)
+	}
+}
+
+func TestGenerateVariantPrompt(t *testing.T) {
+	provider := &FakeProvider{}
+	generator := NewPromptGenerator(provider, noCache{})
+	temperature := 1.2
+
+	_, err := generator.GenerateVariantPrompt(context.Background(), MatchData{MatchID: "1"}, "post_match", PromptVariant{
+		Model:       "gpt-4o",
+		Temperature: &temperature,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err = generator.GenerateVariantPrompt(context.Background(), MatchData{MatchID: "1"}, "post_match", PromptVariant{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	requests := provider.Requests()
+	if requests[0].Model != "gpt-4o" || requests[0].Temperature != 1.2 {
+		t.Errorf("Expected the variant's model and temperature, got %q at %v", requests[0].Model, requests[0].Temperature)
+	}
+	if requests[1].Model != "" || requests[1].Temperature != DefaultTemperature {
+		t.Errorf("Expected the provider's model at the default temperature, got %q at %v", requests[1].Model, requests[1].Temperature)
+	}
+}
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ModelPrice is what a model charges in US dollars per million tokens
+type ModelPrice struct {
+	PromptPerMillion     float64
+	CompletionPerMillion float64
+}
+
+// Cost is the price of the tokens a completion used
+func (p ModelPrice) Cost(usage Usage) float64 {
+	return (float64(usage.PromptTokens)*p.PromptPerMillion + float64(usage.CompletionTokens)*p.CompletionPerMillion) / 1e6
+}
+
+// DefaultModelPrices are the list prices of the providers' default models and
+// their common alternatives. Models without a price, such as local Ollama
+// models, are free.
+var DefaultModelPrices = map[string]ModelPrice{
+	"gpt-4o-mini":       {PromptPerMillion: 0.15, CompletionPerMillion: 0.60},
+	"gpt-4o":            {PromptPerMillion: 2.50, CompletionPerMillion: 10.00},
+	"gpt-4.1-nano":      {PromptPerMillion: 0.10, CompletionPerMillion: 0.40},
+	"gpt-4.1-mini":      {PromptPerMillion: 0.40, CompletionPerMillion: 1.60},
+	"gpt-4.1":           {PromptPerMillion: 2.00, CompletionPerMillion: 8.00},
+	"claude-3-5-haiku":  {PromptPerMillion: 0.80, CompletionPerMillion: 4.00},
+	"claude-3-5-sonnet": {PromptPerMillion: 3.00, CompletionPerMillion: 15.00},
+	"claude-sonnet-4":   {PromptPerMillion: 3.00, CompletionPerMillion: 15.00},
+}
+
+// ParseModelPrices adds prices written as comma-separated
+// "model=prompt/completion" pairs, in dollars per million tokens, to
+// DefaultModelPrices, e.g. "gpt-4o-mini=0.15/0.60,my-model=1/2"
+func ParseModelPrices(s string) (map[string]ModelPrice, error) {
+	prices := make(map[string]ModelPrice, len(DefaultModelPrices))
+	for model, price := range DefaultModelPrices {
+		prices[model] = price
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		model, rates, ok := strings.Cut(entry, "=")
+		prompt, completion, ok2 := strings.Cut(rates, "/")
+		if !ok || !ok2 || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("model price %q must look like model=prompt/completion", entry)
+		}
+		var price ModelPrice
+		var err error
+		if price.PromptPerMillion, err = strconv.ParseFloat(strings.TrimSpace(prompt), 64); err != nil || price.PromptPerMillion < 0 {
+			return nil, fmt.Errorf("invalid prompt price in %q", entry)
+		}
+		if price.CompletionPerMillion, err = strconv.ParseFloat(strings.TrimSpace(completion), 64); err != nil || price.CompletionPerMillion < 0 {
+			return nil, fmt.Errorf("invalid completion price in %q", entry)
+		}
+		prices[strings.TrimSpace(model)] = price
+	}
+	return prices, nil
+}
+
+// priceFor finds a model's price under the longest model name it starts
+// with, so dated releases such as "gpt-4o-mini-2024-07-18" or
+// "claude-3-5-haiku-latest" get the price of their model
+func priceFor(prices map[string]ModelPrice, model string) ModelPrice {
+	var price ModelPrice
+	matched := -1
+	for name, p := range prices {
+		if strings.HasPrefix(model, name) && len(name) > matched {
+			price, matched = p, len(name)
+		}
+	}
+	return price
+}
+
+// LLMCall is what one completion request used and cost
+type LLMCall struct {
+	Provider string
+	Model    string
+	Usage    Usage
+	CostUSD  float64
+	Latency  time.Duration
+	Err      error // Set when the call failed
+}
+
+// UsageRecorder stores LLM calls. Recording is best effort; a call is never
+// failed because it could not be recorded.
+type UsageRecorder interface {
+	RecordLLMCall(ctx context.Context, call LLMCall)
+}
+
+// MeteredProvider records the tokens, cost and latency of every call to the
+// provider it wraps
+type MeteredProvider struct {
+	LLMProvider
+	Recorder UsageRecorder
+	Prices   map[string]ModelPrice
+}
+
+// NewMeteredProvider wraps a provider, pricing calls with prices, or
+// DefaultModelPrices when prices is nil
+func NewMeteredProvider(provider LLMProvider, recorder UsageRecorder, prices map[string]ModelPrice) *MeteredProvider {
+	if prices == nil {
+		prices = DefaultModelPrices
+	}
+	return &MeteredProvider{LLMProvider: provider, Recorder: recorder, Prices: prices}
+}
+
+func (p *MeteredProvider) Complete(ctx context.Context, request CompletionRequest) (Completion, error) {
+	start := time.Now()
+	completion, err := p.LLMProvider.Complete(ctx, request)
+
+	model := completion.Model
+	if model == "" {
+		model = request.Model
+	}
+	p.Recorder.RecordLLMCall(ctx, LLMCall{
+		Provider: p.Name(),
+		Model:    model,
+		Usage:    completion.Usage,
+		CostUSD:  priceFor(p.Prices, model).Cost(completion.Usage),
+		Latency:  time.Since(start),
+		Err:      err,
+	})
+	return completion, err
+}
@@ -0,0 +1,89 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+type recordedCalls []LLMCall
+
+func (r *recordedCalls) RecordLLMCall(ctx context.Context, call LLMCall) {
+	*r = append(*r, call)
+}
+
+func TestParseModelPrices(t *testing.T) {
+	prices, err := ParseModelPrices(" my-model=1/2.5, gpt-4o-mini=0.2/0.8 ")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prices["my-model"] != (ModelPrice{PromptPerMillion: 1, CompletionPerMillion: 2.5}) {
+		t.Errorf("Expected the added price, got %+v", prices["my-model"])
+	}
+	if prices["gpt-4o-mini"].PromptPerMillion != 0.2 || prices["gpt-4o"] != DefaultModelPrices["gpt-4o"] {
+		t.Errorf("Expected overrides on top of the defaults, got %+v", prices)
+	}
+	if DefaultModelPrices["gpt-4o-mini"].PromptPerMillion != 0.15 {
+		t.Error("Expected the defaults to be left alone")
+	}
+
+	for _, invalid := range []string{"gpt-4o", "=1/2", "gpt-4o=1", "gpt-4o=a/2", "gpt-4o=1/-2"} {
+		if _, err := ParseModelPrices(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestPriceFor(t *testing.T) {
+	tests := []struct {
+		model string
+		want  ModelPrice
+	}{
+		{"gpt-4o-mini-2024-07-18", DefaultModelPrices["gpt-4o-mini"]},
+		{"gpt-4o", DefaultModelPrices["gpt-4o"]},
+		{"claude-3-5-haiku-latest", DefaultModelPrices["claude-3-5-haiku"]},
+		{"llama3.1", ModelPrice{}},
+	}
+	for _, tt := range tests {
+		if got := priceFor(DefaultModelPrices, tt.model); got != tt.want {
+			t.Errorf("priceFor(%q) = %+v; expected %+v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestMeteredProvider(t *testing.T) {
+	var calls recordedCalls
+	fake := &FakeProvider{}
+	provider := NewMeteredProvider(fake, &calls, map[string]ModelPrice{
+		ProviderFake: {PromptPerMillion: 1, CompletionPerMillion: 2},
+	})
+
+	completion, err := provider.Complete(context.Background(), testRequest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if completion.Text == "" {
+		t.Error("Expected the wrapped provider's reply")
+	}
+
+	fake.Err = errors.New("timeout")
+	if _, err := provider.Complete(context.Background(), testRequest); err == nil {
+		t.Error("Expected the wrapped provider's error")
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 recorded calls, got %d", len(calls))
+	}
+	if calls[0].Provider != ProviderFake || calls[0].Model != ProviderFake || calls[0].Err != nil {
+		t.Errorf("Unexpected call %+v", calls[0])
+	}
+	if calls[1].Err == nil || calls[1].Model != ProviderFake {
+		t.Errorf("Expected the failed call to be recorded, got %+v", calls[1])
+	}
+
+	cost := ModelPrice{PromptPerMillion: 1, CompletionPerMillion: 2}.Cost(Usage{PromptTokens: 1000, CompletionTokens: 500})
+	if math.Abs(cost-0.002) > 1e-12 {
+		t.Errorf("Expected $0.002, got %v", cost)
+	}
+}
@@ -0,0 +1,226 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Debate prompt length limits, in characters
+const (
+	maxHeadlineLength        = 150
+	maxDescriptionLength     = 500
+	maxCardTitleLength       = 100
+	maxCardDescriptionLength = 300
+)
+
+// debateStances are the stances a debate prompt has exactly one card for
+var debateStances = []string{"agree", "disagree", "wildcard"}
+
+// DebatePromptSchema is the JSON schema a model's debate prompt must match.
+// It is sent to providers that support structured output; ValidateDebatePrompt
+// applies the same rules to every reply.
+var DebatePromptSchema = debatePromptSchema(debateStances)
+
+// debatePromptRules spells out the schema's limits for the system prompt
+var debatePromptRules = debatePromptRulesFor(debateStances)
+
+// debatePromptSchema is the schema for a debate prompt with exactly one card
+// for each of the stances. A stance listed twice has two cards.
+func debatePromptSchema(stances []string) json.RawMessage {
+	distinct, _ := countStances(stances)
+	enum, _ := json.Marshal(distinct)
+	return json.RawMessage(fmt.Sprintf(`{
+  "type": "object",
+  "properties": {
+    "headline": {"type": "string", "minLength": 1, "maxLength": %d},
+    "description": {"type": "string", "maxLength": %d},
+    "cards": {
+      "type": "array",
+      "minItems": %d,
+      "maxItems": %d,
+      "items": {
+        "type": "object",
+        "properties": {
+          "stance": {"type": "string", "enum": %s},
+          "title": {"type": "string", "minLength": 1, "maxLength": %d},
+          "description": {"type": "string", "maxLength": %d}
+        },
+        "required": ["stance", "title", "description"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["headline", "description", "cards"],
+  "additionalProperties": false
+}`, maxHeadlineLength, maxDescriptionLength, len(stances), len(stances), enum, maxCardTitleLength, maxCardDescriptionLength))
+}
+
+// debatePromptRulesFor spells out the limits of debatePromptSchema(stances)
+func debatePromptRulesFor(stances []string) string {
+	return fmt.Sprintf(`Output rules:
+- Return exactly %s
+- Keep the headline under %d characters and the description under %d
+- Keep each card title under %d characters and each card description under %d`,
+		describeCards(stances), maxHeadlineLength, maxDescriptionLength, maxCardTitleLength, maxCardDescriptionLength)
+}
+
+// describeCards writes out the cards a prompt has for the stances, e.g.
+// `three cards: two "agree" and one "wildcard"`
+func describeCards(stances []string) string {
+	distinct, counts := countStances(stances)
+	quoted := make([]string, len(distinct))
+	for i, stance := range distinct {
+		quoted[i] = fmt.Sprintf("%s %q", countWord(counts[stance]), stance)
+	}
+	cards := quoted[0]
+	if len(quoted) > 1 {
+		cards = strings.Join(quoted[:len(quoted)-1], ", ") + " and " + quoted[len(quoted)-1]
+	}
+	return cardCount(len(stances)) + ": " + cards
+}
+
+// countStances lists the distinct stances in the order they first appear,
+// with how many cards each has
+func countStances(stances []string) ([]string, map[string]int) {
+	var distinct []string
+	counts := make(map[string]int)
+	for _, stance := range stances {
+		if counts[stance] == 0 {
+			distinct = append(distinct, stance)
+		}
+		counts[stance]++
+	}
+	return distinct, counts
+}
+
+// cardCount writes out a number of cards
+func cardCount(n int) string {
+	if n == 1 {
+		return "one card"
+	}
+	return countWord(n) + " cards"
+}
+
+// countWord writes out a small number
+func countWord(n int) string {
+	switch n {
+	case 1:
+		return "one"
+	case 2:
+		return "two"
+	case 3:
+		return "three"
+	}
+	return strconv.Itoa(n)
+}
+
+// ValidationError lists every way a debate prompt breaks the schema
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid debate prompt: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateDebatePrompt checks a prompt against DebatePromptSchema, returning
+// a *ValidationError when it does not match
+func ValidateDebatePrompt(prompt DebatePrompt) error {
+	return validateDebatePrompt(prompt, debateStances)
+}
+
+// validateDebatePrompt checks a prompt against debatePromptSchema(stances)
+func validateDebatePrompt(prompt DebatePrompt, stances []string) error {
+	var problems []string
+	checkLength := func(field, value string, required bool, max int) {
+		length := utf8.RuneCountInString(strings.TrimSpace(value))
+		switch {
+		case required && length == 0:
+			problems = append(problems, fmt.Sprintf("%s is required", field))
+		case length > max:
+			problems = append(problems, fmt.Sprintf("%s is %d characters, the limit is %d", field, length, max))
+		}
+	}
+
+	checkLength("headline", prompt.Headline, true, maxHeadlineLength)
+	checkLength("description", prompt.Description, false, maxDescriptionLength)
+
+	distinct, want := countStances(stances)
+	cardsByStance := make(map[string]int)
+	for i, card := range prompt.Cards {
+		field := fmt.Sprintf("cards[%d]", i)
+		if !containsStance(distinct, card.Stance) {
+			problems = append(problems, fmt.Sprintf("%s.stance %q must be one of %s", field, card.Stance, strings.Join(distinct, ", ")))
+		}
+		cardsByStance[card.Stance]++
+		checkLength(field+".title", card.Title, true, maxCardTitleLength)
+		checkLength(field+".description", card.Description, false, maxCardDescriptionLength)
+	}
+	for _, stance := range distinct {
+		if count := cardsByStance[stance]; count != want[stance] {
+			expected := fmt.Sprintf("one %s card", stance)
+			if want[stance] > 1 {
+				expected = fmt.Sprintf("%s %s cards", countWord(want[stance]), stance)
+			}
+			problems = append(problems, fmt.Sprintf("there must be exactly %s, found %d", expected, count))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func containsStance(stances []string, stance string) bool {
+	for _, s := range stances {
+		if stance == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDebatePrompt reads a debate prompt from a model's reply, which may be
+// wrapped in a markdown code fence or surrounded by prose, and validates it
+func ParseDebatePrompt(reply string) (*DebatePrompt, error) {
+	return parseDebatePrompt(reply, debateStances)
+}
+
+// parseDebatePrompt is ParseDebatePrompt for a prompt with one card for each
+// of the stances, a stance listed twice having two
+func parseDebatePrompt(reply string, stances []string) (*DebatePrompt, error) {
+	content := stripCodeFence(reply)
+	// Some models explain themselves around the JSON despite being told not
+	// to
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		content = content[start : end+1]
+	}
+
+	var prompt DebatePrompt
+	if err := json.Unmarshal([]byte(content), &prompt); err != nil {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("the reply is not valid JSON: %v", err)}}
+	}
+	if err := validateDebatePrompt(prompt, stances); err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// stripCodeFence removes a markdown code fence around a reply, with or
+// without a language tag
+func stripCodeFence(reply string) string {
+	reply = strings.TrimSpace(reply)
+	if !strings.HasPrefix(reply, "```") {
+		return reply
+	}
+	reply = strings.TrimPrefix(reply, "```")
+	if newline := strings.Index(reply, "\n"); newline >= 0 {
+		reply = reply[newline+1:] // Drop the language tag, e.g. json
+	}
+	reply = strings.TrimSuffix(strings.TrimSpace(reply), "```")
+	return strings.TrimSpace(reply)
+}
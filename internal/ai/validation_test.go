@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const validPromptJSON = `{"headline": "Is Haaland the best striker in the world?", "description": "He keeps scoring.", "cards": [
+	{"stance": "agree", "title": "Yes", "description": "The numbers speak."},
+	{"stance": "disagree", "title": "No", "description": "Kane is better."},
+	{"stance": "wildcard", "title": "It's Mbappé", "description": "Pace wins."}
+]}`
+
+func TestParseDebatePrompt(t *testing.T) {
+	replies := []string{
+		validPromptJSON,
+		"```json\n" + validPromptJSON + "\n```",
+		"```\n" + validPromptJSON + "\n```",
+		"Here is the debate:\n" + validPromptJSON + "\nEnjoy!",
+		validPromptJSON + "\nEnjoy!",
+	}
+	for _, reply := range replies {
+		prompt, err := ParseDebatePrompt(reply)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %v", reply, err)
+			continue
+		}
+		if prompt.Headline != "Is Haaland the best striker in the world?" || len(prompt.Cards) != 3 {
+			t.Errorf("Unexpected prompt %+v", prompt)
+		}
+	}
+
+	var invalid *ValidationError
+	if _, err := ParseDebatePrompt("not json"); !errors.As(err, &invalid) {
+		t.Errorf("Expected a validation error for non-JSON, got %v", err)
+	}
+}
+
+func TestValidateDebatePrompt(t *testing.T) {
+	prompt := DebatePrompt{
+		Headline: strings.Repeat("a", maxHeadlineLength+1),
+		Cards: []DebateCard{
+			{Stance: "agree", Title: "Yes"},
+			{Stance: "agree", Title: "Also yes"},
+			{Stance: "maybe", Title: ""},
+		},
+	}
+
+	err := ValidateDebatePrompt(prompt)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	want := []string{
+		"headline is 151 characters, the limit is 150",
+		`cards[2].stance "maybe" must be one of agree, disagree, wildcard`,
+		"cards[2].title is required",
+		"there must be exactly one agree card, found 2",
+		"there must be exactly one disagree card, found 0",
+		"there must be exactly one wildcard card, found 0",
+	}
+	if strings.Join(invalid.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected problems %q, got %q", want, invalid.Problems)
+	}
+}
+
+func TestValidateDebatePromptWithRepeatedStance(t *testing.T) {
+	stances := []string{"agree", "agree", "disagree"}
+	prompt := DebatePrompt{
+		Headline: "Who wins?",
+		Cards:    []DebateCard{{Stance: "agree", Title: "Home"}, {Stance: "disagree", Title: "Away"}},
+	}
+
+	err := validateDebatePrompt(prompt, stances)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Problems) != 1 || invalid.Problems[0] != "there must be exactly two agree cards, found 1" {
+		t.Errorf("Expected a missing agree card, got %v", err)
+	}
+	prompt.Cards = append(prompt.Cards, DebateCard{Stance: "agree", Title: "Home again"})
+	if err := validateDebatePrompt(prompt, stances); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if got := describeCards(stances); got != `three cards: two "agree" and one "disagree"` {
+		t.Errorf("Unexpected description %q", got)
+	}
+	if schema := string(debatePromptSchema(stances)); !strings.Contains(schema, `"enum": ["agree","disagree"]`) {
+		t.Errorf("Expected each stance once in the schema's enum, got %s", schema)
+	}
+}
+
+func TestDebatePromptSchemaIsValidJSON(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(DebatePromptSchema, &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+}
+
+func TestGeneratePromptRepairsInvalidReply(t *testing.T) {
+	provider := &FakeProvider{Replies: []string{
+		`{"headline": "Who wins?", "cards": [{"stance": "for", "title": "Home"}]}`,
+		validPromptJSON,
+	}}
+	generator := NewPromptGenerator(provider, noCache{})
+
+	prompt, err := generator.GeneratePreMatchPrompt(context.Background(), MatchData{MatchID: "1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prompt.Cards[2].Title != "It's Mbappé" {
+		t.Errorf("Expected the repaired prompt, got %+v", prompt)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected one repair request, got %d requests", len(requests))
+	}
+	repair := requests[1].Messages
+	if len(repair) != 4 || repair[2].Role != "assistant" || !strings.Contains(repair[3].Content, `cards[0].stance "for"`) {
+		t.Errorf("Expected the repair request to quote the problems, got %+v", repair)
+	}
+	if !strings.Contains(repair[3].Content, `exactly three cards: one "agree", one "disagree" and one "wildcard"`) {
+		t.Errorf("Expected the repair request to ask for the three stances, got %q", repair[3].Content)
+	}
+	if requests[0].Output == nil {
+		t.Error("Expected the request to ask for structured output")
+	}
+}
+
+func TestGeneratePromptGivesUpAfterRepairAttempts(t *testing.T) {
+	provider := &FakeProvider{Reply: `{"headline": ""}`}
+	generator := NewPromptGenerator(provider, noCache{})
+
+	if _, err := generator.GeneratePostMatchPrompt(context.Background(), MatchData{MatchID: "1"}); err == nil {
+		t.Error("Expected an error after the repair attempts run out")
+	}
+	if got := len(provider.Requests()); got != maxRepairAttempts+1 {
+		t.Errorf("Expected %d requests, got %d", maxRepairAttempts+1, got)
+	}
+}
+
+func TestOpenAIStructuredOutput(t *testing.T) {
+	request := testRequest
+	request.Output = &JSONOutput{Name: "debate_prompt", Schema: DebatePromptSchema}
+
+	server, _, body := recordingServer(t, `{"choices": [{"message": {"content": "{}"}}]}`)
+	if _, err := NewOpenAIProvider("key", server.URL, "").Complete(context.Background(), request); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if format, _ := body["response_format"].(map[string]interface{}); format["type"] != "json_object" {
+		t.Errorf("Expected JSON mode, got %v", body)
+	}
+
+	server, _, body = recordingServer(t, `{"choices": [{"message": {"content": "", "tool_calls": [
+		{"function": {"name": "debate_prompt", "arguments": "{\"headline\": \"Hi\"}"}}
+	]}}]}`)
+	provider := NewOpenAIProvider("key", server.URL, "")
+	provider.StructuredOutput = StructuredOutputTools
+	reply, err := provider.Complete(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reply.Text != `{"headline": "Hi"}` {
+		t.Errorf("Expected the tool call arguments, got %q", reply.Text)
+	}
+	if body["tools"] == nil || body["tool_choice"] == nil || body["response_format"] != nil {
+		t.Errorf("Expected a forced tool call, got %v", body)
+	}
+}
@@ -1,14 +1,20 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"log"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/ArronJLinton/fucci-api/internal/ai"
+	"github.com/ArronJLinton/fucci-api/internal/auth"
 	"github.com/ArronJLinton/fucci-api/internal/cache"
 	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/moderation"
+	"github.com/ArronJLinton/fucci-api/internal/policy"
 	"github.com/go-chi/chi"
-	"github.com/google/uuid"
 )
 
 type Config struct {
@@ -18,25 +24,117 @@ type Config struct {
 	RapidAPIKey        string
 	Cache              cache.CacheInterface
 	APIFootballBaseURL string
-	OpenAIKey          string
-	OpenAIBaseURL      string
-	AIPromptGenerator  *ai.PromptGenerator
+	// LLMProvider generates debates and classifies comments. AI features are
+	// off when it is nil.
+	LLMProvider       ai.LLMProvider
+	AIPromptGenerator *ai.PromptGenerator
+	JWTValidator      *auth.JWTValidator
+	TokenIssuer       *auth.TokenIssuer
+	Policy            *policy.Engine
+	RateLimiter       cache.RateLimiter
+	// TrustedProxies are the proxies whose X-Forwarded-For headers are
+	// believed when rate limiting by IP address. Without any, clients are
+	// identified by the address they connect from.
+	TrustedProxies []*net.IPNet
+	// LLMPrices price the LLM calls recorded in llm_calls, defaulting to
+	// ai.DefaultModelPrices. Once LLMMonthlyBudget dollars have been spent in
+	// a calendar month, debate generation is refused until the next. There
+	// is no budget when it is zero.
+	LLMPrices        map[string]ai.ModelPrice
+	LLMMonthlyBudget float64
+	// ReportHideThreshold is how many open reports hide a debate, card or
+	// comment until an admin reviews it
+	ReportHideThreshold int
+	// Moderator screens comments before they are stored. By default it runs
+	// ModerationWordlist (or the built-in wordlists), plus the LLM classifier
+	// when ModerationLLM is set.
+	Moderator          *moderation.Pipeline
+	ModerationWordlist *moderation.WordlistFilter
+	ModerationLLM      bool
+	// PubSub fans debate stream events and live match polls out to every
+	// instance
+	PubSub cache.PubSub
+	// Locker elects the one instance that polls each live fixture
+	Locker cache.Locker
+	// LiveMatchPollInterval is how often fixtures with live stream clients
+	// are polled while in play
+	LiveMatchPollInterval time.Duration
+	// DebateSchedulerLeagues are the API-Football league IDs whose fixtures
+	// get debates generated automatically, PreMatchDebateLead before kickoff
+	// and again at full time. The scheduler is off when it is empty.
+	DebateSchedulerLeagues []int
+	PreMatchDebateLead     time.Duration
+
+	streams       *debateStreamHub
+	liveMatches   *liveMatchPoller
+	debateJobWake chan struct{}
 }
 
-func New(c Config) http.Handler {
+// New builds the API's routes and starts its background debate workers and
+// scheduler, which run until ctx is cancelled
+func New(ctx context.Context, c Config) http.Handler {
 	router := chi.NewRouter()
+	router.Use(c.authenticate)
+
+	// Record the tokens and cost of every LLM call
+	if c.LLMProvider != nil && c.DB != nil {
+		c.LLMProvider = ai.NewMeteredProvider(c.LLMProvider, llmCallStore{db: c.DB}, c.LLMPrices)
+	}
+
+	// Initialize AI prompt generator if an LLM provider is configured
+	if c.AIPromptGenerator == nil && c.LLMProvider != nil {
+		c.AIPromptGenerator = ai.NewPromptGenerator(c.LLMProvider, c.Cache)
+	}
+	if c.AIPromptGenerator != nil && c.AIPromptGenerator.Templates == nil && c.DB != nil {
+		c.AIPromptGenerator.Templates = promptTemplateStore{db: c.DB}
+	}
 
-	// Initialize AI prompt generator if OpenAI key is provided
-	if c.OpenAIKey != "" {
-		c.AIPromptGenerator = ai.NewPromptGenerator(c.OpenAIKey, c.OpenAIBaseURL, c.Cache)
+	if c.Policy == nil {
+		c.Policy = policy.New(c.DB)
+	}
+	if c.RateLimiter == nil {
+		c.RateLimiter = newDefaultRateLimiter(c.Cache)
+	}
+	if c.ReportHideThreshold <= 0 {
+		c.ReportHideThreshold = defaultReportHideThreshold
+	}
+	if c.Moderator == nil {
+		c.Moderator = c.newModerator()
+	}
+	if c.PubSub == nil {
+		c.PubSub = newDefaultPubSub(c.Cache)
+	}
+	c.streams = newDebateStreamHub(c.PubSub)
+	if c.LiveMatchPollInterval <= 0 {
+		c.LiveMatchPollInterval = defaultLiveMatchPollInterval
+	}
+	if c.Locker == nil {
+		c.Locker = newDefaultLocker(c.Cache)
+	}
+	c.liveMatches = newLiveMatchPoller(c.fetchLiveMatch, c.PubSub, c.Locker, c.LiveMatchPollInterval)
+	if c.PreMatchDebateLead <= 0 {
+		c.PreMatchDebateLead = defaultPreMatchDebateLead
+	}
+	c.debateJobWake = make(chan struct{}, 1)
+	if c.AIPromptGenerator != nil {
+		for i := 0; i < debateWorkerCount; i++ {
+			go c.runDebateWorker(ctx)
+		}
+	}
+	if len(c.DebateSchedulerLeagues) > 0 {
+		if c.AIPromptGenerator == nil {
+			log.Println("Debate scheduler disabled: AI prompt generation is not configured")
+		} else {
+			go c.runDebateScheduler(ctx)
+		}
 	}
 
 	// Initialize services
-	teamsService := NewTeamsService(c.DB)
-	teamManagersService := NewTeamManagersService(c.DB)
-	leaguesService := NewLeaguesService(c.DB)
-	playerProfilesService := &PlayerProfileService{DB: c.DB}
-	verificationsService := &VerificationService{DB: c.DB, PlayerProfileSvc: &PlayerProfileService{DB: c.DB}}
+	teamsService := NewTeamsService(c.DB, c.Policy)
+	teamManagersService := NewTeamManagersService(c.DB, c.Policy)
+	leaguesService := NewLeaguesService(c.DB, c.Policy)
+	playerProfilesService := &PlayerProfileService{DB: c.DB, Policy: c.Policy}
+	verificationsService := &VerificationService{DB: c.DB, PlayerProfileSvc: playerProfilesService, Policy: c.Policy}
 
 	// Health check routes
 	router.Get("/health", HandleReadiness)
@@ -44,79 +142,184 @@ func New(c Config) http.Handler {
 	router.Get("/health/cache-stats", c.HandleCacheStats)
 
 	userRouter := chi.NewRouter()
-	userRouter.Post("/create", c.handleCreateUser)
-	userRouter.Get("/all", c.handleListAllUsers) // TEMP: List all users
+	userRouter.Post("/signup", c.handleSignup)
+	userRouter.Post("/login", c.handleLogin)
+	userRouter.Post("/refresh", c.handleRefresh)
+	userRouter.Post("/logout", c.handleLogout)
+	userRouter.Group(func(r chi.Router) {
+		r.Use(requireUser)
+		r.Get("/", c.handleListUsers)
+		r.Get("/{id}", c.handleGetUser)
+		r.Put("/{id}", c.handleUpdateUser)
+		r.Delete("/{id}", c.handleDeleteUser)
+	})
+
+	apiKeyRouter := chi.NewRouter()
+	apiKeyRouter.Use(requireUser)
+	apiKeyRouter.Post("/", c.createAPIKey)
+	apiKeyRouter.Get("/", c.listAPIKeys)
+	apiKeyRouter.Delete("/{id}", c.revokeAPIKey)
 
 	futbolRouter := chi.NewRouter()
+	futbolRouter.Use(requireScope(auth.ScopeFutbolRead))
 	futbolRouter.Get("/matches", c.getMatches)
+	futbolRouter.Get("/matches/{id}/live", c.streamLiveMatch)
 	futbolRouter.Get("/lineup", c.getMatchLineup)
 	futbolRouter.Get("/leagues", c.getLeagues)
 	futbolRouter.Get("/team_standings", c.getLeagueStandingsByTeamId)
 	futbolRouter.Get("/league_standings", c.getLeagueStandingsByLeagueId)
 
 	googleRouter := chi.NewRouter()
+	googleRouter.Use(c.rateLimit(googleSearchLimit))
 	googleRouter.Get("/search", c.search)
 
 	debateRouter := chi.NewRouter()
-	debateRouter.Post("/", c.createDebate)
-	debateRouter.Get("/top", c.getTopDebates)
-	debateRouter.Get("/generate", c.generateAIPrompt)
-	debateRouter.Post("/generate", c.generateDebate)
-	debateRouter.Get("/health", c.checkDebateGenerationHealth)
-	debateRouter.Get("/match", c.getDebatesByMatch)
-	debateRouter.Get("/{id}", c.getDebate)
-	debateRouter.Post("/cards", c.createDebateCard)
-	debateRouter.Post("/votes", c.createVote)
-	debateRouter.Post("/comments", c.createComment)
-	debateRouter.Get("/{debateId}/comments", c.getComments)
+	debateRouter.Group(func(r chi.Router) {
+		r.Use(requireScope(auth.ScopeDebatesRead))
+		r.Get("/top", c.getTopDebates)
+		r.With(c.rateLimit(debateGenerationLimit)).Get("/generate", c.generateAIPrompt)
+		r.Get("/health", c.checkDebateGenerationHealth)
+		r.Get("/match", c.getDebatesByMatch)
+		r.Get("/jobs/{id}", c.getDebateJob)
+		r.Get("/{id}", c.getDebate)
+		r.Get("/{id}/stances", c.getDebateStances)
+		r.Get("/{id}/stream", c.streamDebate) // SSE, or WebSocket when upgrading
+		r.Get("/{debateId}/comments", c.getComments)
+		r.Get("/{debateId}/comments/tree", c.getCommentTree)
+	})
+	debateRouter.Group(func(r chi.Router) {
+		// Partners with the debates:write scope may create and generate debates
+		r.Use(requireUserOrScope(auth.ScopeDebatesWrite))
+		r.Post("/", c.createDebate)
+		r.With(c.rateLimit(debateGenerationLimit)).Post("/generate", c.generateDebate)
+		r.Post("/cards", c.createDebateCard)
+	})
+	debateRouter.Group(func(r chi.Router) {
+		r.Use(requireUser)
+		r.Post("/votes", c.createVote)
+		r.Delete("/votes/{cardId}", c.deleteVote)
+		r.Post("/{id}/stance", c.setDebateStance)
+		r.Delete("/{id}/stance", c.deleteDebateStance)
+		r.Post("/comments", c.createComment)
+		r.Put("/comments/{id}", c.updateComment)    // Author or admin
+		r.Delete("/comments/{id}", c.deleteComment) // Author or admin
+		r.Get("/comments/{id}/edits", c.getCommentEdits)
+		r.Post("/comments/{id}/like", c.likeComment)
+		r.Delete("/comments/{id}/like", c.unlikeComment)
+		r.Post("/comments/{id}/reactions", c.reactToComment)
+		r.Delete("/comments/{id}/reactions", c.deleteCommentReaction)
+	})
 	// Admin routes for soft delete management
-	debateRouter.Delete("/{id}/hard", c.hardDeleteDebate) // Permanent deletion
-	debateRouter.Post("/{id}/restore", c.restoreDebate)   // Restore soft-deleted debate
+	debateRouter.Group(func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Get("/deleted", c.listDeletedDebates)    // Soft-deleted debates awaiting review
+		r.Delete("/{id}/hard", c.hardDeleteDebate) // Permanent deletion
+		r.Post("/{id}/restore", c.restoreDebate)   // Restore soft-deleted debate
+		r.Get("/comments/moderation", c.listCommentModerationQueue)
+		r.Post("/comments/{id}/restore", c.restoreComment)
+		r.Post("/comments/{id}/approve", c.approveComment) // Publish a held comment
+	})
+
+	reportRouter := chi.NewRouter()
+	reportRouter.With(requireUser).Post("/", c.createReport)
+	reportRouter.Group(func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Get("/", c.listReports)
+		r.Get("/audit", c.listModerationActions)
+		r.Get("/{id}/target", c.getReportTarget) // Includes hidden content
+		r.Post("/{id}/resolve", c.resolveReport)
+	})
+
+	// Admin routes for editing and previewing debate prompt templates
+	promptTemplateRouter := chi.NewRouter()
+	promptTemplateRouter.Use(requireAdmin)
+	promptTemplateRouter.Get("/", c.listPromptTemplates)
+	promptTemplateRouter.Get("/defaults", c.getDefaultPromptTemplates)
+	promptTemplateRouter.Post("/", c.createPromptTemplate)
+	promptTemplateRouter.Post("/{id}/preview", c.previewPromptTemplate)
+	promptTemplateRouter.Post("/{id}/activate", c.activatePromptTemplate)
+	promptTemplateRouter.Post("/{id}/deactivate", c.deactivatePromptTemplate)
+
+	// Admin routes for A/B experiments over debate generation
+	experimentRouter := chi.NewRouter()
+	experimentRouter.Use(requireAdmin)
+	experimentRouter.Get("/", c.listExperiments)
+	experimentRouter.Post("/", c.createExperiment)
+	experimentRouter.Get("/{id}", c.getExperiment)
+	experimentRouter.Get("/{id}/report", c.getExperimentReport)
+	experimentRouter.Post("/{id}/start", c.startExperiment)
+	experimentRouter.Post("/{id}/stop", c.stopExperiment)
+
+	// Admin routes for LLM token usage and cost
+	llmUsageRouter := chi.NewRouter()
+	llmUsageRouter.Use(requireAdmin)
+	llmUsageRouter.Get("/daily", c.getDailyLLMUsage)
+	llmUsageRouter.Get("/monthly", c.getMonthlyLLMUsage)
 
 	// Teams routes
 	teamsRouter := chi.NewRouter()
-	teamsRouter.Post("/", teamsService.CreateTeam)
 	teamsRouter.Get("/", teamsService.ListTeams)
 	teamsRouter.Get("/{id}", teamsService.GetTeam)
-	teamsRouter.Put("/{id}", teamsService.UpdateTeam)
-	teamsRouter.Delete("/{id}", teamsService.DeleteTeam)
 	teamsRouter.Get("/{id}/stats", teamsService.GetTeamStats)
+	teamsRouter.Group(func(r chi.Router) {
+		r.Use(requireUser)
+		r.Post("/", teamsService.CreateTeam)
+		r.Put("/{id}", teamsService.UpdateTeam)
+		r.Delete("/{id}", teamsService.DeleteTeam)
+	})
 
 	// Team Managers routes
 	teamManagersRouter := chi.NewRouter()
-	teamManagersRouter.Post("/", teamManagersService.CreateTeamManager)
 	teamManagersRouter.Get("/", teamManagersService.ListTeamManagers)
 	teamManagersRouter.Get("/{id}", teamManagersService.GetTeamManager)
-	teamManagersRouter.Put("/{id}", teamManagersService.UpdateTeamManager)
-	teamManagersRouter.Delete("/{id}", teamManagersService.DeleteTeamManager)
 	teamManagersRouter.Get("/{id}/stats", teamManagersService.GetManagerStats)
+	teamManagersRouter.Group(func(r chi.Router) {
+		r.Use(requireUser)
+		r.Post("/", teamManagersService.CreateTeamManager)
+		r.Put("/{id}", teamManagersService.UpdateTeamManager)
+		r.Delete("/{id}", teamManagersService.DeleteTeamManager)
+	})
 
 	// Leagues routes
 	leaguesRouter := chi.NewRouter()
-	leaguesRouter.Post("/", leaguesService.CreateLeague)
 	leaguesRouter.Get("/", leaguesService.ListLeagues)
 	leaguesRouter.Get("/{id}", leaguesService.GetLeague)
-	leaguesRouter.Put("/{id}", leaguesService.UpdateLeague)
-	leaguesRouter.Delete("/{id}", leaguesService.DeleteLeague)
 	leaguesRouter.Get("/{id}/stats", leaguesService.GetLeagueStats)
+	leaguesRouter.Group(func(r chi.Router) {
+		r.Use(requireUser)
+		r.Post("/", leaguesService.CreateLeague)
+		r.Put("/{id}", leaguesService.UpdateLeague)
+		r.Delete("/{id}", leaguesService.DeleteLeague)
+	})
 
 	// Player Profiles routes
 	playerProfilesRouter := chi.NewRouter()
-	playerProfilesRouter.Post("/", playerProfilesService.CreatePlayerProfile)
 	playerProfilesRouter.Get("/{id}", playerProfilesService.GetPlayerProfile)
-	playerProfilesRouter.Put("/{id}", playerProfilesService.UpdatePlayerProfile)
-	playerProfilesRouter.Delete("/{id}", playerProfilesService.DeletePlayerProfile)
+	playerProfilesRouter.Group(func(r chi.Router) {
+		r.Use(requireUser)
+		r.Post("/", playerProfilesService.CreatePlayerProfile)
+		r.Put("/{id}", playerProfilesService.UpdatePlayerProfile)
+		r.Delete("/{id}", playerProfilesService.DeletePlayerProfile)
+	})
 
 	// Verifications routes
 	verificationsRouter := chi.NewRouter()
-	verificationsRouter.Post("/", verificationsService.AddVerification)
-	verificationsRouter.Delete("/{id}", verificationsService.RemoveVerification)
 	verificationsRouter.Get("/player/{playerId}", verificationsService.ListVerifications)
+	verificationsRouter.Group(func(r chi.Router) {
+		r.Use(requireUser)
+		r.Post("/", verificationsService.AddVerification)
+		r.Delete("/{id}", verificationsService.RemoveVerification)
+	})
 
 	router.Mount("/users", userRouter)
+	router.Mount("/api-keys", apiKeyRouter)
 	router.Mount("/futbol", futbolRouter)
 	router.Mount("/google", googleRouter)
 	router.Mount("/debates", debateRouter)
+	router.Mount("/reports", reportRouter)
+	router.Mount("/prompt-templates", promptTemplateRouter)
+	router.Mount("/experiments", experimentRouter)
+	router.Mount("/llm-usage", llmUsageRouter)
 	router.Mount("/teams", teamsRouter)
 	router.Mount("/team-managers", teamManagersRouter)
 	router.Mount("/leagues", leaguesRouter)
@@ -125,11 +328,3 @@ func New(c Config) http.Handler {
 
 	return router
 }
-
-// getUserIDFromContext extracts user ID from request context
-// This is a placeholder - in a real app, this would extract from JWT token or session
-func getUserIDFromContext(r *http.Request) uuid.UUID {
-	// For now, return a default UUID
-	// In a real implementation, this would extract from JWT token or session
-	return uuid.MustParse("00000000-0000-0000-0000-000000000001")
-}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/auth"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+const maxAPIKeyNameLength = 100
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty"`
+}
+
+type APIKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyResponse carries the plaintext key, which is only ever shown once
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+func newAPIKeyResponse(apiKey database.ApiKey) APIKeyResponse {
+	response := APIKeyResponse{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.KeyPrefix,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if response.Scopes == nil {
+		response.Scopes = []string{}
+	}
+	if apiKey.LastUsedAt.Valid {
+		response.LastUsedAt = &apiKey.LastUsedAt.Time
+	}
+	if apiKey.ExpiresAt.Valid {
+		response.ExpiresAt = &apiKey.ExpiresAt.Time
+	}
+	if apiKey.RevokedAt.Valid {
+		response.RevokedAt = &apiKey.RevokedAt.Time
+	}
+	return response
+}
+
+// createAPIKey mints a new API key owned by the current user
+func (c *Config) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAPIKeyNameLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("name is required and must be at most %d characters", maxAPIKeyNameLength))
+		return
+	}
+	if len(req.Scopes) == 0 {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("at least one scope is required. Valid scopes: %s", strings.Join(auth.Scopes, ", ")))
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid scope %q. Valid scopes: %s", scope, strings.Join(auth.Scopes, ", ")))
+			return
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		respondWithError(w, http.StatusBadRequest, "expires_in_days must be positive")
+		return
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var expiresAt sql.NullTime
+	if req.ExpiresInDays > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().AddDate(0, 0, req.ExpiresInDays), Valid: true}
+	}
+
+	apiKey, err := c.DB.CreateAPIKey(ctx, database.CreateAPIKeyParams{
+		UserID:    user.ID,
+		Name:      req.Name,
+		KeyPrefix: prefix,
+		KeyHash:   hash,
+		Scopes:    req.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create API key: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, CreateAPIKeyResponse{
+		APIKeyResponse: newAPIKeyResponse(apiKey),
+		Key:            key,
+	})
+}
+
+// listAPIKeys returns the current user's API keys, including revoked ones
+func (c *Config) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	apiKeys, err := c.DB.ListAPIKeysByUser(ctx, user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list API keys: %v", err))
+		return
+	}
+
+	response := make([]APIKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		response = append(response, newAPIKeyResponse(apiKey))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// revokeAPIKey revokes an API key. Only its owner or an admin may revoke it.
+func (c *Config) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	apiKey, err := c.DB.GetAPIKey(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get API key: %v", err))
+		return
+	}
+
+	// Other users' keys are reported as missing rather than forbidden
+	if apiKey.UserID != user.ID && !user.IsAdmin {
+		respondWithError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+
+	if err := c.DB.RevokeAPIKey(ctx, id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to revoke API key: %v", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
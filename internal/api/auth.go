@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/auth"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/policy"
+)
+
+type contextKey string
+
+const (
+	userContextKey   contextKey = "user"
+	apiKeyContextKey contextKey = "api_key"
+)
+
+// authenticate resolves the bearer token or API key on the request, if any,
+// and stores the authenticated user or key on the request context. Requests
+// without an Authorization header pass through anonymously; invalid
+// credentials get a 401.
+func (c *Config) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, err := auth.GetAPIKey(r.Header); err == nil {
+			c.authenticateAPIKey(w, r, next, key)
+			return
+		}
+
+		tokenString, err := auth.GetBearerToken(r.Header)
+		if errors.Is(err, auth.ErrNoAuthHeader) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		if c.JWTValidator == nil {
+			respondWithError(w, http.StatusUnauthorized, "Authentication is not configured")
+			return
+		}
+
+		claims, err := c.JWTValidator.Validate(tokenString)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		userID, err := strconv.ParseInt(claims.Subject, 10, 32)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid token subject")
+			return
+		}
+
+		user, err := c.DB.GetUser(r.Context(), int32(userID))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusUnauthorized, "User not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load user: %v", err))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withUser(r.Context(), user)))
+	})
+}
+
+// authenticateAPIKey resolves a partner API key. API keys never act as their
+// owner: they only grant the scopes they were minted with.
+func (c *Config) authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	ctx := r.Context()
+
+	apiKey, err := c.DB.GetAPIKeyByHash(ctx, auth.HashToken(key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load API key: %v", err))
+		return
+	}
+	if apiKey.RevokedAt.Valid {
+		respondWithError(w, http.StatusUnauthorized, "API key has been revoked")
+		return
+	}
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		respondWithError(w, http.StatusUnauthorized, "API key has expired")
+		return
+	}
+
+	// Usage tracking is best effort and must not fail the request
+	if err := c.DB.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		fmt.Printf("Failed to update API key last_used_at: %v\n", err)
+	}
+
+	next.ServeHTTP(w, r.WithContext(withAPIKey(ctx, apiKey)))
+}
+
+// requireUser rejects requests that were not authenticated by authenticate
+func requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := userFromContext(r.Context()); !ok {
+			respondWithError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdmin rejects requests from anyone but authenticated admins
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := userFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		if !user.IsAdmin {
+			respondWithError(w, http.StatusForbidden, "Admin access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireScope rejects API key requests whose key lacks scope. User and
+// anonymous requests are left to the route's own checks.
+func requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey, ok := apiKeyFromContext(r.Context()); ok && !hasScope(apiKey, scope) {
+				respondWithError(w, http.StatusForbidden, fmt.Sprintf("API key is missing the %s scope", scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireUserOrScope admits authenticated users and API keys holding scope
+func requireUserOrScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := userFromContext(r.Context()); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			apiKey, ok := apiKeyFromContext(r.Context())
+			if !ok {
+				respondWithError(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+			if !hasScope(apiKey, scope) {
+				respondWithError(w, http.StatusForbidden, fmt.Sprintf("API key is missing the %s scope", scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func hasScope(apiKey database.ApiKey, scope string) bool {
+	for _, s := range apiKey.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithPolicyError maps an error from the policy engine onto a response.
+// Denials are always a 403 with the engine's explanation as the error body,
+// and a missing record a 404 naming it.
+func respondWithPolicyError(w http.ResponseWriter, err error) {
+	var notFound *policy.NotFoundError
+	switch {
+	case errors.Is(err, policy.ErrForbidden):
+		respondWithError(w, http.StatusForbidden, err.Error())
+	case errors.As(err, &notFound):
+		respondWithError(w, http.StatusNotFound, notFound.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		respondWithError(w, http.StatusNotFound, "Not found")
+	default:
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to check permissions: %v", err))
+	}
+}
+
+// withUser returns a copy of ctx carrying the authenticated user
+func withUser(ctx context.Context, user database.User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// userFromContext returns the authenticated user stored on the request context
+func userFromContext(ctx context.Context) (database.User, bool) {
+	user, ok := ctx.Value(userContextKey).(database.User)
+	return user, ok
+}
+
+// withAPIKey returns a copy of ctx carrying the authenticated API key
+func withAPIKey(ctx context.Context, apiKey database.ApiKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey, apiKey)
+}
+
+// apiKeyFromContext returns the API key that authenticated the request
+func apiKeyFromContext(ctx context.Context) (database.ApiKey, bool) {
+	apiKey, ok := ctx.Value(apiKeyContextKey).(database.ApiKey)
+	return apiKey, ok
+}
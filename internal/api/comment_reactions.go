@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+)
+
+// maxReactionEmojiLength matches the comment_reactions.emoji column
+const maxReactionEmojiLength = 10
+
+type CommentReactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// CommentReactionCounts aggregates the likes and emoji reactions on a comment
+type CommentReactionCounts struct {
+	Likes  int            `json:"likes"`
+	Emojis map[string]int `json:"emojis,omitempty"`
+}
+
+// newCommentReactionCounts groups reaction count rows by comment
+func newCommentReactionCounts(rows []database.GetCommentReactionCountsRow) map[int32]CommentReactionCounts {
+	counts := make(map[int32]CommentReactionCounts)
+	for _, row := range rows {
+		reactions := counts[row.CommentID]
+		switch row.ReactionType {
+		case "like":
+			reactions.Likes = int(row.Count)
+		case "emoji":
+			if reactions.Emojis == nil {
+				reactions.Emojis = make(map[string]int)
+			}
+			if row.Emoji.Valid {
+				reactions.Emojis[row.Emoji.String] = int(row.Count)
+			}
+		}
+		counts[row.CommentID] = reactions
+	}
+	return counts
+}
+
+// getCommentReactions loads the reaction counts for the given comments
+func (c *Config) getCommentReactions(ctx context.Context, commentIDs []int32) (map[int32]CommentReactionCounts, error) {
+	rows, err := c.DB.GetCommentReactionCounts(ctx, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+	return newCommentReactionCounts(rows), nil
+}
+
+func (c *Config) likeComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	comment, ok := c.loadCommentParam(w, r)
+	if !ok {
+		return
+	}
+
+	err := c.DB.CreateCommentLike(ctx, database.CreateCommentLikeParams{
+		CommentID: comment.ID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to like comment: %v", err))
+		return
+	}
+
+	c.respondWithCommentReactions(w, r, comment, "Comment liked successfully")
+}
+
+func (c *Config) unlikeComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	comment, ok := c.loadCommentParam(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := c.DB.DeleteCommentLike(ctx, database.DeleteCommentLikeParams{
+		CommentID: comment.ID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to unlike comment: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Like not found")
+		return
+	}
+
+	c.respondWithCommentReactions(w, r, comment, "Comment unliked successfully")
+}
+
+func (c *Config) reactToComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	comment, ok := c.loadCommentParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req CommentReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	emoji := strings.TrimSpace(req.Emoji)
+	if emoji == "" {
+		respondWithError(w, http.StatusBadRequest, "emoji is required")
+		return
+	}
+	if utf8.RuneCountInString(emoji) > maxReactionEmojiLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("emoji must be at most %d characters", maxReactionEmojiLength))
+		return
+	}
+
+	err := c.DB.CreateCommentEmojiReaction(ctx, database.CreateCommentEmojiReactionParams{
+		CommentID: comment.ID,
+		UserID:    user.ID,
+		Emoji:     sql.NullString{String: emoji, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to react to comment: %v", err))
+		return
+	}
+
+	c.respondWithCommentReactions(w, r, comment, "Reaction added successfully")
+}
+
+// deleteCommentReaction removes the caller's emoji reaction given by the
+// emoji query parameter
+func (c *Config) deleteCommentReaction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	comment, ok := c.loadCommentParam(w, r)
+	if !ok {
+		return
+	}
+
+	emoji := strings.TrimSpace(r.URL.Query().Get("emoji"))
+	if emoji == "" {
+		respondWithError(w, http.StatusBadRequest, "emoji parameter is required")
+		return
+	}
+
+	deleted, err := c.DB.DeleteCommentEmojiReaction(ctx, database.DeleteCommentEmojiReactionParams{
+		CommentID: comment.ID,
+		UserID:    user.ID,
+		Emoji:     sql.NullString{String: emoji, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove reaction: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Reaction not found")
+		return
+	}
+
+	c.respondWithCommentReactions(w, r, comment, "Reaction removed successfully")
+}
+
+// respondWithCommentReactions refreshes the debate's analytics after a
+// reaction changed and responds with the comment's new counts
+func (c *Config) respondWithCommentReactions(w http.ResponseWriter, r *http.Request, comment database.GetCommentRow, message string) {
+	ctx := r.Context()
+
+	c.updateDebateAnalytics(ctx, comment.DebateID.Int32)
+
+	reactions, err := c.getCommentReactions(ctx, []int32{comment.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment reactions: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":    message,
+		"comment_id": comment.ID,
+		"reactions":  reactions[comment.ID],
+	})
+}
@@ -0,0 +1,637 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/policy"
+	"github.com/go-chi/chi"
+)
+
+// Comment tree sort modes
+const (
+	commentSortNewest    = "newest"
+	commentSortOldest    = "oldest"
+	commentSortMostLiked = "most_liked"
+)
+
+const (
+	defaultCommentTreeDepth = 3
+	maxCommentTreeDepth     = 10
+	defaultCommentPageSize  = 20
+	maxCommentPageSize      = 100
+)
+
+// CommentNode is a comment with its replies nested beneath it. ReplyCount
+// counts direct replies, including any cut off by the requested depth.
+type CommentNode struct {
+	CommentResponse
+	Depth      int            `json:"depth"`
+	ReplyCount int            `json:"reply_count"`
+	Replies    []*CommentNode `json:"replies"`
+}
+
+type CommentTreeResponse struct {
+	Comments   []*CommentNode `json:"comments"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	HasMore    bool           `json:"has_more"`
+}
+
+// commentCursor marks the last top-level comment of a page. Likes is only
+// used when sorting by most liked.
+type commentCursor struct {
+	CreatedAt time.Time
+	ID        int32
+	Likes     int64
+}
+
+func (c commentCursor) encode() string {
+	raw := fmt.Sprintf("%d:%d:%d", c.CreatedAt.UnixMicro(), c.ID, c.Likes)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCommentCursor(s string) (commentCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return commentCursor{}, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return commentCursor{}, fmt.Errorf("invalid cursor")
+	}
+	micros, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return commentCursor{}, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil || id <= 0 {
+		return commentCursor{}, fmt.Errorf("invalid cursor")
+	}
+	likes, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return commentCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return commentCursor{CreatedAt: time.UnixMicro(micros).UTC(), ID: int32(id), Likes: likes}, nil
+}
+
+// getCommentTree returns a page of a debate's top-level comments with their
+// replies nested up to depth levels below them
+func (c *Config) getCommentTree(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	debateID, err := strconv.ParseInt(chi.URLParam(r, "debateId"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid debate ID")
+		return
+	}
+
+	query := r.URL.Query()
+	sortMode := query.Get("sort")
+	if sortMode == "" {
+		sortMode = commentSortNewest
+	}
+	if sortMode != commentSortNewest && sortMode != commentSortOldest && sortMode != commentSortMostLiked {
+		respondWithError(w, http.StatusBadRequest, "sort must be 'newest', 'oldest' or 'most_liked'")
+		return
+	}
+
+	depth, err := strconv.Atoi(query.Get("depth"))
+	if err != nil || depth < 0 {
+		depth = defaultCommentTreeDepth
+	}
+	if depth > maxCommentTreeDepth {
+		depth = maxCommentTreeDepth
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultCommentPageSize
+	}
+	if limit > maxCommentPageSize {
+		limit = maxCommentPageSize
+	}
+
+	var cursor commentCursor
+	if s := query.Get("cursor"); s != "" {
+		if cursor, err = decodeCommentCursor(s); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	roots, err := c.DB.ListRootComments(ctx, database.ListRootCommentsParams{
+		DebateID:        sql.NullInt32{Int32: int32(debateID), Valid: true},
+		CursorID:        cursor.ID,
+		Sort:            sortMode,
+		CursorCreatedAt: cursor.CreatedAt,
+		CursorLikes:     cursor.Likes,
+		PageSize:        int32(limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comments: %v", err))
+		return
+	}
+
+	response := CommentTreeResponse{Comments: []*CommentNode{}}
+	if len(roots) > limit {
+		roots = roots[:limit]
+		last := roots[len(roots)-1]
+		response.HasMore = true
+		response.NextCursor = commentCursor{CreatedAt: last.CreatedAt.Time, ID: last.ID, Likes: last.LikeCount}.encode()
+	}
+
+	rootIDs := make([]int32, len(roots))
+	for i, row := range roots {
+		rootIDs[i] = row.ID
+		response.Comments = append(response.Comments, &CommentNode{
+			CommentResponse: newCommentResponse(database.GetCommentsRow{
+				ID:              row.ID,
+				DebateID:        row.DebateID,
+				ParentCommentID: row.ParentCommentID,
+				UserID:          row.UserID,
+				Content:         row.Content,
+				CreatedAt:       row.CreatedAt,
+				UpdatedAt:       row.UpdatedAt,
+				DeletedAt:       row.DeletedAt,
+				HiddenAt:        row.HiddenAt,
+				Firstname:       row.Firstname,
+				Lastname:        row.Lastname,
+			}),
+			ReplyCount: int(row.ReplyCount),
+			Replies:    []*CommentNode{},
+		})
+	}
+
+	nodes := make(map[int32]*CommentNode)
+	for _, root := range response.Comments {
+		nodes[root.ID] = root
+	}
+	if depth > 0 && len(rootIDs) > 0 {
+		replies, err := c.DB.ListCommentReplies(ctx, database.ListCommentRepliesParams{
+			RootIds:  rootIDs,
+			MaxDepth: int32(depth),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment replies: %v", err))
+			return
+		}
+		nodes = attachCommentReplies(response.Comments, replies)
+	}
+
+	if len(nodes) > 0 {
+		commentIDs := make([]int32, 0, len(nodes))
+		for id := range nodes {
+			commentIDs = append(commentIDs, id)
+		}
+		reactions, err := c.getCommentReactions(ctx, commentIDs)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment reactions: %v", err))
+			return
+		}
+		for id, node := range nodes {
+			node.Reactions = reactions[id]
+		}
+	}
+	sortCommentReplies(response.Comments, sortMode)
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// attachCommentReplies nests replies under their parents, which are either
+// roots or earlier replies, and returns every node in the tree by ID
+func attachCommentReplies(roots []*CommentNode, replies []database.ListCommentRepliesRow) map[int32]*CommentNode {
+	nodes := make(map[int32]*CommentNode, len(roots)+len(replies))
+	for _, root := range roots {
+		nodes[root.ID] = root
+	}
+
+	// Replies arrive oldest first, so a parent is always indexed before its
+	// children
+	for _, row := range replies {
+		parent, ok := nodes[row.ParentCommentID.Int32]
+		if !ok {
+			continue
+		}
+		node := &CommentNode{
+			CommentResponse: newCommentResponse(database.GetCommentsRow{
+				ID:              row.ID,
+				DebateID:        row.DebateID,
+				ParentCommentID: row.ParentCommentID,
+				UserID:          row.UserID,
+				Content:         row.Content,
+				CreatedAt:       row.CreatedAt,
+				UpdatedAt:       row.UpdatedAt,
+				DeletedAt:       row.DeletedAt,
+				HiddenAt:        row.HiddenAt,
+				Firstname:       row.Firstname,
+				Lastname:        row.Lastname,
+			}),
+			Depth:      int(row.Depth),
+			ReplyCount: int(row.ReplyCount),
+			Replies:    []*CommentNode{},
+		}
+		parent.Replies = append(parent.Replies, node)
+		nodes[row.ID] = node
+	}
+
+	return nodes
+}
+
+// sortCommentReplies orders the replies at every level by sortMode. Replies
+// are already oldest first.
+func sortCommentReplies(nodes []*CommentNode, sortMode string) {
+	for _, node := range nodes {
+		replies := node.Replies
+		switch sortMode {
+		case commentSortNewest:
+			sort.SliceStable(replies, func(i, j int) bool {
+				if !replies[i].CreatedAt.Equal(replies[j].CreatedAt) {
+					return replies[i].CreatedAt.After(replies[j].CreatedAt)
+				}
+				return replies[i].ID > replies[j].ID
+			})
+		case commentSortMostLiked:
+			sort.SliceStable(replies, func(i, j int) bool {
+				if replies[i].Reactions.Likes != replies[j].Reactions.Likes {
+					return replies[i].Reactions.Likes > replies[j].Reactions.Likes
+				}
+				return replies[i].ID > replies[j].ID
+			})
+		}
+		sortCommentReplies(replies, sortMode)
+	}
+}
+
+// removedCommentPlaceholder and hiddenCommentPlaceholder replace the content
+// of soft-deleted and moderated comments so their replies keep a parent to
+// hang from
+const (
+	removedCommentPlaceholder = "[removed]"
+	hiddenCommentPlaceholder  = "[hidden]"
+)
+
+func newCommentResponse(comment database.GetCommentsRow) CommentResponse {
+	response := CommentResponse{
+		ID:            comment.ID,
+		DebateID:      comment.DebateID.Int32,
+		UserID:        comment.UserID.Int32,
+		UserFirstName: comment.Firstname,
+		UserLastName:  comment.Lastname,
+		Content:       comment.Content,
+		CreatedAt:     comment.CreatedAt.Time,
+		UpdatedAt:     comment.UpdatedAt.Time,
+	}
+	if comment.ParentCommentID.Valid {
+		response.ParentCommentID = &comment.ParentCommentID.Int32
+	}
+	switch {
+	case comment.DeletedAt.Valid:
+		response.Removed = true
+		response.Content = removedCommentPlaceholder
+	case comment.HiddenAt.Valid:
+		response.Hidden = true
+		response.Content = hiddenCommentPlaceholder
+	default:
+		return response
+	}
+	response.UserID = 0
+	response.UserFirstName = ""
+	response.UserLastName = ""
+	return response
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content"`
+}
+
+type CommentEditResponse struct {
+	ID              int32     `json:"id"`
+	CommentID       int32     `json:"comment_id"`
+	EditorID        *int32    `json:"editor_id,omitempty"`
+	PreviousContent string    `json:"previous_content"`
+	EditedAt        time.Time `json:"edited_at"`
+}
+
+// ModerationCommentResponse shows admins a comment as written, even when it
+// has been removed or hidden
+type ModerationCommentResponse struct {
+	CommentResponse
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy        *int32     `json:"deleted_by,omitempty"`
+	HiddenAt         *time.Time `json:"hidden_at,omitempty"`
+	ModerationStatus string     `json:"moderation_status"`
+	ModerationReason string     `json:"moderation_reason,omitempty"`
+	EditCount        int        `json:"edit_count"`
+}
+
+// Moderation queue filters
+const (
+	moderationStatusRemoved = "removed"
+	moderationStatusEdited  = "edited"
+	moderationStatusHidden  = "hidden"
+	moderationStatusHeld    = "held"
+)
+
+// updateComment replaces a comment's content, keeping the old content in its
+// edit history
+func (c *Config) updateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	comment, ok := c.loadCommentParam(w, r)
+	if !ok {
+		return
+	}
+	if err := c.Policy.AuthorizeComment(user, comment.UserID.Int32, policy.ActionUpdate); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
+
+	var req UpdateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		respondWithError(w, http.StatusBadRequest, "content is required")
+		return
+	}
+
+	if req.Content != comment.Content {
+		// Edits are screened like new comments
+		moderated := c.moderateComment(r, req.Content)
+
+		tx, err := c.DBConn.BeginTx(ctx, nil)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start transaction: %v", err))
+			return
+		}
+		defer tx.Rollback()
+		qtx := c.DB.WithTx(tx)
+
+		_, err = qtx.CreateCommentEdit(ctx, database.CreateCommentEditParams{
+			CommentID:       comment.ID,
+			EditorID:        sql.NullInt32{Int32: user.ID, Valid: true},
+			PreviousContent: comment.Content,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to record comment edit: %v", err))
+			return
+		}
+
+		updated, err := qtx.UpdateComment(ctx, database.UpdateCommentParams{
+			ID:               comment.ID,
+			Content:          moderated.Content,
+			ModerationStatus: commentModerationStatus(moderated.Decision),
+			ModerationReason: sql.NullString{String: moderated.Reason, Valid: moderated.Reason != ""},
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update comment: %v", err))
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to commit transaction: %v", err))
+			return
+		}
+		comment.Content = updated.Content
+		comment.UpdatedAt = updated.UpdatedAt
+		comment.HiddenAt = updated.HiddenAt
+		comment.ModerationStatus = updated.ModerationStatus
+		comment.ModerationReason = updated.ModerationReason
+	}
+
+	response := newCommentResponse(database.GetCommentsRow(comment))
+	if reactions, err := c.getCommentReactions(ctx, []int32{comment.ID}); err == nil {
+		response.Reactions = reactions[comment.ID]
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// deleteComment soft deletes a comment. Its replies stay in place beneath a
+// "[removed]" placeholder.
+func (c *Config) deleteComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	comment, ok := c.loadCommentParam(w, r)
+	if !ok {
+		return
+	}
+	if err := c.Policy.AuthorizeComment(user, comment.UserID.Int32, policy.ActionDelete); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
+
+	deleted, err := c.DB.SoftDeleteComment(ctx, database.SoftDeleteCommentParams{
+		ID:        comment.ID,
+		DeletedBy: sql.NullInt32{Int32: user.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete comment: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Comment not found")
+		return
+	}
+
+	// Admins removing someone else's comment are acting as moderators
+	if comment.UserID.Int32 != user.ID {
+		c.recordModerationAction(ctx, database.CreateModerationActionParams{
+			ModeratorID: sql.NullInt32{Int32: user.ID, Valid: true},
+			Action:      moderationActionDeleteComment,
+			TargetType:  reportTargetComment,
+			TargetID:    comment.ID,
+		})
+	}
+
+	// Update analytics
+	c.updateDebateAnalytics(ctx, comment.DebateID.Int32)
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Comment deleted successfully"})
+}
+
+// getCommentEdits lists a comment's previous versions, newest first. Only the
+// author and admins may see them.
+func (c *Config) getCommentEdits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	comment, ok := c.loadCommentParam(w, r)
+	if !ok {
+		return
+	}
+	if err := c.Policy.AuthorizeComment(user, comment.UserID.Int32, policy.ActionUpdate); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
+
+	edits, err := c.DB.ListCommentEdits(ctx, comment.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment edits: %v", err))
+		return
+	}
+
+	response := make([]CommentEditResponse, 0, len(edits))
+	for _, edit := range edits {
+		editResponse := CommentEditResponse{
+			ID:              edit.ID,
+			CommentID:       edit.CommentID,
+			PreviousContent: edit.PreviousContent,
+			EditedAt:        edit.EditedAt,
+		}
+		if edit.EditorID.Valid {
+			editResponse.EditorID = &edit.EditorID.Int32
+		}
+		response = append(response, editResponse)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// listCommentModerationQueue lists removed, edited, hidden or held comments
+// for admin review
+func (c *Config) listCommentModerationQueue(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	if status == "" {
+		status = moderationStatusRemoved
+	}
+	switch status {
+	case moderationStatusRemoved, moderationStatusEdited, moderationStatusHidden, moderationStatusHeld:
+	default:
+		respondWithError(w, http.StatusBadRequest, "status must be 'removed', 'edited', 'hidden' or 'held'")
+		return
+	}
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	comments, err := c.DB.ListModerationComments(r.Context(), database.ListModerationCommentsParams{
+		Status:     status,
+		PageSize:   int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list comments: %v", err))
+		return
+	}
+
+	response := make([]ModerationCommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		item := ModerationCommentResponse{
+			CommentResponse: CommentResponse{
+				ID:            comment.ID,
+				DebateID:      comment.DebateID.Int32,
+				UserID:        comment.UserID.Int32,
+				UserFirstName: comment.Firstname,
+				UserLastName:  comment.Lastname,
+				Content:       comment.Content,
+				Removed:       comment.DeletedAt.Valid,
+				Hidden:        comment.HiddenAt.Valid,
+				CreatedAt:     comment.CreatedAt.Time,
+				UpdatedAt:     comment.UpdatedAt.Time,
+			},
+			ModerationStatus: comment.ModerationStatus,
+			ModerationReason: comment.ModerationReason.String,
+			EditCount:        int(comment.EditCount),
+		}
+		if comment.ParentCommentID.Valid {
+			item.ParentCommentID = &comment.ParentCommentID.Int32
+		}
+		if comment.DeletedAt.Valid {
+			item.DeletedAt = &comment.DeletedAt.Time
+		}
+		if comment.DeletedBy.Valid {
+			item.DeletedBy = &comment.DeletedBy.Int32
+		}
+		if comment.HiddenAt.Valid {
+			item.HiddenAt = &comment.HiddenAt.Time
+		}
+		response = append(response, item)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// restoreComment brings back a soft-deleted comment
+func (c *Config) restoreComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	commentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	restored, err := c.DB.RestoreComment(ctx, int32(commentID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to restore comment: %v", err))
+		return
+	}
+	if restored == 0 {
+		respondWithError(w, http.StatusNotFound, "Removed comment not found")
+		return
+	}
+
+	c.recordModerationAction(ctx, database.CreateModerationActionParams{
+		ModeratorID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Action:      moderationActionRestoreComment,
+		TargetType:  reportTargetComment,
+		TargetID:    int32(commentID),
+	})
+
+	// Update analytics
+	if comment, err := c.DB.GetComment(ctx, int32(commentID)); err == nil {
+		c.updateDebateAnalytics(ctx, comment.DebateID.Int32)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Comment restored successfully"})
+}
+
+// loadCommentParam loads the live comment named by the {id} URL parameter,
+// writing the error response when it cannot. Removed and hidden comments are
+// reported as not found.
+func (c *Config) loadCommentParam(w http.ResponseWriter, r *http.Request) (database.GetCommentRow, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return database.GetCommentRow{}, false
+	}
+
+	comment, err := c.DB.GetComment(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Comment not found")
+			return database.GetCommentRow{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment: %v", err))
+		return database.GetCommentRow{}, false
+	}
+	if comment.DeletedAt.Valid || comment.HiddenAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Comment not found")
+		return database.GetCommentRow{}, false
+	}
+	return comment, true
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+)
+
+func TestCommentCursorRoundTrip(t *testing.T) {
+	cursor := commentCursor{CreatedAt: time.Date(2026, 5, 1, 18, 30, 0, 123456000, time.UTC), ID: 42, Likes: 7}
+
+	decoded, err := decodeCommentCursor(cursor.encode())
+	if err != nil {
+		t.Fatalf("Expected cursor to decode, got %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID || decoded.Likes != cursor.Likes {
+		t.Errorf("Expected %+v, got %+v", cursor, decoded)
+	}
+
+	for _, invalid := range []string{"not base64!", "MTIz", "YWJjOjE6MA", "MTox"} {
+		if _, err := decodeCommentCursor(invalid); err == nil {
+			t.Errorf("Expected cursor %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestAttachCommentReplies(t *testing.T) {
+	base := time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+	reply := func(id, parent int32, depth int32, minutes int) database.ListCommentRepliesRow {
+		return database.ListCommentRepliesRow{
+			ID:              id,
+			ParentCommentID: sql.NullInt32{Int32: parent, Valid: true},
+			CreatedAt:       sql.NullTime{Time: base.Add(time.Duration(minutes) * time.Minute), Valid: true},
+			Depth:           depth,
+		}
+	}
+	newRoots := func() []*CommentNode {
+		return []*CommentNode{{CommentResponse: CommentResponse{ID: 1}, ReplyCount: 2, Replies: []*CommentNode{}}}
+	}
+	replies := []database.ListCommentRepliesRow{
+		reply(2, 1, 1, 1),
+		reply(3, 1, 1, 2),
+		reply(4, 2, 2, 3),
+	}
+
+	t.Run("oldest first", func(t *testing.T) {
+		roots := newRoots()
+		attachCommentReplies(roots, replies)
+		sortCommentReplies(roots, commentSortOldest)
+
+		if len(roots[0].Replies) != 2 || roots[0].Replies[0].ID != 2 || roots[0].Replies[1].ID != 3 {
+			t.Fatalf("Expected replies 2 then 3, got %+v", roots[0].Replies)
+		}
+		nested := roots[0].Replies[0].Replies
+		if len(nested) != 1 || nested[0].ID != 4 || nested[0].Depth != 2 {
+			t.Errorf("Expected reply 4 nested under 2 at depth 2, got %+v", nested)
+		}
+	})
+
+	t.Run("newest first", func(t *testing.T) {
+		roots := newRoots()
+		attachCommentReplies(roots, replies)
+		sortCommentReplies(roots, commentSortNewest)
+
+		if roots[0].Replies[0].ID != 3 || roots[0].Replies[1].ID != 2 {
+			t.Errorf("Expected replies 3 then 2, got %d then %d", roots[0].Replies[0].ID, roots[0].Replies[1].ID)
+		}
+	})
+
+	t.Run("most liked first", func(t *testing.T) {
+		roots := newRoots()
+		nodes := attachCommentReplies(roots, replies)
+		if len(nodes) != 4 {
+			t.Fatalf("Expected 4 nodes, got %d", len(nodes))
+		}
+		nodes[3].Reactions.Likes = 5
+		sortCommentReplies(roots, commentSortMostLiked)
+
+		if roots[0].Replies[0].ID != 3 || roots[0].Replies[1].ID != 2 {
+			t.Errorf("Expected replies 3 then 2, got %d then %d", roots[0].Replies[0].ID, roots[0].Replies[1].ID)
+		}
+	})
+}
+
+func TestNewCommentResponseRemoved(t *testing.T) {
+	comment := database.GetCommentsRow{
+		ID:              5,
+		DebateID:        sql.NullInt32{Int32: 1, Valid: true},
+		ParentCommentID: sql.NullInt32{Int32: 4, Valid: true},
+		UserID:          sql.NullInt32{Int32: 9, Valid: true},
+		Content:         "something rude",
+		Firstname:       "Ada",
+		Lastname:        "Lovelace",
+	}
+
+	live := newCommentResponse(comment)
+	if live.Removed || live.Content != comment.Content || live.UserID != 9 {
+		t.Errorf("Expected live comment to be shown as written, got %+v", live)
+	}
+
+	comment.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	removed := newCommentResponse(comment)
+	if !removed.Removed || removed.Content != removedCommentPlaceholder {
+		t.Errorf("Expected removed placeholder, got %+v", removed)
+	}
+	if removed.UserID != 0 || removed.UserFirstName != "" || removed.UserLastName != "" {
+		t.Errorf("Expected author to be withheld, got %+v", removed)
+	}
+	if removed.ParentCommentID == nil || *removed.ParentCommentID != 4 {
+		t.Error("Expected removed comment to keep its place in the thread")
+	}
+
+	comment.DeletedAt = sql.NullTime{}
+	comment.HiddenAt = sql.NullTime{Time: time.Now(), Valid: true}
+	hidden := newCommentResponse(comment)
+	if !hidden.Hidden || hidden.Removed || hidden.Content != hiddenCommentPlaceholder {
+		t.Errorf("Expected hidden placeholder, got %+v", hidden)
+	}
+	if hidden.UserID != 0 || hidden.UserFirstName != "" {
+		t.Errorf("Expected author of hidden comment to be withheld, got %+v", hidden)
+	}
+}
+
+func TestNewCommentReactionCounts(t *testing.T) {
+	rows := []database.GetCommentReactionCountsRow{
+		{CommentID: 1, ReactionType: "like", Count: 3},
+		{CommentID: 1, ReactionType: "emoji", Emoji: sql.NullString{String: "🔥", Valid: true}, Count: 2},
+		{CommentID: 2, ReactionType: "emoji", Emoji: sql.NullString{String: "😂", Valid: true}, Count: 1},
+	}
+
+	counts := newCommentReactionCounts(rows)
+	if counts[1].Likes != 3 || counts[1].Emojis["🔥"] != 2 {
+		t.Errorf("Expected 3 likes and 2 🔥 on comment 1, got %+v", counts[1])
+	}
+	if counts[2].Likes != 0 || counts[2].Emojis["😂"] != 1 {
+		t.Errorf("Expected no likes and 1 😂 on comment 2, got %+v", counts[2])
+	}
+	if _, ok := counts[3]; ok {
+		t.Error("Expected no counts for a comment without reactions")
+	}
+}
@@ -27,16 +27,6 @@ func (m *MockQueries) GetUser(ctx context.Context, id int32) (database.User, err
 	return args.Get(0).(database.User), args.Error(1)
 }
 
-func (m *MockQueries) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
-	args := m.Called(ctx, arg)
-	return args.Get(0).(database.User), args.Error(1)
-}
-
-func (m *MockQueries) ListUsers(ctx context.Context) ([]database.User, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]database.User), args.Error(1)
-}
-
 // Mock for Player Profile operations
 func (m *MockQueries) CreatePlayerProfile(ctx context.Context, arg database.CreatePlayerProfileParams) (database.PlayerProfile, error) {
 	args := m.Called(ctx, arg)
@@ -146,6 +136,17 @@ func (m *MockQueries) DeleteTeamManager(ctx context.Context, id uuid.UUID) error
 	return args.Error(0)
 }
 
+// Mock for Debate Job operations
+func (m *MockQueries) EnqueueDebateJob(ctx context.Context, arg database.EnqueueDebateJobParams) (database.DebateJob, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.DebateJob), args.Error(1)
+}
+
+func (m *MockQueries) GetActiveDebateJob(ctx context.Context, arg database.GetActiveDebateJobParams) (database.DebateJob, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.DebateJob), args.Error(1)
+}
+
 // Test-specific service structs that can accept the mock
 type TestPlayerProfileService struct {
 	DB *MockQueries
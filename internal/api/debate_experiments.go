@@ -0,0 +1,514 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/lib/pq"
+)
+
+// Experiment limits
+const (
+	minExperimentVariants          = 2
+	maxExperimentVariants          = 10
+	maxExperimentNameLength        = 100
+	maxExperimentVariantNameLength = 50
+	maxExperimentModelLength       = 100
+	maxExperimentTemperature       = 2.0
+)
+
+// Experiment statuses
+const (
+	experimentStatusDraft   = "draft"
+	experimentStatusRunning = "running"
+	experimentStatusStopped = "stopped"
+)
+
+// experimentConfidenceLevel is the confidence level of the report's intervals
+const experimentConfidenceLevel = 0.95
+
+// tCritical95 holds the two-sided 95% critical values of Student's t
+// distribution for 1 to 30 degrees of freedom
+var tCritical95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+type CreateExperimentVariantRequest struct {
+	Name string `json:"name"`
+	// Weight is the variant's share of generations relative to the others,
+	// 1 when unset
+	Weight                int32    `json:"weight"`
+	PromptTemplateVersion *int32   `json:"prompt_template_version"` // nil uses the active template
+	Model                 string   `json:"model"`                   // Empty uses the configured model
+	Temperature           *float64 `json:"temperature"`             // nil uses the default temperature
+}
+
+type CreateExperimentRequest struct {
+	Name        string                           `json:"name"`
+	Description string                           `json:"description"`
+	DebateType  string                           `json:"debate_type"`
+	Variants    []CreateExperimentVariantRequest `json:"variants"`
+}
+
+type ExperimentVariantResponse struct {
+	ID                    int32    `json:"id"`
+	Name                  string   `json:"name"`
+	Weight                int32    `json:"weight"`
+	PromptTemplateVersion *int32   `json:"prompt_template_version,omitempty"`
+	Model                 string   `json:"model,omitempty"`
+	Temperature           *float64 `json:"temperature,omitempty"`
+}
+
+type ExperimentResponse struct {
+	ID          int32                       `json:"id"`
+	Name        string                      `json:"name"`
+	Description string                      `json:"description,omitempty"`
+	DebateType  string                      `json:"debate_type"`
+	Status      string                      `json:"status"`
+	CreatedBy   *int32                      `json:"created_by,omitempty"`
+	CreatedAt   time.Time                   `json:"created_at"`
+	StartedAt   *time.Time                  `json:"started_at,omitempty"`
+	StoppedAt   *time.Time                  `json:"stopped_at,omitempty"`
+	Variants    []ExperimentVariantResponse `json:"variants,omitempty"`
+}
+
+// ConfidenceInterval bounds a mean at the report's confidence level
+type ConfidenceInterval struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// MetricSummary describes one engagement metric across a variant's debates.
+// The interval is omitted until the variant has two debates.
+type MetricSummary struct {
+	Mean               float64             `json:"mean"`
+	StdDev             float64             `json:"std_dev"`
+	ConfidenceInterval *ConfidenceInterval `json:"confidence_interval,omitempty"`
+}
+
+type ExperimentVariantReport struct {
+	VariantID       int32         `json:"variant_id"`
+	Name            string        `json:"name"`
+	Debates         int32         `json:"debates"`
+	EngagementScore MetricSummary `json:"engagement_score"`
+	Votes           MetricSummary `json:"votes"`
+	Comments        MetricSummary `json:"comments"`
+}
+
+type ExperimentReportResponse struct {
+	Experiment      ExperimentResponse        `json:"experiment"`
+	ConfidenceLevel float64                   `json:"confidence_level"`
+	Variants        []ExperimentVariantReport `json:"variants"`
+}
+
+func newExperimentResponse(experiment database.DebateExperiment, variants []database.DebateExperimentVariant) ExperimentResponse {
+	response := ExperimentResponse{
+		ID:          experiment.ID,
+		Name:        experiment.Name,
+		Description: experiment.Description.String,
+		DebateType:  experiment.DebateType,
+		Status:      experiment.Status,
+		CreatedAt:   experiment.CreatedAt,
+	}
+	if experiment.CreatedBy.Valid {
+		response.CreatedBy = &experiment.CreatedBy.Int32
+	}
+	if experiment.StartedAt.Valid {
+		response.StartedAt = &experiment.StartedAt.Time
+	}
+	if experiment.StoppedAt.Valid {
+		response.StoppedAt = &experiment.StoppedAt.Time
+	}
+	for _, variant := range variants {
+		variantResponse := ExperimentVariantResponse{
+			ID:     variant.ID,
+			Name:   variant.Name,
+			Weight: variant.Weight,
+			Model:  variant.Model.String,
+		}
+		if variant.PromptTemplateVersion.Valid {
+			variantResponse.PromptTemplateVersion = &variant.PromptTemplateVersion.Int32
+		}
+		if variant.Temperature.Valid {
+			variantResponse.Temperature = &variant.Temperature.Float64
+		}
+		response.Variants = append(response.Variants, variantResponse)
+	}
+	return response
+}
+
+// newMetricSummary summarises a metric from its mean and sample standard
+// deviation over n debates, with a t-distribution confidence interval
+func newMetricSummary(mean, stdDev float64, n int32) MetricSummary {
+	summary := MetricSummary{Mean: mean, StdDev: stdDev}
+	if n < 2 {
+		return summary
+	}
+	margin := tCritical(int(n)-1) * stdDev / math.Sqrt(float64(n))
+	summary.ConfidenceInterval = &ConfidenceInterval{Lower: mean - margin, Upper: mean + margin}
+	return summary
+}
+
+// tCritical returns the 95% critical value of Student's t distribution,
+// rounding degrees of freedom past the table down to keep intervals
+// conservative
+func tCritical(degreesOfFreedom int) float64 {
+	switch {
+	case degreesOfFreedom <= len(tCritical95):
+		return tCritical95[degreesOfFreedom-1]
+	case degreesOfFreedom <= 40:
+		return tCritical95[len(tCritical95)-1]
+	case degreesOfFreedom <= 60:
+		return 2.021
+	case degreesOfFreedom <= 120:
+		return 2.000
+	default:
+		return 1.980
+	}
+}
+
+// assignExperimentVariant picks a variant for a debate by weight. The pick
+// depends only on the key, so a retried or regenerated debate keeps its
+// variant.
+func assignExperimentVariant(variants []database.DebateExperimentVariant, key string) database.DebateExperimentVariant {
+	var totalWeight uint32
+	for _, variant := range variants {
+		totalWeight += uint32(variant.Weight)
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	point := hash.Sum32() % totalWeight
+	for _, variant := range variants {
+		if point < uint32(variant.Weight) {
+			return variant
+		}
+		point -= uint32(variant.Weight)
+	}
+	return variants[len(variants)-1]
+}
+
+// experimentVariant assigns a debate to a variant of the experiment running
+// for its debate type, if any, returning the variant and the generation
+// settings it stands for. Experiments never hold up generation: failures are
+// logged and the debate is generated outside the experiment.
+func (c *Config) experimentVariant(ctx context.Context, matchID, debateType string) (*database.DebateExperimentVariant, *ai.PromptVariant) {
+	variants, err := c.DB.GetRunningExperimentVariants(ctx, debateType)
+	if err != nil {
+		fmt.Printf("Failed to load %s experiment variants: %v\n", debateType, err)
+		return nil, nil
+	}
+	if len(variants) == 0 {
+		return nil, nil
+	}
+
+	variant := assignExperimentVariant(variants, fmt.Sprintf("%d:%s:%s", variants[0].ExperimentID, matchID, debateType))
+	promptVariant := &ai.PromptVariant{Model: variant.Model.String}
+	if variant.Temperature.Valid {
+		promptVariant.Temperature = &variant.Temperature.Float64
+	}
+	if variant.PromptTemplateVersion.Valid {
+		tmpl, err := c.promptTemplateByVersion(ctx, debateType, variant.PromptTemplateVersion.Int32)
+		if err != nil {
+			fmt.Printf("Failed to load prompt template for experiment variant %d: %v\n", variant.ID, err)
+			return nil, nil
+		}
+		promptVariant.Template = tmpl
+	}
+	return &variant, promptVariant
+}
+
+// promptTemplateByVersion loads a stored template version, or the built-in
+// template for version 0
+func (c *Config) promptTemplateByVersion(ctx context.Context, debateType string, version int32) (*ai.PromptTemplate, error) {
+	if version == ai.DefaultTemplateVersion {
+		return ai.DefaultPromptTemplate(debateType)
+	}
+	tmpl, err := c.DB.GetPromptTemplateByVersion(ctx, database.GetPromptTemplateByVersionParams{
+		DebateType: debateType,
+		Version:    version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newAIPromptTemplate(tmpl), nil
+}
+
+// validateCreateExperimentRequest checks an experiment's fields, returning
+// the message to send back when one is invalid
+func validateCreateExperimentRequest(req *CreateExperimentRequest) (string, bool) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxExperimentNameLength {
+		return fmt.Sprintf("name is required and must be at most %d characters", maxExperimentNameLength), false
+	}
+	if req.DebateType != "pre_match" && req.DebateType != "post_match" {
+		return "debate_type must be 'pre_match' or 'post_match'", false
+	}
+	if len(req.Variants) < minExperimentVariants || len(req.Variants) > maxExperimentVariants {
+		return fmt.Sprintf("an experiment needs between %d and %d variants", minExperimentVariants, maxExperimentVariants), false
+	}
+
+	names := make(map[string]bool)
+	for i := range req.Variants {
+		variant := &req.Variants[i]
+		variant.Name = strings.TrimSpace(variant.Name)
+		variant.Model = strings.TrimSpace(variant.Model)
+		if variant.Name == "" || len(variant.Name) > maxExperimentVariantNameLength {
+			return fmt.Sprintf("variants[%d].name is required and must be at most %d characters", i, maxExperimentVariantNameLength), false
+		}
+		if names[variant.Name] {
+			return fmt.Sprintf("variant name %q is used twice", variant.Name), false
+		}
+		names[variant.Name] = true
+		if variant.Weight == 0 {
+			variant.Weight = 1
+		}
+		if variant.Weight < 0 {
+			return fmt.Sprintf("variants[%d].weight must be positive", i), false
+		}
+		if variant.PromptTemplateVersion != nil && *variant.PromptTemplateVersion < 0 {
+			return fmt.Sprintf("variants[%d].prompt_template_version must not be negative", i), false
+		}
+		if len(variant.Model) > maxExperimentModelLength {
+			return fmt.Sprintf("variants[%d].model must be at most %d characters", i, maxExperimentModelLength), false
+		}
+		if variant.Temperature != nil && (*variant.Temperature < 0 || *variant.Temperature > maxExperimentTemperature) {
+			return fmt.Sprintf("variants[%d].temperature must be between 0 and %g", i, maxExperimentTemperature), false
+		}
+	}
+	return "", true
+}
+
+// createExperiment saves a draft experiment with its variants
+func (c *Config) createExperiment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	var req CreateExperimentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if message, ok := validateCreateExperimentRequest(&req); !ok {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+	for _, variant := range req.Variants {
+		if variant.PromptTemplateVersion == nil {
+			continue
+		}
+		if _, err := c.promptTemplateByVersion(ctx, req.DebateType, *variant.PromptTemplateVersion); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Variant %q uses %s prompt template version %d, which does not exist", variant.Name, req.DebateType, *variant.PromptTemplateVersion))
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get prompt template: %v", err))
+			return
+		}
+	}
+	req.Description = strings.TrimSpace(req.Description)
+
+	tx, err := c.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to begin transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := c.DB.WithTx(tx)
+
+	experiment, err := qtx.CreateDebateExperiment(ctx, database.CreateDebateExperimentParams{
+		Name:        req.Name,
+		Description: sql.NullString{String: req.Description, Valid: req.Description != ""},
+		DebateType:  req.DebateType,
+		CreatedBy:   sql.NullInt32{Int32: user.ID, Valid: true},
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, "An experiment with this name already exists")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create experiment: %v", err))
+		return
+	}
+
+	variants := make([]database.DebateExperimentVariant, 0, len(req.Variants))
+	for _, variantReq := range req.Variants {
+		params := database.CreateDebateExperimentVariantParams{
+			ExperimentID: experiment.ID,
+			Name:         variantReq.Name,
+			Weight:       variantReq.Weight,
+			Model:        sql.NullString{String: variantReq.Model, Valid: variantReq.Model != ""},
+		}
+		if variantReq.PromptTemplateVersion != nil {
+			params.PromptTemplateVersion = sql.NullInt32{Int32: *variantReq.PromptTemplateVersion, Valid: true}
+		}
+		if variantReq.Temperature != nil {
+			params.Temperature = sql.NullFloat64{Float64: *variantReq.Temperature, Valid: true}
+		}
+		variant, err := qtx.CreateDebateExperimentVariant(ctx, params)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create experiment variant: %v", err))
+			return
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newExperimentResponse(experiment, variants))
+}
+
+// listExperiments returns every experiment, newest first, without variants
+func (c *Config) listExperiments(w http.ResponseWriter, r *http.Request) {
+	experiments, err := c.DB.ListDebateExperiments(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list experiments: %v", err))
+		return
+	}
+
+	response := make([]ExperimentResponse, len(experiments))
+	for i, experiment := range experiments {
+		response[i] = newExperimentResponse(experiment, nil)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getExperimentFromURL loads the experiment named by the {id} URL parameter
+// and its variants, writing the error response when it cannot
+func (c *Config) getExperimentFromURL(w http.ResponseWriter, r *http.Request) (database.DebateExperiment, []database.DebateExperimentVariant, bool) {
+	ctx := r.Context()
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid experiment ID")
+		return database.DebateExperiment{}, nil, false
+	}
+
+	experiment, err := c.DB.GetDebateExperiment(ctx, int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Experiment not found")
+			return database.DebateExperiment{}, nil, false
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get experiment: %v", err))
+		return database.DebateExperiment{}, nil, false
+	}
+
+	variants, err := c.DB.ListDebateExperimentVariants(ctx, experiment.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get experiment variants: %v", err))
+		return database.DebateExperiment{}, nil, false
+	}
+	return experiment, variants, true
+}
+
+func (c *Config) getExperiment(w http.ResponseWriter, r *http.Request) {
+	experiment, variants, ok := c.getExperimentFromURL(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newExperimentResponse(experiment, variants))
+}
+
+// startExperiment starts assigning new debates of the experiment's type to
+// its variants. Only draft experiments can start, one per debate type.
+func (c *Config) startExperiment(w http.ResponseWriter, r *http.Request) {
+	experiment, variants, ok := c.getExperimentFromURL(w, r)
+	if !ok {
+		return
+	}
+	if experiment.Status != experimentStatusDraft {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Experiment is %s; only draft experiments can be started", experiment.Status))
+		return
+	}
+
+	started, err := c.DB.StartDebateExperiment(r.Context(), experiment.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("Another %s experiment is already running", experiment.DebateType))
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusConflict, "Experiment has already been started")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start experiment: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newExperimentResponse(started, variants))
+}
+
+// stopExperiment stops assigning debates to the experiment. Its debates and
+// report are kept.
+func (c *Config) stopExperiment(w http.ResponseWriter, r *http.Request) {
+	experiment, variants, ok := c.getExperimentFromURL(w, r)
+	if !ok {
+		return
+	}
+	if experiment.Status != experimentStatusRunning {
+		respondWithError(w, http.StatusConflict, "Experiment is not running")
+		return
+	}
+
+	stopped, err := c.DB.StopDebateExperiment(r.Context(), experiment.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusConflict, "Experiment is not running")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to stop experiment: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newExperimentResponse(stopped, variants))
+}
+
+// getExperimentReport compares the engagement of each variant's debates
+func (c *Config) getExperimentReport(w http.ResponseWriter, r *http.Request) {
+	experiment, variants, ok := c.getExperimentFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := c.DB.GetDebateExperimentReport(r.Context(), experiment.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get experiment report: %v", err))
+		return
+	}
+
+	response := ExperimentReportResponse{
+		Experiment:      newExperimentResponse(experiment, variants),
+		ConfidenceLevel: experimentConfidenceLevel,
+		Variants:        make([]ExperimentVariantReport, len(rows)),
+	}
+	for i, row := range rows {
+		response.Variants[i] = ExperimentVariantReport{
+			VariantID:       row.VariantID,
+			Name:            row.Name,
+			Debates:         row.Debates,
+			EngagementScore: newMetricSummary(row.AvgEngagementScore, row.StddevEngagementScore, row.Debates),
+			Votes:           newMetricSummary(row.AvgVotes, row.StddevVotes, row.Debates),
+			Comments:        newMetricSummary(row.AvgComments, row.StddevComments, row.Debates),
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
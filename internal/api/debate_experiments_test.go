@@ -0,0 +1,83 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+)
+
+func TestAssignExperimentVariant(t *testing.T) {
+	variants := []database.DebateExperimentVariant{
+		{ID: 1, Name: "control", Weight: 3},
+		{ID: 2, Name: "spicy", Weight: 1},
+	}
+
+	counts := make(map[int32]int)
+	for i := 0; i < 4000; i++ {
+		key := fmt.Sprintf("1:%d:pre_match", 1000000+i)
+		variant := assignExperimentVariant(variants, key)
+		if again := assignExperimentVariant(variants, key); again.ID != variant.ID {
+			t.Fatalf("Expected %s to keep variant %d, got %d", key, variant.ID, again.ID)
+		}
+		counts[variant.ID]++
+	}
+
+	// Weighted 3:1, so the control should get about 3000 of 4000
+	if counts[1] < 2800 || counts[1] > 3200 {
+		t.Errorf("Expected about 3000 control assignments, got %v", counts)
+	}
+}
+
+func TestNewMetricSummary(t *testing.T) {
+	summary := newMetricSummary(10, 4, 1)
+	if summary.ConfidenceInterval != nil {
+		t.Error("Expected no interval for a single debate")
+	}
+
+	// t(0.975, 3) = 3.182, so the margin is 3.182 * 4 / 2
+	summary = newMetricSummary(10, 4, 4)
+	if summary.ConfidenceInterval == nil {
+		t.Fatal("Expected an interval")
+	}
+	if math.Abs(summary.ConfidenceInterval.Lower-3.636) > 1e-9 || math.Abs(summary.ConfidenceInterval.Upper-16.364) > 1e-9 {
+		t.Errorf("Unexpected interval %+v", summary.ConfidenceInterval)
+	}
+
+	if got := tCritical(500); got != 1.980 {
+		t.Errorf("Expected 1.980 for large samples, got %v", got)
+	}
+}
+
+func TestValidateCreateExperimentRequest(t *testing.T) {
+	hot := 1.5
+	tooHot := 2.5
+	valid := CreateExperimentRequest{
+		Name:       " Spicy headlines ",
+		DebateType: "pre_match",
+		Variants: []CreateExperimentVariantRequest{
+			{Name: "control"},
+			{Name: "spicy", Weight: 2, Temperature: &hot},
+		},
+	}
+	if message, ok := validateCreateExperimentRequest(&valid); !ok {
+		t.Fatalf("Expected a valid request, got %q", message)
+	}
+	if valid.Name != "Spicy headlines" || valid.Variants[0].Weight != 1 {
+		t.Errorf("Expected the name trimmed and the weight defaulted, got %+v", valid)
+	}
+
+	invalid := []CreateExperimentRequest{
+		{Name: "One variant", DebateType: "pre_match", Variants: []CreateExperimentVariantRequest{{Name: "control"}}},
+		{Name: "Bad type", DebateType: "half_time", Variants: valid.Variants},
+		{Name: "Duplicate", DebateType: "pre_match", Variants: []CreateExperimentVariantRequest{{Name: "a"}, {Name: "a"}}},
+		{Name: "Too hot", DebateType: "pre_match", Variants: []CreateExperimentVariantRequest{{Name: "a"}, {Name: "b", Temperature: &tooHot}}},
+		{Name: "Negative weight", DebateType: "pre_match", Variants: []CreateExperimentVariantRequest{{Name: "a"}, {Name: "b", Weight: -1}}},
+	}
+	for _, req := range invalid {
+		if _, ok := validateCreateExperimentRequest(&req); ok {
+			t.Errorf("Expected %q to be rejected", req.Name)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// Debate job statuses, matching the debate_jobs.status check constraint
+const (
+	debateJobPending   = "pending"
+	debateJobRunning   = "running"
+	debateJobSucceeded = "succeeded"
+	debateJobFailed    = "failed"
+)
+
+const (
+	debateWorkerCount = 2
+	// debateWorkerPollInterval is how often idle workers check the queue for
+	// jobs queued by other instances or due for a retry
+	debateWorkerPollInterval = 5 * time.Second
+
+	debateJobMaxAttempts = 5
+	// debateJobLease is how long a claimed job may run before another worker
+	// may claim it again, e.g. after the instance running it was redeployed
+	debateJobLease       = 10 * time.Minute
+	debateJobBaseBackoff = time.Minute
+	debateJobMaxBackoff  = time.Hour
+)
+
+// errDebateJobLeaseLost means another worker claimed a job while this one was
+// still running it, so this worker's result is not stored
+var errDebateJobLeaseLost = errors.New("debate job lease was lost to another worker")
+
+// errCanonicalDebateExists means another job stored the canonical debate for
+// a match and type while this one was generating it
+var errCanonicalDebateExists = errors.New("a canonical debate already exists for the match")
+
+// permanentJobError marks a job failure that retrying cannot fix
+type permanentJobError struct {
+	error
+}
+
+// DebateJobResponse reports the progress of a debate generation job. Debate
+// is set once the job has succeeded.
+type DebateJobResponse struct {
+	ID         int32           `json:"id"`
+	MatchID    string          `json:"match_id"`
+	DebateType string          `json:"debate_type"`
+	Locale     string          `json:"locale"`
+	Status     string          `json:"status"` // "pending", "running", "succeeded" or "failed"
+	Attempts   int             `json:"attempts"`
+	Error      string          `json:"error,omitempty"`
+	DebateID   *int32          `json:"debate_id,omitempty"`
+	Debate     *DebateResponse `json:"debate,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+func newDebateJobResponse(job database.DebateJob) DebateJobResponse {
+	response := DebateJobResponse{
+		ID:         job.ID,
+		MatchID:    job.MatchID,
+		DebateType: job.DebateType,
+		Locale:     job.Locale,
+		Status:     job.Status,
+		Attempts:   int(job.Attempts),
+		Error:      job.LastError.String,
+		CreatedAt:  job.CreatedAt,
+		UpdatedAt:  job.UpdatedAt,
+	}
+	if job.DebateID.Valid {
+		response.DebateID = &job.DebateID.Int32
+	}
+	return response
+}
+
+// getDebateJob reports a debate generation job's status, including the
+// debate once it has been generated
+func (c *Config) getDebateJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jobID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := c.DB.GetDebateJob(ctx, int32(jobID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Debate job not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate job: %v", err))
+		return
+	}
+
+	response := newDebateJobResponse(job)
+	if job.Status == debateJobSucceeded && job.DebateID.Valid {
+		debate, err := c.loadDebateResponse(ctx, job.DebateID.Int32)
+		switch {
+		case err == nil:
+			response.Debate = &debate
+		case !errors.Is(err, sql.ErrNoRows): // The debate may since have been deleted
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate: %v", err))
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// requestDebateJobKey is a fresh idempotency key for a job requested through
+// the API
+func requestDebateJobKey() string {
+	return "request:" + uuid.NewString()
+}
+
+// debateJobQueue is the subset of database.Queries that enqueueing a job uses
+type debateJobQueue interface {
+	EnqueueDebateJob(ctx context.Context, arg database.EnqueueDebateJobParams) (database.DebateJob, error)
+	GetActiveDebateJob(ctx context.Context, arg database.GetActiveDebateJobParams) (database.DebateJob, error)
+}
+
+// enqueueDebateJob queues a job to generate a debate in a locale now and
+// returns it. A forced job replaces the match's existing debate of that type
+// and locale. When a job for the same debate is already queued or running,
+// that job is returned instead. When the key has been used before there is
+// nothing to do and it returns sql.ErrNoRows.
+func (c *Config) enqueueDebateJob(ctx context.Context, key, matchID, debateType, locale string, force bool) (database.DebateJob, error) {
+	job, queued, err := enqueueDebateJob(ctx, c.DB, key, matchID, debateType, locale, force)
+	if err != nil || !queued {
+		return job, err
+	}
+
+	// Start on the job now rather than at the next poll
+	select {
+	case c.debateJobWake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// enqueueDebateJob inserts the job, reporting whether it was queued rather
+// than an active job returned in its place
+func enqueueDebateJob(ctx context.Context, q debateJobQueue, key, matchID, debateType, locale string, force bool) (database.DebateJob, bool, error) {
+	// The active job that blocked the insert may finish before it can be
+	// read, freeing the way for ours, so the insert is tried once more
+	var job database.DebateJob
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		job, err = q.EnqueueDebateJob(ctx, database.EnqueueDebateJobParams{
+			IdempotencyKey:  key,
+			MatchID:         matchID,
+			DebateType:      debateType,
+			MaxAttempts:     debateJobMaxAttempts,
+			RunAt:           time.Now().UTC(),
+			ForceRegenerate: force,
+			Locale:          locale,
+		})
+		if err != sql.ErrNoRows {
+			return job, err == nil, err
+		}
+		job, err = q.GetActiveDebateJob(ctx, database.GetActiveDebateJobParams{
+			MatchID:    matchID,
+			DebateType: debateType,
+			Locale:     locale,
+		})
+		if err != sql.ErrNoRows {
+			return job, false, err
+		}
+	}
+	return job, false, err
+}
+
+// runDebateWorker runs queued debate jobs until ctx is cancelled
+func (c *Config) runDebateWorker(ctx context.Context) {
+	for {
+		if failed, err := c.DB.FailExpiredDebateJobs(ctx); err != nil {
+			log.Printf("Failed to fail expired debate jobs: %v\n", err)
+		} else if failed > 0 {
+			log.Printf("Failed %d debate jobs whose last attempt's lease expired\n", failed)
+		}
+		for c.runNextDebateJob(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.debateJobWake:
+		case <-time.After(debateWorkerPollInterval):
+		}
+	}
+}
+
+// runNextDebateJob claims and runs the next due job, reporting whether there
+// was one
+func (c *Config) runNextDebateJob(ctx context.Context) bool {
+	jobs, err := c.DB.ClaimDueDebateJobs(ctx, database.ClaimDueDebateJobsParams{
+		LockedUntil: sql.NullTime{Time: time.Now().UTC().Add(debateJobLease), Valid: true},
+		BatchSize:   1,
+	})
+	if err != nil {
+		log.Printf("Failed to claim debate jobs: %v\n", err)
+		return false
+	}
+	if len(jobs) == 0 {
+		return false
+	}
+
+	c.runDebateJob(ctx, jobs[0])
+	return true
+}
+
+// runDebateJob runs one claimed job and records the outcome, scheduling a
+// retry with exponential backoff when the failure may be temporary
+func (c *Config) runDebateJob(ctx context.Context, job database.DebateJob) {
+	// A job claimed once the monthly LLM budget is spent waits for it to
+	// reset rather than spending past it
+	if budget, err := c.llmBudget(ctx, time.Now()); err != nil {
+		log.Printf("Failed to check LLM budget: %v\n", err)
+	} else if budget != nil && budget.Exhausted {
+		log.Printf("Monthly LLM budget spent, deferring debate job %d until %s\n", job.ID, budget.ResetsAt.Format(time.DateOnly))
+		err := c.DB.RetryDebateJob(ctx, database.RetryDebateJobParams{
+			ID:        job.ID,
+			RunAt:     budget.ResetsAt,
+			LastError: sql.NullString{String: "monthly LLM budget spent", Valid: true},
+		})
+		if err != nil {
+			log.Printf("Failed to update debate job %d: %v\n", job.ID, err)
+		}
+		return
+	}
+
+	err := c.generateDebateForJob(withLLMEndpoint(ctx, debateJobEndpoint(job)), job)
+	if err == nil {
+		return
+	}
+	if errors.Is(err, errDebateJobLeaseLost) {
+		// The worker that now holds the job records its outcome
+		log.Printf("Debate job %d: %v\n", job.ID, err)
+		return
+	}
+
+	lastError := sql.NullString{String: err.Error(), Valid: true}
+	var permanent permanentJobError
+	if ctx.Err() != nil {
+		// The worker is shutting down. Hand the job straight back rather
+		// than leaving it until its lease expires.
+		err = c.DB.RetryDebateJob(context.WithoutCancel(ctx), database.RetryDebateJobParams{
+			ID:        job.ID,
+			RunAt:     time.Now().UTC(),
+			LastError: lastError,
+		})
+	} else if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		log.Printf("Debate job %d failed: %v\n", job.ID, err)
+		err = c.DB.FailDebateJob(ctx, database.FailDebateJobParams{ID: job.ID, LastError: lastError})
+	} else {
+		err = c.DB.RetryDebateJob(ctx, database.RetryDebateJobParams{
+			ID:        job.ID,
+			RunAt:     time.Now().UTC().Add(debateJobBackoff(job.Attempts)),
+			LastError: lastError,
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to update debate job %d: %v\n", job.ID, err)
+	}
+}
+
+// debateJobBackoff is the wait before retrying a job after its nth attempt
+func debateJobBackoff(attempts int32) time.Duration {
+	backoff := debateJobBaseBackoff
+	for i := int32(1); i < attempts && backoff < debateJobMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > debateJobMaxBackoff {
+		backoff = debateJobMaxBackoff
+	}
+	return backoff
+}
+
+// generateDebateForJob generates a job's debate and marks the job succeeded
+func (c *Config) generateDebateForJob(ctx context.Context, job database.DebateJob) error {
+	if done, err := c.reuseMatchDebate(ctx, job); done || err != nil {
+		return err
+	}
+
+	matchInfo, err := c.getMatchInfo(ctx, job.MatchID)
+	if err != nil {
+		return fmt.Errorf("failed to get match info: %w", err)
+	}
+	if err := c.validateMatchStatusForDebateType(matchInfo.Status, job.DebateType); err != nil {
+		return permanentJobError{err}
+	}
+
+	_, err = c.generateMatchDebate(ctx, job, matchInfo)
+	if errors.Is(err, errCanonicalDebateExists) {
+		// Another job, most likely for another language, stored the
+		// canonical debate first, so this job's debate is a translation of it
+		if done, reuseErr := c.reuseMatchDebate(ctx, job); done || reuseErr != nil {
+			return reuseErr
+		}
+	}
+	return err
+}
+
+// reuseMatchDebate completes a job with the debate another job already
+// stored for it, or stores the job's debate as a translation of the match's
+// canonical debate, reporting whether it did either. Otherwise the job's
+// debate has to be generated.
+func (c *Config) reuseMatchDebate(ctx context.Context, job database.DebateJob) (bool, error) {
+	existingDebates, err := c.DB.GetDebatesByMatch(ctx, job.MatchID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get debates: %w", err)
+	}
+	var canonical *database.Debate
+	for i, existing := range existingDebates {
+		if existing.DebateType != job.DebateType {
+			continue
+		}
+		// Another job for the same debate may already have created it. A
+		// forced job always generates: its debate is stored and the job
+		// completed in one transaction, so a retry means nothing was stored.
+		if existing.Locale == job.Locale && !job.ForceRegenerate {
+			return true, completeDebateJob(ctx, c.DB, job, existing.ID)
+		}
+		if !existing.CanonicalDebateID.Valid {
+			canonical = &existingDebates[i]
+		}
+	}
+
+	// A debate in another language than the canonical debate's is a
+	// translation of it
+	if canonical != nil && canonical.Locale != job.Locale {
+		return true, c.translateMatchDebate(ctx, job, *canonical)
+	}
+	return false, nil
+}
+
+// completeDebateJob marks a job succeeded with its debate, provided this
+// worker still holds the job's lease. Otherwise it returns
+// errDebateJobLeaseLost, and a transaction storing the debate should be
+// rolled back.
+func completeDebateJob(ctx context.Context, q *database.Queries, job database.DebateJob, debateID int32) error {
+	completed, err := q.CompleteDebateJob(ctx, database.CompleteDebateJobParams{
+		ID:          job.ID,
+		DebateID:    sql.NullInt32{Int32: debateID, Valid: true},
+		LockedUntil: job.LockedUntil,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete debate job: %w", err)
+	}
+	if completed == 0 {
+		return errDebateJobLeaseLost
+	}
+	return nil
+}
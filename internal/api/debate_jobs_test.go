@@ -0,0 +1,408 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDebateJobBackoff(t *testing.T) {
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}
+	for i, expected := range want {
+		if got := debateJobBackoff(int32(i + 1)); got != expected {
+			t.Errorf("Expected backoff %s after attempt %d, got %s", expected, i+1, got)
+		}
+	}
+	if got := debateJobBackoff(20); got != debateJobMaxBackoff {
+		t.Errorf("Expected backoff to be capped at %s, got %s", debateJobMaxBackoff, got)
+	}
+}
+
+func TestNewDebateJobResponse(t *testing.T) {
+	job := database.DebateJob{
+		ID:         3,
+		MatchID:    "1035037",
+		DebateType: "post_match",
+		Status:     debateJobPending,
+		Attempts:   1,
+		LastError:  sql.NullString{String: "failed to get match info: timeout", Valid: true},
+	}
+
+	response := newDebateJobResponse(job)
+	if response.Error != job.LastError.String {
+		t.Errorf("Expected the last error to be reported, got %q", response.Error)
+	}
+	if response.DebateID != nil {
+		t.Error("Expected no debate ID before the job succeeds")
+	}
+
+	job.Status = debateJobSucceeded
+	job.LastError = sql.NullString{}
+	job.DebateID = sql.NullInt32{Int32: 42, Valid: true}
+	response = newDebateJobResponse(job)
+	if response.DebateID == nil || *response.DebateID != 42 {
+		t.Errorf("Expected debate ID 42, got %v", response.DebateID)
+	}
+	if response.Error != "" {
+		t.Errorf("Expected no error, got %q", response.Error)
+	}
+}
+
+func TestRunDebateJobHandsBackOnShutdown(t *testing.T) {
+	config := newTestConfig(t)
+	job := addTestJob(t, config, database.DebateJob{
+		Status:      debateJobRunning,
+		Attempts:    1,
+		LockedUntil: sql.NullTime{Time: time.Now().UTC().Add(debateJobLease), Valid: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	config.runDebateJob(ctx, job)
+
+	job = getTestJob(t, config, job.ID)
+	if job.Status != debateJobPending || job.LockedUntil.Valid {
+		t.Errorf("Expected the job to be queued again, got %s", job.Status)
+	}
+	if job.RunAt.After(time.Now()) {
+		t.Errorf("Expected the job to be due straight away, got %v", job.RunAt)
+	}
+}
+
+func TestRunDebateWorkerFailsExpiredLastAttempts(t *testing.T) {
+	config := newTestConfig(t)
+	expired := sql.NullTime{Time: time.Now().UTC().Add(-time.Minute), Valid: true}
+	exhausted := addTestJob(t, config, database.DebateJob{Status: debateJobRunning, Attempts: 5, LockedUntil: expired})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		config.runDebateWorker(ctx)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for getTestJob(t, config, exhausted.ID).Status != debateJobFailed && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-stopped
+
+	if job := getTestJob(t, config, exhausted.ID); job.Status != debateJobFailed || job.Attempts != 5 {
+		t.Errorf("Expected the job out of attempts to fail, got %s after %d attempts", job.Status, job.Attempts)
+	}
+}
+
+func TestClaimDueDebateJobs(t *testing.T) {
+	config := newTestConfig(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	due := addTestJob(t, config, database.DebateJob{RunAt: now.Add(-time.Minute)})
+	addTestJob(t, config, database.DebateJob{MatchID: "1035038", RunAt: now.Add(time.Hour)})
+	addTestJob(t, config, database.DebateJob{
+		MatchID:     "1035039",
+		Status:      debateJobRunning,
+		Attempts:    1,
+		LockedUntil: sql.NullTime{Time: now.Add(debateJobLease), Valid: true},
+	})
+	// A job whose lease expired is claimed again while it has attempts left
+	expired := sql.NullTime{Time: now.Add(-time.Minute), Valid: true}
+	abandoned := addTestJob(t, config, database.DebateJob{MatchID: "1035040", Status: debateJobRunning, Attempts: 2, LockedUntil: expired})
+	addTestJob(t, config, database.DebateJob{MatchID: "1035041", Status: debateJobRunning, Attempts: 5, LockedUntil: expired})
+
+	lease := sql.NullTime{Time: now.Add(debateJobLease), Valid: true}
+	claim := func() []database.DebateJob {
+		t.Helper()
+		jobs, err := config.DB.ClaimDueDebateJobs(ctx, database.ClaimDueDebateJobsParams{LockedUntil: lease, BatchSize: 10})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return jobs
+	}
+
+	// Only the due job and the abandoned one are claimed, oldest first
+	jobs := claim()
+	if len(jobs) != 2 {
+		t.Fatalf("Expected jobs %d and %d to be claimed, got %+v", due.ID, abandoned.ID, jobs)
+	}
+	claimed := make(map[int32]database.DebateJob)
+	for _, job := range jobs {
+		claimed[job.ID] = job
+	}
+	if job := claimed[due.ID]; job.Status != debateJobRunning || job.Attempts != 1 || !job.LockedUntil.Time.Equal(lease.Time) {
+		t.Errorf("Expected the due job to run its first attempt under the lease, got %+v", job)
+	}
+	if job := claimed[abandoned.ID]; job.Attempts != 3 || !job.LockedUntil.Time.Equal(lease.Time) {
+		t.Errorf("Expected the abandoned job to run its third attempt under the lease, got %+v", job)
+	}
+	if jobs := claim(); len(jobs) != 0 {
+		t.Errorf("Expected a claimed job not to be claimed again under its lease, got %+v", jobs)
+	}
+
+	// Completing needs the lease the job was claimed under
+	stale := claimed[due.ID]
+	stale.LockedUntil.Time = stale.LockedUntil.Time.Add(-time.Minute)
+	if err := completeDebateJob(ctx, config.DB, stale, 0); !errors.Is(err, errDebateJobLeaseLost) {
+		t.Errorf("Expected completing under another lease to be refused, got %v", err)
+	}
+	debate, _ := addTestDebate(t, config, database.Debate{Headline: "Fair result?"})
+	if err := completeDebateJob(ctx, config.DB, claimed[due.ID], debate.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if job := getTestJob(t, config, due.ID); job.Status != debateJobSucceeded || job.DebateID.Int32 != debate.ID || job.LockedUntil.Valid {
+		t.Errorf("Expected the job to succeed with debate %d, got %+v", debate.ID, job)
+	}
+}
+
+func TestGenerateDebateReusesActiveJob(t *testing.T) {
+	config := newTestConfig(t)
+	config.AIPromptGenerator = &ai.PromptGenerator{}
+
+	var jobIDs []int32
+	for i := 0; i < 2; i++ {
+		rr := serveTestRequest(config.generateDebate, http.MethodPost, "/v1/api/debates/generate",
+			GenerateDebateRequest{MatchID: "1035037", DebateType: "post_match"}, database.User{})
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("Expected request %d to be accepted, got %d: %s", i+1, rr.Code, rr.Body.String())
+		}
+		var response struct {
+			Job DebateJobResponse `json:"job"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		jobIDs = append(jobIDs, response.Job.ID)
+
+		if want := fmt.Sprintf("/v1/api/debates/jobs/%d", response.Job.ID); rr.Header().Get("Location") != want {
+			t.Errorf("Expected Location %s, got %s", want, rr.Header().Get("Location"))
+		}
+	}
+
+	if jobIDs[0] != jobIDs[1] {
+		t.Errorf("Expected the second request to get the queued job %d, got %d", jobIDs[0], jobIDs[1])
+	}
+	if count := countTestRows(t, config, "debate_jobs", "TRUE"); count != 1 {
+		t.Errorf("Expected one job to be queued, got %d", count)
+	}
+
+	// The same debate in another language is a job of its own
+	rr := serveTestRequest(config.generateDebate, http.MethodPost, "/v1/api/debates/generate",
+		GenerateDebateRequest{MatchID: "1035037", DebateType: "post_match", Locale: "es"}, database.User{})
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected the Spanish request to be accepted, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if count := countTestRows(t, config, "debate_jobs", "TRUE"); count != 2 {
+		t.Errorf("Expected a second job for the Spanish debate, got %d jobs", count)
+	}
+}
+
+func TestGenerateDebateWithBudgetSpent(t *testing.T) {
+	config := newTestConfig(t)
+	config.AIPromptGenerator = &ai.PromptGenerator{}
+	config.LLMMonthlyBudget = 50
+	err := config.DB.CreateLLMCall(context.Background(), database.CreateLLMCallParams{
+		Provider: ai.ProviderFake,
+		Model:    "fake",
+		Endpoint: "debates/generate",
+		CostUsd:  "50.25",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	addTestDebate(t, config, database.Debate{Headline: "Fair result?"}, database.DebateCard{Stance: "agree", Title: "Yes"})
+
+	// An existing debate costs nothing to return
+	rr := serveTestRequest(config.generateDebate, http.MethodPost, "/v1/api/debates/generate",
+		GenerateDebateRequest{MatchID: "1035037", DebateType: "post_match"}, database.User{})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the existing debate, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = serveTestRequest(config.generateDebate, http.MethodPost, "/v1/api/debates/generate",
+		GenerateDebateRequest{MatchID: "1035037", DebateType: "pre_match"}, database.User{})
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected a 503 with Retry-After, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if count := countTestRows(t, config, "debate_jobs", "TRUE"); count != 0 {
+		t.Errorf("Expected nothing to be queued, got %d jobs", count)
+	}
+}
+
+func TestRunDebateJobDefersWhenBudgetSpent(t *testing.T) {
+	config := newTestConfig(t)
+	config.LLMMonthlyBudget = 50
+	err := config.DB.CreateLLMCall(context.Background(), database.CreateLLMCallParams{
+		Provider: ai.ProviderFake,
+		Model:    "fake",
+		Endpoint: "debates/generate",
+		CostUsd:  "50",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	job := addTestJob(t, config, database.DebateJob{
+		Status:      debateJobRunning,
+		Attempts:    1,
+		LockedUntil: sql.NullTime{Time: time.Now().UTC().Add(debateJobLease), Valid: true},
+	})
+
+	config.runDebateJob(context.Background(), job)
+
+	job = getTestJob(t, config, job.ID)
+	if job.Status != debateJobPending || job.LockedUntil.Valid {
+		t.Fatalf("Expected the job to be queued again, got %s", job.Status)
+	}
+	if resetsAt := monthStart(time.Now()).AddDate(0, 1, 0); !job.RunAt.Equal(resetsAt) {
+		t.Errorf("Expected the job to wait until the budget resets at %v, got %v", resetsAt, job.RunAt)
+	}
+	if job.LastError.String != "monthly LLM budget spent" {
+		t.Errorf("Expected the wait to be explained, got %q", job.LastError.String)
+	}
+}
+
+func TestEnqueueDebateJobAfterActiveJobFinishes(t *testing.T) {
+	mockDB := new(MockQueries)
+	ctx := context.Background()
+	queued := database.DebateJob{ID: 2, MatchID: "1035037", DebateType: "post_match", Status: debateJobPending, ForceRegenerate: true}
+
+	// The active job that blocked the insert completes before it is read
+	// back, so the insert is tried again and succeeds
+	mockDB.On("EnqueueDebateJob", ctx, mock.Anything).Return(database.DebateJob{}, sql.ErrNoRows).Once()
+	mockDB.On("GetActiveDebateJob", ctx, database.GetActiveDebateJobParams{MatchID: "1035037", DebateType: "post_match", Locale: "en"}).
+		Return(database.DebateJob{}, sql.ErrNoRows).Once()
+	mockDB.On("EnqueueDebateJob", ctx, mock.MatchedBy(func(arg database.EnqueueDebateJobParams) bool {
+		return arg.ForceRegenerate && arg.Locale == "en"
+	})).Return(queued, nil).Once()
+
+	job, created, err := enqueueDebateJob(ctx, mockDB, requestDebateJobKey(), "1035037", "post_match", "en", true)
+
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, queued, job)
+	mockDB.AssertExpectations(t)
+}
+
+func TestEnqueueDebateJobReturnsActiveJob(t *testing.T) {
+	mockDB := new(MockQueries)
+	ctx := context.Background()
+	active := database.DebateJob{ID: 1, MatchID: "1035037", DebateType: "post_match", Status: debateJobRunning}
+
+	mockDB.On("EnqueueDebateJob", ctx, mock.Anything).Return(database.DebateJob{}, sql.ErrNoRows).Once()
+	mockDB.On("GetActiveDebateJob", ctx, mock.Anything).Return(active, nil).Once()
+
+	job, created, err := enqueueDebateJob(ctx, mockDB, requestDebateJobKey(), "1035037", "post_match", "en", false)
+
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, active, job)
+	mockDB.AssertExpectations(t)
+}
+
+func TestRunDebateJobRetriesThenFails(t *testing.T) {
+	config := newTestConfig(t)
+	// Translating the canonical debate fails, as a provider outage would
+	config.AIPromptGenerator = ai.NewPromptGenerator(&ai.FakeProvider{Err: errors.New("connection reset")}, nil)
+	addTestDebate(t, config, database.Debate{Headline: "Fair result?"}, database.DebateCard{Stance: "agree", Title: "Yes"})
+	lease := sql.NullTime{Time: time.Now().UTC().Add(debateJobLease), Valid: true}
+
+	job := addTestJob(t, config, database.DebateJob{Locale: "es", Status: debateJobRunning, Attempts: 2, LockedUntil: lease})
+	before := time.Now()
+	config.runDebateJob(context.Background(), job)
+
+	job = getTestJob(t, config, job.ID)
+	if job.Status != debateJobPending || job.LockedUntil.Valid {
+		t.Fatalf("Expected the job to be queued for a retry, got %s", job.Status)
+	}
+	if wait := job.RunAt.Sub(before); wait < debateJobBackoff(2)-time.Second || wait > debateJobBackoff(2)+time.Minute {
+		t.Errorf("Expected the retry in %s, got %s", debateJobBackoff(2), wait)
+	}
+	if job.LastError.String == "" {
+		t.Error("Expected the failure to be recorded")
+	}
+
+	// The last attempt fails the job for good
+	if _, err := config.DBConn.Exec("UPDATE debate_jobs SET status = $2, attempts = max_attempts, locked_until = $3 WHERE id = $1", job.ID, debateJobRunning, lease); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config.runDebateJob(context.Background(), getTestJob(t, config, job.ID))
+
+	if job = getTestJob(t, config, job.ID); job.Status != debateJobFailed {
+		t.Errorf("Expected the job to fail after %d attempts, got %s", job.MaxAttempts, job.Status)
+	}
+}
+
+func TestStoreMatchDebateIsAtomic(t *testing.T) {
+	config := newTestConfig(t)
+	ctx := context.Background()
+	old, _ := addTestDebate(t, config, database.Debate{Headline: "Old"}, database.DebateCard{Stance: "agree", Title: "Old card"})
+	job := addTestJob(t, config, database.DebateJob{
+		Status:          debateJobRunning,
+		Attempts:        1,
+		LockedUntil:     sql.NullTime{Time: time.Now().UTC().Add(debateJobLease), Valid: true},
+		ForceRegenerate: true,
+	})
+	prompt := &ai.DebatePrompt{
+		Headline: "New",
+		Cards: []ai.DebateCard{
+			{Stance: "agree", Title: "Agree"},
+			{Stance: "disagree", Title: "Disagree"},
+		},
+	}
+
+	// A card fails to store after the new debate has been created: its
+	// title is longer than the column allows
+	tooLong := &ai.DebatePrompt{
+		Headline: "New",
+		Cards: []ai.DebateCard{
+			{Stance: "agree", Title: "Agree"},
+			{Stance: "disagree", Title: strings.Repeat("a", 256)},
+		},
+	}
+	if _, err := config.storeMatchDebate(ctx, job, tooLong, &ai.MatchData{}, nil); err == nil {
+		t.Fatal("Expected storing the debate to fail")
+	}
+	if live, err := config.DB.GetDebate(ctx, old.ID); err != nil || live.Headline != "Old" {
+		t.Errorf("Expected the old debate to stay live, got %v", err)
+	}
+	if count := countTestRows(t, config, "debates", "TRUE"); count != 1 {
+		t.Errorf("Expected the new debate to be rolled back, got %d debates", count)
+	}
+	if count := countTestRows(t, config, "debate_cards", "TRUE"); count != 1 {
+		t.Errorf("Expected the new cards to be rolled back, got %d cards", count)
+	}
+	if status := getTestJob(t, config, job.ID).Status; status != debateJobRunning {
+		t.Errorf("Expected the job not to be completed, got %s", status)
+	}
+
+	// Another worker claims the job while this one is generating
+	if _, err := config.DBConn.Exec("UPDATE debate_jobs SET locked_until = locked_until + INTERVAL '1 minute' WHERE id = $1", job.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := config.storeMatchDebate(ctx, job, prompt, &ai.MatchData{}, nil); !errors.Is(err, errDebateJobLeaseLost) {
+		t.Fatalf("Expected the lost lease to be reported, got %v", err)
+	}
+	if live, err := config.DB.GetDebate(ctx, old.ID); err != nil || live.Headline != "Old" {
+		t.Errorf("Expected the old debate to stay live, got %v", err)
+	}
+
+	// With the lease held the new debate replaces the old one
+	claimed := getTestJob(t, config, job.ID)
+	response, err := config.storeMatchDebate(ctx, claimed, prompt, &ai.MatchData{}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := config.DB.GetDebate(ctx, old.ID); err != sql.ErrNoRows {
+		t.Errorf("Expected the old debate to be retired, got %v", err)
+	}
+	if job := getTestJob(t, config, job.ID); job.Status != debateJobSucceeded || job.DebateID.Int32 != response.ID {
+		t.Errorf("Expected the job to succeed with debate %d, got %s with %d", response.ID, job.Status, job.DebateID.Int32)
+	}
+}
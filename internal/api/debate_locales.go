@@ -0,0 +1,320 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+)
+
+// supportedLocale normalises a language tag to a locale debates can be
+// generated in, falling back from a regional tag such as "pt-BR" to its
+// language
+func supportedLocale(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if _, ok := ai.Languages[tag]; ok {
+		return tag, true
+	}
+	if i := strings.IndexAny(tag, "-_"); i > 0 {
+		if _, ok := ai.Languages[tag[:i]]; ok {
+			return tag[:i], true
+		}
+	}
+	return "", false
+}
+
+// supportedLocales lists the locales debates can be generated in
+func supportedLocales() []string {
+	locales := make([]string, 0, len(ai.Languages))
+	for locale := range ai.Languages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// preferredLocales reads an Accept-Language header into the supported
+// locales it names, most preferred first. Languages weighted q=0 and the "*"
+// wildcard are ignored.
+func preferredLocales(header string) []string {
+	type weightedLocale struct {
+		locale string
+		weight float64
+	}
+	var weighted []weightedLocale
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		locale, ok := supportedLocale(tag)
+		if !ok || weight <= 0 {
+			continue
+		}
+		weighted = append(weighted, weightedLocale{locale: locale, weight: weight})
+	}
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].weight > weighted[j].weight
+	})
+
+	var locales []string
+	seen := make(map[string]bool)
+	for _, w := range weighted {
+		if !seen[w.locale] {
+			seen[w.locale] = true
+			locales = append(locales, w.locale)
+		}
+	}
+	return locales
+}
+
+// canonicalDebateID is the ID of the debate whose votes, stances and
+// analytics a debate shares: its own unless it is a translation
+func canonicalDebateID(debate database.Debate) int32 {
+	if debate.CanonicalDebateID.Valid {
+		return debate.CanonicalDebateID.Int32
+	}
+	return debate.ID
+}
+
+// pickDebateVariant returns the member of a debate family written in the
+// most preferred locale, if any of them is
+func pickDebateVariant(family []database.Debate, locales []string) (database.Debate, bool) {
+	for _, locale := range locales {
+		for _, debate := range family {
+			if debate.Locale == locale {
+				return debate, true
+			}
+		}
+	}
+	return database.Debate{}, false
+}
+
+// pickDebateVariants keeps one debate per family, in the order the canonical
+// debates are listed: the member in the most preferred locale, or the
+// canonical debate. Translations whose canonical debate is not listed are
+// dropped.
+func pickDebateVariants(debates []database.Debate, locales []string) []database.Debate {
+	families := make(map[int32][]database.Debate)
+	var canonicals []database.Debate
+	for _, debate := range debates {
+		if !debate.CanonicalDebateID.Valid {
+			canonicals = append(canonicals, debate)
+		}
+		id := canonicalDebateID(debate)
+		families[id] = append(families[id], debate)
+	}
+
+	picked := make([]database.Debate, 0, len(canonicals))
+	for _, canonical := range canonicals {
+		if variant, ok := pickDebateVariant(families[canonical.ID], locales); ok {
+			picked = append(picked, variant)
+			continue
+		}
+		picked = append(picked, canonical)
+	}
+	return picked
+}
+
+// translationCardIDs maps the canonical cards of a translation's cards to
+// the translation's own
+func translationCardIDs(cards []database.DebateCard) map[int32]int32 {
+	cardIDs := make(map[int32]int32, len(cards))
+	for _, card := range cards {
+		if card.CanonicalCardID.Valid {
+			cardIDs[card.CanonicalCardID.Int32] = card.ID
+		}
+	}
+	return cardIDs
+}
+
+// localizeStances copies a canonical debate's stance breakdown onto one of
+// its translations, with the translation's debate and card IDs
+func localizeStances(breakdown *StanceBreakdown, debateID int32, cardIDs map[int32]int32) *StanceBreakdown {
+	if breakdown == nil {
+		return nil
+	}
+	localized := *breakdown
+	localized.DebateID = debateID
+	localized.Stances = make([]StanceTally, len(breakdown.Stances))
+	for i, tally := range breakdown.Stances {
+		if cardID, ok := cardIDs[tally.DebateCardID]; ok {
+			tally.DebateCardID = cardID
+		}
+		localized.Stances[i] = tally
+	}
+	if breakdown.UserCardID != nil {
+		userCardID := *breakdown.UserCardID
+		if cardID, ok := cardIDs[userCardID]; ok {
+			userCardID = cardID
+		}
+		localized.UserCardID = &userCardID
+	}
+	return &localized
+}
+
+// localizeStanceBreakdown shows the stance breakdown of a debate's family
+// with the debate's own IDs when the debate is a translation
+func (c *Config) localizeStanceBreakdown(ctx context.Context, debate database.Debate, breakdown *StanceBreakdown) (*StanceBreakdown, error) {
+	if !debate.CanonicalDebateID.Valid || breakdown == nil {
+		return breakdown, nil
+	}
+	cards, err := c.DB.GetDebateCards(ctx, sql.NullInt32{Int32: debate.ID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	return localizeStances(breakdown, debate.ID, translationCardIDs(cards)), nil
+}
+
+// voteCardID returns the card votes on a card are recorded against: the
+// canonical card when the card belongs to a translation
+func (c *Config) voteCardID(ctx context.Context, cardID int32) (int32, error) {
+	card, err := c.DB.GetDebateCard(ctx, cardID)
+	if err != nil {
+		return 0, err
+	}
+	if card.CanonicalCardID.Valid {
+		return card.CanonicalCardID.Int32, nil
+	}
+	return card.ID, nil
+}
+
+// debateVariantID returns the ID of the member of a debate's family written
+// in the most preferred of the locales, or the debate's own ID when none is
+func (c *Config) debateVariantID(ctx context.Context, debateID int32, locales []string) (int32, error) {
+	debate, err := c.DB.GetDebate(ctx, debateID)
+	if err != nil {
+		return 0, err
+	}
+	family, err := c.DB.GetDebateFamily(ctx, canonicalDebateID(debate))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get debate translations: %w", err)
+	}
+	if variant, ok := pickDebateVariant(family, locales); ok {
+		return variant.ID, nil
+	}
+	return debate.ID, nil
+}
+
+// translateMatchDebate stores a translation of a match's canonical debate in
+// the job's locale. The translation has its own cards and comments; its
+// votes, stances and analytics are the canonical debate's.
+func (c *Config) translateMatchDebate(ctx context.Context, job database.DebateJob, canonical database.Debate) error {
+	cards, err := c.DB.GetDebateCards(ctx, sql.NullInt32{Int32: canonical.ID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to get debate cards: %w", err)
+	}
+	if len(cards) == 0 {
+		return permanentJobError{errors.New("the canonical debate has no cards to translate")}
+	}
+	source := ai.DebatePrompt{
+		Headline:    canonical.Headline,
+		Description: canonical.Description.String,
+	}
+	// A stance may have several cards, e.g. after an admin added one, so the
+	// canonical cards of each stance are kept in order
+	canonicalCards := make(map[string][]int32, len(cards))
+	for _, card := range cards {
+		source.Cards = append(source.Cards, ai.DebateCard{
+			Stance:      card.Stance,
+			Title:       card.Title,
+			Description: card.Description.String,
+		})
+		canonicalCards[card.Stance] = append(canonicalCards[card.Stance], card.ID)
+	}
+
+	translation, err := c.AIPromptGenerator.TranslateDebatePrompt(ctx, source, job.Locale)
+	if err != nil {
+		return fmt.Errorf("failed to translate debate: %w", err)
+	}
+
+	// The AI call above is slow, so the transaction only starts once there is
+	// something to store
+	tx, err := c.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := c.DB.WithTx(tx)
+
+	if job.ForceRegenerate {
+		translations, err := qtx.GetDebateTranslations(ctx, database.GetDebateTranslationsParams{
+			CanonicalDebateIds: []int32{canonical.ID},
+			Locales:            []string{job.Locale},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get existing translations: %w", err)
+		}
+		for _, existing := range translations {
+			if err := qtx.SoftDeleteDebate(ctx, existing.ID); err != nil {
+				return fmt.Errorf("failed to soft delete existing translation: %w", err)
+			}
+		}
+	}
+
+	debate, err := qtx.CreateDebate(ctx, database.CreateDebateParams{
+		MatchID:               canonical.MatchID,
+		DebateType:            canonical.DebateType,
+		Headline:              translation.Headline,
+		Description:           sql.NullString{String: translation.Description, Valid: translation.Description != ""},
+		AiGenerated:           sql.NullBool{Bool: true, Valid: true},
+		PromptTemplateVersion: canonical.PromptTemplateVersion,
+		Locale:                job.Locale,
+		CanonicalDebateID:     sql.NullInt32{Int32: canonical.ID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create debate: %w", err)
+	}
+
+	// Cards are matched to the canonical ones by stance, in order, both of
+	// which the translation keeps
+	created := 0
+	for _, card := range translation.Cards {
+		remaining := canonicalCards[card.Stance]
+		if len(remaining) == 0 {
+			fmt.Printf("Skipping translated card with no canonical card: stance=%s\n", card.Stance)
+			continue
+		}
+		canonicalCardID := remaining[0]
+		_, err := qtx.CreateDebateCard(ctx, database.CreateDebateCardParams{
+			DebateID:        sql.NullInt32{Int32: debate.ID, Valid: true},
+			Stance:          card.Stance,
+			Title:           card.Title,
+			Description:     sql.NullString{String: card.Description, Valid: card.Description != ""},
+			AiGenerated:     sql.NullBool{Bool: true, Valid: true},
+			CanonicalCardID: sql.NullInt32{Int32: canonicalCardID, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create debate card: %w", err)
+		}
+		canonicalCards[card.Stance] = remaining[1:]
+		created++
+	}
+	// Every canonical card needs its translation, or votes cast in this
+	// language could not reach some of the stances. The translation was
+	// checked to have the canonical cards' stances, so this is not worth
+	// paying for another translation.
+	if created != len(cards) {
+		return permanentJobError{fmt.Errorf("translated %d of the debate's %d cards", created, len(cards))}
+	}
+
+	if err := completeDebateJob(ctx, qtx, job, debate.ID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit debate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/lib/pq"
+)
+
+func TestSupportedLocale(t *testing.T) {
+	tests := []struct {
+		tag    string
+		locale string
+		ok     bool
+	}{
+		{"es", "es", true},
+		{" PT-br ", "pt", true},
+		{"fr_CA", "fr", true},
+		{"ja", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		locale, ok := supportedLocale(tt.tag)
+		if locale != tt.locale || ok != tt.ok {
+			t.Errorf("supportedLocale(%q) = %q, %v; expected %q, %v", tt.tag, locale, ok, tt.locale, tt.ok)
+		}
+	}
+}
+
+func TestPreferredLocales(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", nil},
+		{"pt-BR,pt;q=0.9,en;q=0.8", []string{"pt", "en"}},
+		{"en;q=0.5, fr", []string{"fr", "en"}},
+		{"ja, *;q=0.5, de;q=0", nil},
+		{"ar;q=abc, es;q=0.3", []string{"es"}},
+	}
+	for _, tt := range tests {
+		got := preferredLocales(tt.header)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("preferredLocales(%q) = %v; expected %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestPickDebateVariants(t *testing.T) {
+	translationOf := func(id int32) sql.NullInt32 {
+		return sql.NullInt32{Int32: id, Valid: true}
+	}
+	debates := []database.Debate{
+		{ID: 1, Locale: "en"},
+		{ID: 2, Locale: "es", CanonicalDebateID: translationOf(1)},
+		{ID: 3, Locale: "fr", CanonicalDebateID: translationOf(1)},
+		{ID: 4, Locale: "en"},
+		{ID: 5, Locale: "es", CanonicalDebateID: translationOf(9)}, // Canonical not listed
+	}
+
+	tests := []struct {
+		locales []string
+		want    []int32
+	}{
+		{nil, []int32{1, 4}},
+		{[]string{"es"}, []int32{2, 4}},
+		{[]string{"de", "fr", "es"}, []int32{3, 4}},
+		{[]string{"en", "es"}, []int32{1, 4}},
+	}
+	for _, tt := range tests {
+		picked := pickDebateVariants(debates, tt.locales)
+		if len(picked) != len(tt.want) {
+			t.Errorf("%v: expected %d debates, got %d", tt.locales, len(tt.want), len(picked))
+			continue
+		}
+		for i, debate := range picked {
+			if debate.ID != tt.want[i] {
+				t.Errorf("%v: expected debate %d at %d, got %d", tt.locales, tt.want[i], i, debate.ID)
+			}
+		}
+	}
+}
+
+func TestLocalizeStances(t *testing.T) {
+	userCardID := int32(11)
+	breakdown := &StanceBreakdown{
+		DebateID:       1,
+		TotalResponses: 3,
+		Stances: []StanceTally{
+			{DebateCardID: 10, Stance: "agree", Count: 1},
+			{DebateCardID: 11, Stance: "disagree", Count: 2},
+		},
+		UserCardID: &userCardID,
+	}
+	cardIDs := translationCardIDs([]database.DebateCard{
+		{ID: 20, CanonicalCardID: sql.NullInt32{Int32: 10, Valid: true}},
+		{ID: 21, CanonicalCardID: sql.NullInt32{Int32: 11, Valid: true}},
+	})
+
+	localized := localizeStances(breakdown, 2, cardIDs)
+	if localized.DebateID != 2 || localized.TotalResponses != 3 {
+		t.Errorf("Expected the translation's breakdown, got %+v", localized)
+	}
+	if localized.Stances[0].DebateCardID != 20 || localized.Stances[1].DebateCardID != 21 {
+		t.Errorf("Expected the translation's card IDs, got %+v", localized.Stances)
+	}
+	if *localized.UserCardID != 21 {
+		t.Errorf("Expected the caller's pick on card 21, got %d", *localized.UserCardID)
+	}
+	if breakdown.DebateID != 1 || breakdown.Stances[0].DebateCardID != 10 || *breakdown.UserCardID != 11 {
+		t.Errorf("Expected the canonical breakdown to be left alone, got %+v", breakdown)
+	}
+	if localizeStances(nil, 2, cardIDs) != nil {
+		t.Error("Expected no breakdown for a debate without one")
+	}
+}
+
+// addTranslatedDebate stores a canonical English debate with agree and
+// disagree cards and its Spanish translation
+func addTranslatedDebate(t *testing.T, c *Config) (database.Debate, []database.DebateCard, database.Debate, []database.DebateCard) {
+	t.Helper()
+	canonical, canonicalCards := addTestDebate(t, c, database.Debate{Headline: "Fair result?"},
+		database.DebateCard{Stance: "agree", Title: "Yes"}, database.DebateCard{Stance: "disagree", Title: "No"})
+	translationOf := func(id int32) sql.NullInt32 {
+		return sql.NullInt32{Int32: id, Valid: true}
+	}
+	translation, translationCards := addTestDebate(t, c, database.Debate{
+		Headline:          "¿Resultado justo?",
+		Locale:            "es",
+		CanonicalDebateID: translationOf(canonical.ID),
+	},
+		database.DebateCard{Stance: "agree", Title: "Sí", CanonicalCardID: translationOf(canonicalCards[0].ID)},
+		database.DebateCard{Stance: "disagree", Title: "No", CanonicalCardID: translationOf(canonicalCards[1].ID)})
+	return canonical, canonicalCards, translation, translationCards
+}
+
+func TestTranslationActivityCountsOnCanonical(t *testing.T) {
+	config := newTestConfig(t)
+	ctx := context.Background()
+	canonical, canonicalCards, translation, translationCards := addTranslatedDebate(t, config)
+	fans := []database.User{addTestUser(t, config), addTestUser(t, config), addTestUser(t, config)}
+
+	// Votes on a translated card are recorded against the canonical card
+	rr := serveTestRequest(config.createVote, http.MethodPost, "/debates/votes", CreateVoteRequest{DebateCardID: translationCards[1].ID, VoteType: "upvote"}, fans[0])
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected the vote to be created, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = serveTestRequest(config.createVote, http.MethodPost, "/debates/votes", CreateVoteRequest{DebateCardID: canonicalCards[1].ID, VoteType: "upvote"}, fans[1])
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected the vote to be created, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rows, err := config.DB.GetVoteCounts(ctx, []int32{canonicalCards[1].ID, translationCards[1].ID})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	counts := voteCountsByCard(rows)
+	if counts[canonicalCards[1].ID].Upvotes != 2 || counts[translationCards[1].ID].Upvotes != 0 {
+		t.Errorf("Expected both upvotes on the canonical card, got %+v", counts)
+	}
+
+	// Analytics add up the activity of the whole family on the canonical
+	// debate, whichever member it happened on
+	comment := func(debateID int32) database.Comment {
+		t.Helper()
+		comment, err := config.DB.CreateComment(ctx, database.CreateCommentParams{
+			DebateID:         sql.NullInt32{Int32: debateID, Valid: true},
+			UserID:           sql.NullInt32{Int32: fans[0].ID, Valid: true},
+			Content:          "What a match",
+			ModerationStatus: "allowed",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return comment
+	}
+	comment(canonical.ID)
+	comment(translation.ID)
+	liked := comment(translation.ID)
+	for _, fan := range fans {
+		if err := config.DB.CreateCommentLike(ctx, database.CreateCommentLikeParams{CommentID: liked.ID, UserID: fan.ID}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	config.updateDebateAnalytics(ctx, translation.ID)
+
+	analytics, err := config.DB.GetDebateAnalytics(ctx, sql.NullInt32{Int32: canonical.ID, Valid: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if analytics.TotalVotes.Int32 != 2 || analytics.TotalComments.Int32 != 3 {
+		t.Errorf("Expected 2 votes and 3 comments on the canonical debate, got %d and %d", analytics.TotalVotes.Int32, analytics.TotalComments.Int32)
+	}
+	if analytics.EngagementScore.String != "11.00" {
+		t.Errorf("Expected an engagement score of 2 + 3*2 + 3, got %s", analytics.EngagementScore.String)
+	}
+	untouched, err := config.DB.GetDebateAnalytics(ctx, sql.NullInt32{Int32: translation.ID, Valid: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if untouched.TotalVotes.Int32 != 0 || untouched.TotalComments.Int32 != 0 {
+		t.Errorf("Expected the translation's own analytics to be left alone, got %+v", untouched)
+	}
+}
+
+func TestForcedRegenerationRetiresTranslations(t *testing.T) {
+	config := newTestConfig(t)
+	canonical, _, translation, _ := addTranslatedDebate(t, config)
+	job := addTestJob(t, config, database.DebateJob{
+		Status:          debateJobRunning,
+		Attempts:        1,
+		LockedUntil:     sql.NullTime{Time: time.Now().UTC().Add(debateJobLease), Valid: true},
+		ForceRegenerate: true,
+	})
+	prompt := &ai.DebatePrompt{Headline: "New", Cards: []ai.DebateCard{{Stance: "agree", Title: "Agree"}}}
+
+	response, err := config.storeMatchDebate(context.Background(), job, prompt, &ai.MatchData{}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, retired := range []database.Debate{canonical, translation} {
+		if _, err := config.DB.GetDebate(context.Background(), retired.ID); err != sql.ErrNoRows {
+			t.Errorf("Expected debate %d (%s) to be retired, got %v", retired.ID, retired.Locale, err)
+		}
+	}
+	family, err := config.DB.GetDebateFamily(context.Background(), response.ID)
+	if err != nil || len(family) != 1 {
+		t.Errorf("Expected the new debate to start without translations, got %d debates (%v)", len(family), err)
+	}
+}
+
+func TestCanonicalDebateConflictStoresTranslation(t *testing.T) {
+	config := newTestConfig(t)
+	config.AIPromptGenerator = ai.NewPromptGenerator(&ai.FakeProvider{}, nil)
+	job := addTestJob(t, config, database.DebateJob{
+		Locale:      "es",
+		Status:      debateJobRunning,
+		Attempts:    1,
+		LockedUntil: sql.NullTime{Time: time.Now().UTC().Add(debateJobLease), Valid: true},
+	})
+
+	// An English job stores the canonical debate while this one generates
+	canonical, canonicalCards := addTestDebate(t, config, database.Debate{Headline: "Fair result?"},
+		database.DebateCard{Stance: "agree", Title: "Yes"}, database.DebateCard{Stance: "wildcard", Title: "The referee"})
+	prompt := &ai.DebatePrompt{Headline: "¿Resultado justo?", Cards: []ai.DebateCard{{Stance: "agree", Title: "Sí"}}}
+	if _, err := config.storeMatchDebate(context.Background(), job, prompt, &ai.MatchData{}, nil); !errors.Is(err, errCanonicalDebateExists) {
+		t.Fatalf("Expected the canonical debate conflict to be reported, got %v", err)
+	}
+
+	// The job then stores a translation of it, with a card for each of the
+	// canonical cards
+	done, err := config.reuseMatchDebate(context.Background(), job)
+	if err != nil || !done {
+		t.Fatalf("Expected the job to store a translation, got %v, %v", done, err)
+	}
+	completed := getTestJob(t, config, job.ID)
+	if completed.Status != debateJobSucceeded {
+		t.Fatalf("Expected the job to succeed, got %s", completed.Status)
+	}
+	translation, err := config.DB.GetDebate(context.Background(), completed.DebateID.Int32)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if translation.CanonicalDebateID.Int32 != canonical.ID || translation.Locale != "es" {
+		t.Errorf("Expected a Spanish translation of debate %d, got %+v", canonical.ID, translation)
+	}
+	cards, err := config.DB.GetDebateCards(context.Background(), sql.NullInt32{Int32: translation.ID, Valid: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cardIDs := translationCardIDs(cards)
+	if len(cards) != 2 || cardIDs[canonicalCards[0].ID] == 0 || cardIDs[canonicalCards[1].ID] == 0 {
+		t.Errorf("Expected a translated card for each canonical card, got %+v", cards)
+	}
+}
+
+func TestCanonicalDebateIsUnique(t *testing.T) {
+	config := newTestConfig(t)
+	ctx := context.Background()
+	canonical, _, _, _ := addTranslatedDebate(t, config)
+
+	create := func(params database.CreateDebateParams) error {
+		params.MatchID, params.DebateType = "1035037", "post_match"
+		_, err := config.DB.CreateDebate(ctx, params)
+		return err
+	}
+	var pqErr *pq.Error
+	if err := create(database.CreateDebateParams{Headline: "Again?", Locale: "fr"}); !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		t.Errorf("Expected a second canonical debate to be rejected, got %v", err)
+	}
+	translationOf := sql.NullInt32{Int32: canonical.ID, Valid: true}
+	if err := create(database.CreateDebateParams{Headline: "¿Otra vez?", Locale: "es", CanonicalDebateID: translationOf}); !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		t.Errorf("Expected a second Spanish translation to be rejected, got %v", err)
+	}
+	if err := create(database.CreateDebateParams{Headline: "Encore ?", Locale: "fr", CanonicalDebateID: translationOf}); err != nil {
+		t.Errorf("Expected a translation in another language to be stored, got %v", err)
+	}
+
+	// Once retired, the debate can be replaced
+	if err := config.DB.SoftDeleteDebate(ctx, canonical.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := create(database.CreateDebateParams{Headline: "Fair result?", Locale: "en"}); err != nil {
+		t.Errorf("Expected a new canonical debate to be stored, got %v", err)
+	}
+}
+
+func TestTranslateDebateWithRepeatedStance(t *testing.T) {
+	config := newTestConfig(t)
+	config.AIPromptGenerator = ai.NewPromptGenerator(&ai.FakeProvider{}, nil)
+	// An admin added a second agree card to the canonical debate
+	_, canonicalCards := addTestDebate(t, config, database.Debate{Headline: "Fair result?"},
+		database.DebateCard{Stance: "agree", Title: "Yes"}, database.DebateCard{Stance: "disagree", Title: "No"},
+		database.DebateCard{Stance: "agree", Title: "Yes, and deserved"})
+	job := addTestJob(t, config, database.DebateJob{
+		Locale:      "es",
+		Status:      debateJobRunning,
+		Attempts:    1,
+		LockedUntil: sql.NullTime{Time: time.Now().UTC().Add(debateJobLease), Valid: true},
+	})
+
+	if done, err := config.reuseMatchDebate(context.Background(), job); err != nil || !done {
+		t.Fatalf("Expected the job to store a translation, got %v, %v", done, err)
+	}
+	completed := getTestJob(t, config, job.ID)
+	cards, err := config.DB.GetDebateCards(context.Background(), completed.DebateID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cardIDs := translationCardIDs(cards)
+	for _, canonicalCard := range canonicalCards {
+		if cardIDs[canonicalCard.ID] == 0 {
+			t.Errorf("Expected a translation of card %d (%s), got %+v", canonicalCard.ID, canonicalCard.Title, cards)
+		}
+	}
+}
+
+func TestTranslateDebateWithoutCardsFails(t *testing.T) {
+	config := newTestConfig(t)
+	provider := &ai.FakeProvider{}
+	config.AIPromptGenerator = ai.NewPromptGenerator(provider, nil)
+	addTestDebate(t, config, database.Debate{Headline: "Fair result?"})
+	job := addTestJob(t, config, database.DebateJob{
+		Locale:      "es",
+		Status:      debateJobRunning,
+		Attempts:    1,
+		LockedUntil: sql.NullTime{Time: time.Now().UTC().Add(debateJobLease), Valid: true},
+	})
+
+	// Retrying cannot give the canonical debate cards, so the job fails on
+	// its first attempt without paying for a translation
+	config.runDebateJob(context.Background(), job)
+
+	if job := getTestJob(t, config, job.ID); job.Status != debateJobFailed {
+		t.Errorf("Expected the job to fail, got %s", job.Status)
+	}
+	if requests := provider.Requests(); len(requests) != 0 {
+		t.Errorf("Expected no translation to be requested, got %d requests", len(requests))
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+)
+
+const (
+	debateSchedulerInterval   = 5 * time.Minute
+	defaultPreMatchDebateLead = 6 * time.Hour
+	// postMatchScanWindow is how long after kickoff a fixture is still
+	// checked for full time, covering extra time, penalties and delays
+	postMatchScanWindow = 6 * time.Hour
+)
+
+// API-Football short statuses of fixtures ready for a post-match debate
+var postMatchStatuses = map[string]bool{"FT": true, "AET": true, "PEN": true}
+
+// runDebateScheduler queues debate jobs for the fixtures of
+// DebateSchedulerLeagues until ctx is cancelled. Every instance may run it;
+// the job queue keeps them from doing the same work twice.
+func (c *Config) runDebateScheduler(ctx context.Context) {
+	ticker := time.NewTicker(debateSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		c.scheduleDebateJobs(ctx, time.Now().UTC())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scheduleDebateJobs enqueues a job for each followed fixture that is due a
+// pre-match or post-match debate
+func (c *Config) scheduleDebateJobs(ctx context.Context, now time.Time) {
+	// Nothing is queued while the monthly LLM budget is spent
+	if budget, err := c.llmBudget(ctx, now); err != nil {
+		log.Printf("Failed to check LLM budget: %v\n", err)
+	} else if budget != nil && budget.Exhausted {
+		log.Printf("Monthly LLM budget spent, not scheduling debates until %s\n", budget.ResetsAt.Format(time.DateOnly))
+		return
+	}
+
+	followed := make(map[int]bool, len(c.DebateSchedulerLeagues))
+	for _, leagueID := range c.DebateSchedulerLeagues {
+		followed[leagueID] = true
+	}
+
+	for _, date := range debateScanDates(now, c.PreMatchDebateLead) {
+		matches, err := c.fetchMatches(ctx, date)
+		if err != nil {
+			log.Printf("Failed to get matches for %s: %v\n", date, err)
+			continue
+		}
+
+		for _, match := range matches.Response {
+			if !followed[match.League.ID] {
+				continue
+			}
+			debateType, ok := scheduledDebateType(match.Fixture.Status.Short, match.Fixture.Date, now, c.PreMatchDebateLead)
+			if !ok {
+				continue
+			}
+			key := scheduledDebateJobKey(match.Fixture.ID, debateType)
+			_, err := c.enqueueDebateJob(ctx, key, strconv.Itoa(match.Fixture.ID), debateType, ai.DefaultLocale, false)
+			if err != nil && err != sql.ErrNoRows {
+				log.Printf("Failed to enqueue debate job %s: %v\n", key, err)
+			}
+		}
+	}
+}
+
+// debateScanDates lists the fixture dates that may hold matches due a debate:
+// those kicking off within the pre-match lead, and those that kicked off
+// recently enough to be finishing now
+func debateScanDates(now time.Time, lead time.Duration) []string {
+	var dates []string
+	for day := now.Add(-postMatchScanWindow); !day.After(now.Add(lead)); day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day.Format("2006-01-02"))
+	}
+	if last := now.Add(lead).Format("2006-01-02"); dates[len(dates)-1] != last {
+		dates = append(dates, last)
+	}
+	return dates
+}
+
+// scheduledDebateType reports which debate a fixture is due, if any:
+// pre-match from lead before kickoff until kickoff, post-match once the match
+// has finished
+func scheduledDebateType(status string, kickoff, now time.Time, lead time.Duration) (string, bool) {
+	if postMatchStatuses[status] {
+		return "post_match", true
+	}
+	if (status == "NS" || status == "TBD") && !now.Before(kickoff.Add(-lead)) && now.Before(kickoff) {
+		return "pre_match", true
+	}
+	return "", false
+}
+
+func scheduledDebateJobKey(fixtureID int, debateType string) string {
+	return fmt.Sprintf("scheduled:%d:%s", fixtureID, debateType)
+}
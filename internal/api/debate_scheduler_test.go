@@ -0,0 +1,59 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScheduledDebateType(t *testing.T) {
+	kickoff := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	lead := 6 * time.Hour
+
+	tests := []struct {
+		name     string
+		status   string
+		now      time.Time
+		wantType string
+		wantOK   bool
+	}{
+		{"too early for pre-match", "NS", kickoff.Add(-7 * time.Hour), "", false},
+		{"pre-match window opens", "NS", kickoff.Add(-6 * time.Hour), "pre_match", true},
+		{"just before kickoff", "TBD", kickoff.Add(-time.Minute), "pre_match", true},
+		{"in play", "2H", kickoff.Add(time.Hour), "", false},
+		{"postponed", "PST", kickoff.Add(-time.Hour), "", false},
+		{"full time", "FT", kickoff.Add(2 * time.Hour), "post_match", true},
+		{"after extra time", "AET", kickoff.Add(2 * time.Hour), "post_match", true},
+		{"after penalties", "PEN", kickoff.Add(3 * time.Hour), "post_match", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debateType, ok := scheduledDebateType(tt.status, kickoff, tt.now, lead)
+			if debateType != tt.wantType || ok != tt.wantOK {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.wantType, tt.wantOK, debateType, ok)
+			}
+		})
+	}
+}
+
+func TestDebateScanDates(t *testing.T) {
+	tests := []struct {
+		now  time.Time
+		lead time.Duration
+		want []string
+	}{
+		{time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), 6 * time.Hour, []string{"2026-10-17"}},
+		// Late kickoffs from the day before may still be finishing
+		{time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC), 6 * time.Hour, []string{"2026-10-16", "2026-10-17"}},
+		// Tomorrow's early kickoffs are within the pre-match lead
+		{time.Date(2026, 10, 17, 22, 0, 0, 0, time.UTC), 6 * time.Hour, []string{"2026-10-17", "2026-10-18"}},
+		{time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), 48 * time.Hour, []string{"2026-10-17", "2026-10-18", "2026-10-19"}},
+	}
+
+	for _, tt := range tests {
+		if got := debateScanDates(tt.now, tt.lead); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("debateScanDates(%s, %s) = %v, want %v", tt.now, tt.lead, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/go-chi/chi"
+)
+
+type SetDebateStanceRequest struct {
+	DebateCardID int32 `json:"debate_card_id"`
+}
+
+// StanceTally is the share of a debate's poll picking one card
+type StanceTally struct {
+	DebateCardID int32   `json:"debate_card_id"`
+	Stance       string  `json:"stance"`
+	Count        int     `json:"count"`
+	Percentage   float64 `json:"percentage"`
+}
+
+// StanceBreakdown answers "what percent of fans agree?" for a debate
+type StanceBreakdown struct {
+	DebateID       int32         `json:"debate_id"`
+	TotalResponses int           `json:"total_responses"`
+	Stances        []StanceTally `json:"stances"`
+	UserCardID     *int32        `json:"user_card_id,omitempty"`
+}
+
+// newStanceBreakdowns groups tally rows by debate and works out percentages,
+// rounded to one decimal place
+func newStanceBreakdowns(rows []database.GetDebateStanceTalliesRow) map[int32]*StanceBreakdown {
+	breakdowns := make(map[int32]*StanceBreakdown)
+	for _, row := range rows {
+		debateID := row.DebateID.Int32
+		breakdown, ok := breakdowns[debateID]
+		if !ok {
+			breakdown = &StanceBreakdown{DebateID: debateID, Stances: []StanceTally{}}
+			breakdowns[debateID] = breakdown
+		}
+		breakdown.TotalResponses += int(row.Count)
+		breakdown.Stances = append(breakdown.Stances, StanceTally{
+			DebateCardID: row.DebateCardID,
+			Stance:       row.Stance,
+			Count:        int(row.Count),
+		})
+	}
+
+	for _, breakdown := range breakdowns {
+		if breakdown.TotalResponses == 0 {
+			continue
+		}
+		for i := range breakdown.Stances {
+			share := float64(breakdown.Stances[i].Count) / float64(breakdown.TotalResponses)
+			breakdown.Stances[i].Percentage = math.Round(share*1000) / 10
+		}
+	}
+	return breakdowns
+}
+
+// getStanceBreakdown tallies a debate's poll, including the caller's own pick
+// when a user is signed in
+func (c *Config) getStanceBreakdown(ctx context.Context, debateID int32) (*StanceBreakdown, error) {
+	rows, err := c.DB.GetDebateStanceTallies(ctx, []int32{debateID})
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown, ok := newStanceBreakdowns(rows)[debateID]
+	if !ok {
+		breakdown = &StanceBreakdown{DebateID: debateID, Stances: []StanceTally{}}
+	}
+
+	if user, ok := userFromContext(ctx); ok {
+		stance, err := c.DB.GetUserDebateStance(ctx, database.GetUserDebateStanceParams{
+			DebateID: debateID,
+			UserID:   user.ID,
+		})
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			breakdown.UserCardID = &stance.DebateCardID
+		}
+	}
+	return breakdown, nil
+}
+
+// loadDebateParam loads the debate named by the ID in the URL, writing the
+// error response when it does not exist
+func (c *Config) loadDebateParam(w http.ResponseWriter, r *http.Request) (database.Debate, bool) {
+	debateID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid debate ID")
+		return database.Debate{}, false
+	}
+
+	debate, err := c.DB.GetDebate(r.Context(), int32(debateID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Debate not found")
+			return database.Debate{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate: %v", err))
+		return database.Debate{}, false
+	}
+	return debate, true
+}
+
+// getLocalizedStanceBreakdown tallies the poll a debate shares with its
+// translations, shown with the debate's own card IDs
+func (c *Config) getLocalizedStanceBreakdown(ctx context.Context, debate database.Debate) (*StanceBreakdown, error) {
+	breakdown, err := c.getStanceBreakdown(ctx, canonicalDebateID(debate))
+	if err != nil {
+		return nil, err
+	}
+	return c.localizeStanceBreakdown(ctx, debate, breakdown)
+}
+
+func (c *Config) getDebateStances(w http.ResponseWriter, r *http.Request) {
+	debate, ok := c.loadDebateParam(w, r)
+	if !ok {
+		return
+	}
+
+	breakdown, err := c.getLocalizedStanceBreakdown(r.Context(), debate)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate stances: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, breakdown)
+}
+
+// setDebateStance records the caller's pick in a debate's poll, replacing any
+// earlier pick. Picks in a translation are recorded in the canonical debate's
+// poll.
+func (c *Config) setDebateStance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	debate, ok := c.loadDebateParam(w, r)
+	if !ok {
+		return
+	}
+	debateID := canonicalDebateID(debate)
+
+	var req SetDebateStanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.DebateCardID == 0 {
+		respondWithError(w, http.StatusBadRequest, "debate_card_id is required")
+		return
+	}
+
+	if debate.CanonicalDebateID.Valid {
+		card, err := c.DB.GetDebateCard(ctx, req.DebateCardID)
+		if err != nil && err != sql.ErrNoRows {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate card: %v", err))
+			return
+		}
+		if err == sql.ErrNoRows || card.DebateID.Int32 != debate.ID || !card.CanonicalCardID.Valid {
+			respondWithError(w, http.StatusNotFound, "Debate card not found in this debate")
+			return
+		}
+		req.DebateCardID = card.CanonicalCardID.Int32
+	}
+
+	user, _ := userFromContext(ctx)
+
+	_, err := c.DB.UpsertDebateStance(ctx, database.UpsertDebateStanceParams{
+		DebateID:     debateID,
+		UserID:       user.ID,
+		DebateCardID: req.DebateCardID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Debate card not found in this debate")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set debate stance: %v", err))
+		return
+	}
+
+	breakdown, err := c.getStanceBreakdown(ctx, debateID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate stances: %v", err))
+		return
+	}
+	c.publishDebateEvent(ctx, debateID, debateEventStances, breakdown)
+
+	breakdown, err = c.localizeStanceBreakdown(ctx, debate, breakdown)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate stances: %v", err))
+		return
+	}
+	respondWithJSON(w, http.StatusOK, breakdown)
+}
+
+// deleteDebateStance withdraws the caller from a debate's poll
+func (c *Config) deleteDebateStance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	debate, ok := c.loadDebateParam(w, r)
+	if !ok {
+		return
+	}
+	debateID := canonicalDebateID(debate)
+
+	user, _ := userFromContext(ctx)
+
+	deleted, err := c.DB.DeleteDebateStance(ctx, database.DeleteDebateStanceParams{
+		DebateID: debateID,
+		UserID:   user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete debate stance: %v", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Stance not found")
+		return
+	}
+
+	breakdown, err := c.getStanceBreakdown(ctx, debateID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate stances: %v", err))
+		return
+	}
+	c.publishDebateEvent(ctx, debateID, debateEventStances, breakdown)
+
+	breakdown, err = c.localizeStanceBreakdown(ctx, debate, breakdown)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate stances: %v", err))
+		return
+	}
+	respondWithJSON(w, http.StatusOK, breakdown)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+)
+
+func TestNewStanceBreakdowns(t *testing.T) {
+	debate := func(id int32) sql.NullInt32 { return sql.NullInt32{Int32: id, Valid: true} }
+	rows := []database.GetDebateStanceTalliesRow{
+		{DebateID: debate(1), DebateCardID: 10, Stance: "agree", Count: 2},
+		{DebateID: debate(1), DebateCardID: 11, Stance: "disagree", Count: 1},
+		{DebateID: debate(1), DebateCardID: 12, Stance: "wildcard", Count: 0},
+		{DebateID: debate(2), DebateCardID: 20, Stance: "agree", Count: 0},
+	}
+
+	breakdowns := newStanceBreakdowns(rows)
+	if len(breakdowns) != 2 {
+		t.Fatalf("Expected 2 breakdowns, got %d", len(breakdowns))
+	}
+
+	first := breakdowns[1]
+	if first.TotalResponses != 3 {
+		t.Errorf("Expected 3 responses, got %d", first.TotalResponses)
+	}
+	expected := []float64{66.7, 33.3, 0}
+	for i, tally := range first.Stances {
+		if tally.Percentage != expected[i] {
+			t.Errorf("Expected %s to be %.1f%%, got %.1f%%", tally.Stance, expected[i], tally.Percentage)
+		}
+	}
+
+	// A poll nobody has answered reports zero rather than dividing by zero
+	second := breakdowns[2]
+	if second.TotalResponses != 0 || second.Stances[0].Percentage != 0 {
+		t.Errorf("Expected an empty poll, got %+v", second)
+	}
+}
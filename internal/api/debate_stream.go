@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/cache"
+)
+
+// Debate stream event types
+const (
+	debateEventVotes     = "votes"
+	debateEventComment   = "comment"
+	debateEventAnalytics = "analytics"
+	debateEventStances   = "stances"
+)
+
+// streamHeartbeatInterval keeps idle streams open through proxies that drop
+// quiet connections
+const streamHeartbeatInterval = 25 * time.Second
+
+// DebateEvent is one update pushed to a debate's stream subscribers
+type DebateEvent struct {
+	Type     string      `json:"type"`
+	DebateID int32       `json:"debate_id"`
+	Data     interface{} `json:"data"`
+}
+
+// CardVoteCounts carries the new vote counts of the one card whose votes
+// changed
+type CardVoteCounts struct {
+	DebateCardID int32      `json:"debate_card_id"`
+	VoteCounts   VoteCounts `json:"vote_counts"`
+}
+
+// newDefaultPubSub uses Redis when the cache is backed by it and falls back to
+// process memory otherwise
+func newDefaultPubSub(c cache.CacheInterface) cache.PubSub {
+	if pubsub, ok := c.(cache.PubSub); ok {
+		return pubsub
+	}
+	return cache.NewMemoryPubSub()
+}
+
+func debateChannel(debateID int32) string {
+	return fmt.Sprintf("debate:%d:events", debateID)
+}
+
+// debateStreamHub shares one pub/sub subscription per debate between every
+// client on this instance streaming that debate
+type debateStreamHub struct {
+	pubsub cache.PubSub
+
+	mu     sync.Mutex
+	topics map[int32]*debateTopic
+}
+
+type debateTopic struct {
+	debateID     int32
+	subscription cache.Subscription
+	clients      map[chan []byte]struct{}
+}
+
+func newDebateStreamHub(pubsub cache.PubSub) *debateStreamHub {
+	return &debateStreamHub{pubsub: pubsub, topics: make(map[int32]*debateTopic)}
+}
+
+// subscribe registers a client for a debate's events. The returned function
+// unregisters it.
+func (h *debateStreamHub) subscribe(debateID int32) (<-chan []byte, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	topic, ok := h.topics[debateID]
+	if !ok {
+		// The subscription outlives the request that opened it
+		subscription, err := h.pubsub.Subscribe(context.Background(), debateChannel(debateID))
+		if err != nil {
+			return nil, nil, err
+		}
+		topic = &debateTopic{debateID: debateID, subscription: subscription, clients: make(map[chan []byte]struct{})}
+		h.topics[debateID] = topic
+		go h.fanOut(topic)
+	}
+
+	client := make(chan []byte, 16)
+	topic.clients[client] = struct{}{}
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(topic.clients, client)
+		if len(topic.clients) == 0 && h.topics[debateID] == topic {
+			delete(h.topics, debateID)
+			topic.subscription.Close()
+		}
+	}
+	return client, unsubscribe, nil
+}
+
+// fanOut copies a debate's messages to its clients. A client too slow to keep
+// up misses messages rather than holding up the others. If the subscription
+// drops while clients remain, the topic is forgotten so the next client
+// subscribes afresh, and the remaining clients' channels are closed so their
+// streams end and they reconnect.
+func (h *debateStreamHub) fanOut(topic *debateTopic) {
+	for message := range topic.subscription.Messages() {
+		h.mu.Lock()
+		for client := range topic.clients {
+			select {
+			case client <- message:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.topics[topic.debateID] == topic {
+		delete(h.topics, topic.debateID)
+		topic.subscription.Close()
+	}
+	for client := range topic.clients {
+		close(client)
+		delete(topic.clients, client)
+	}
+}
+
+// publishDebateEvent sends an event to the debate's stream subscribers on
+// every instance. Failures are logged; streaming is best effort.
+func (c *Config) publishDebateEvent(ctx context.Context, debateID int32, eventType string, data interface{}) {
+	message, err := json.Marshal(DebateEvent{Type: eventType, DebateID: debateID, Data: data})
+	if err != nil {
+		fmt.Printf("Failed to encode debate event: %v\n", err)
+		return
+	}
+	if err := c.PubSub.Publish(ctx, debateChannel(debateID), message); err != nil {
+		fmt.Printf("Failed to publish debate event: %v\n", err)
+	}
+}
+
+// streamDebate pushes a debate's vote, stance, comment and analytics updates to
+// the client as they happen, over a WebSocket when the request asks to upgrade
+// and as server-sent events otherwise. A translation's stream only carries
+// its comments; its votes, stances and analytics are its canonical debate's.
+func (c *Config) streamDebate(w http.ResponseWriter, r *http.Request) {
+	debate, ok := c.loadDebateParam(w, r)
+	if !ok {
+		return
+	}
+
+	events, unsubscribe, err := c.streams.subscribe(debate.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to subscribe to debate: %v", err))
+		return
+	}
+	defer unsubscribe()
+
+	if isWebSocketUpgrade(r) {
+		streamDebateWebSocket(w, r, events)
+		return
+	}
+	streamSSE(w, r, events)
+}
+
+// streamSSE writes each message from events as a server-sent event until the
+// client disconnects or events is closed
+func streamSSE(w http.ResponseWriter, r *http.Request, events <-chan []byte) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx buffering the stream
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case message, ok := <-events:
+			if !ok {
+				return
+			}
+			w.Write(formatSSEEvent(message))
+		}
+		flusher.Flush()
+	}
+}
+
+// formatSSEEvent frames a JSON stream message as a server-sent event named
+// after its type
+func formatSSEEvent(message []byte) []byte {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(message, &event); err != nil || event.Type == "" {
+		event.Type = "message"
+	}
+	return []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event.Type, message))
+}
+
+func streamDebateWebSocket(w http.ResponseWriter, r *http.Request, events <-chan []byte) {
+	ws, ok := upgradeWebSocket(w, r)
+	if !ok {
+		return
+	}
+	defer ws.Close()
+
+	// A hijacked connection's request context is not cancelled when the
+	// client leaves, so the read loop reports it instead
+	done := make(chan struct{})
+	go ws.readLoop(done)
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case <-heartbeat.C:
+			err = ws.writeFrame(websocketPing, nil)
+		case message, ok := <-events:
+			if !ok {
+				return
+			}
+			err = ws.writeFrame(websocketText, message)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
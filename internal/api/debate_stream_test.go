@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/cache"
+)
+
+func TestWebSocketAccept(t *testing.T) {
+	// Example handshake from RFC 6455 section 1.3
+	if got := websocketAccept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Expected s3pPLMBiTxaQ9kYGzzhZRbK+xOo=, got %s", got)
+	}
+}
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	tests := []struct {
+		upgrade    string
+		connection string
+		want       bool
+	}{
+		{"websocket", "Upgrade", true},
+		{"WebSocket", "keep-alive, Upgrade", true},
+		{"websocket", "keep-alive", false},
+		{"", "Upgrade", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/debates/1/stream", nil)
+		r.Header.Set("Upgrade", tt.upgrade)
+		r.Header.Set("Connection", tt.connection)
+		if got := isWebSocketUpgrade(r); got != tt.want {
+			t.Errorf("isWebSocketUpgrade(%q, %q) = %v, want %v", tt.upgrade, tt.connection, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSSEEvent(t *testing.T) {
+	message := []byte(`{"type":"votes","debate_id":1,"data":{}}`)
+	want := "event: votes\ndata: " + string(message) + "\n\n"
+	if got := string(formatSSEEvent(message)); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if got := string(formatSSEEvent([]byte("not json"))); got != "event: message\ndata: not json\n\n" {
+		t.Errorf("Expected an unnamed message event, got %q", got)
+	}
+}
+
+func TestDebateStreamHub(t *testing.T) {
+	pubsub := cache.NewMemoryPubSub()
+	hub := newDebateStreamHub(pubsub)
+	ctx := context.Background()
+
+	first, unsubscribeFirst, err := hub.subscribe(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, unsubscribeSecond, _ := hub.subscribe(1)
+	if len(hub.topics) != 1 {
+		t.Errorf("Expected clients of one debate to share a topic, got %d topics", len(hub.topics))
+	}
+
+	pubsub.Publish(ctx, debateChannel(1), []byte("update"))
+	for i, events := range []<-chan []byte{first, second} {
+		select {
+		case message := <-events:
+			if string(message) != "update" {
+				t.Errorf("Expected client %d to receive update, got %s", i+1, message)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected client %d to receive the message", i+1)
+		}
+	}
+
+	unsubscribeFirst()
+	if len(hub.topics) != 1 {
+		t.Error("Expected the topic to stay open while a client remains")
+	}
+	unsubscribeSecond()
+	if len(hub.topics) != 0 {
+		t.Error("Expected the topic to close with its last client")
+	}
+}
+
+func TestDebateStreamHubDroppedSubscription(t *testing.T) {
+	hub := newDebateStreamHub(cache.NewMemoryPubSub())
+
+	events, unsubscribe, err := hub.subscribe(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer unsubscribe()
+
+	hub.mu.Lock()
+	dropped := hub.topics[1]
+	hub.mu.Unlock()
+	dropped.subscription.Close()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("Expected the client's channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the client's channel to be closed when the subscription dropped")
+	}
+
+	hub.mu.Lock()
+	remaining := len(hub.topics)
+	hub.mu.Unlock()
+	if remaining != 0 {
+		t.Errorf("Expected the dropped topic to be forgotten, got %d topics", remaining)
+	}
+
+	// The next client subscribes afresh
+	_, unsubscribeAgain, err := hub.subscribe(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer unsubscribeAgain()
+	hub.mu.Lock()
+	resubscribed := hub.topics[1]
+	hub.mu.Unlock()
+	if resubscribed == nil || resubscribed == dropped {
+		t.Error("Expected a new subscription for the next client")
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ArronJLinton/fucci-api/internal/ai"
 	"github.com/ArronJLinton/fucci-api/internal/database"
 	"github.com/go-chi/chi"
+	"github.com/lib/pq"
 )
 
 // Debate API types
@@ -28,6 +31,7 @@ type GenerateDebateRequest struct {
 	MatchID         string `json:"match_id"`
 	DebateType      string `json:"debate_type"`                // "pre_match" or "post_match"
 	ForceRegenerate bool   `json:"force_regenerate,omitempty"` // Force regeneration even if cached
+	Locale          string `json:"locale,omitempty"`           // Language to generate in, "en" when unset
 }
 
 type CreateDebateCardRequest struct {
@@ -59,8 +63,18 @@ type DebateResponse struct {
 	AIGenerated bool                     `json:"ai_generated"`
 	CreatedAt   time.Time                `json:"created_at"`
 	UpdatedAt   time.Time                `json:"updated_at"`
+	DeletedAt   *time.Time               `json:"deleted_at,omitempty"`
 	Cards       []DebateCardResponse     `json:"cards,omitempty"`
 	Analytics   *DebateAnalyticsResponse `json:"analytics,omitempty"`
+	Stances     *StanceBreakdown         `json:"stances,omitempty"`
+	// PromptTemplateVersion is the prompt template version an AI debate was
+	// generated with, 0 being the built-in template
+	PromptTemplateVersion *int32 `json:"prompt_template_version,omitempty"`
+	ExperimentVariantID   *int32 `json:"experiment_variant_id,omitempty"`
+	Locale                string `json:"locale"`
+	// CanonicalDebateID is set on translations, whose votes and stances are
+	// counted with the canonical debate's
+	CanonicalDebateID *int32 `json:"canonical_debate_id,omitempty"`
 }
 
 type DebateCardResponse struct {
@@ -74,6 +88,9 @@ type DebateCardResponse struct {
 	UpdatedAt   time.Time     `json:"updated_at"`
 	VoteCounts  VoteCounts    `json:"vote_counts"`
 	UserVote    *VoteResponse `json:"user_vote,omitempty"`
+	// CanonicalCardID is set on translated cards, whose votes are recorded
+	// against the canonical card
+	CanonicalCardID *int32 `json:"canonical_card_id,omitempty"`
 }
 
 type VoteCounts struct {
@@ -92,25 +109,29 @@ type VoteResponse struct {
 }
 
 type CommentResponse struct {
-	ID              int32     `json:"id"`
-	DebateID        int32     `json:"debate_id"`
-	ParentCommentID *int32    `json:"parent_comment_id,omitempty"`
-	UserID          int32     `json:"user_id"`
-	UserFirstName   string    `json:"user_first_name"`
-	UserLastName    string    `json:"user_last_name"`
-	Content         string    `json:"content"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              int32                 `json:"id"`
+	DebateID        int32                 `json:"debate_id"`
+	ParentCommentID *int32                `json:"parent_comment_id,omitempty"`
+	UserID          int32                 `json:"user_id"`
+	UserFirstName   string                `json:"user_first_name"`
+	UserLastName    string                `json:"user_last_name"`
+	Content         string                `json:"content"`
+	Removed         bool                  `json:"removed,omitempty"` // Soft deleted; content and author are withheld
+	Hidden          bool                  `json:"hidden,omitempty"`  // Hidden by moderation; content and author are withheld
+	Reactions       CommentReactionCounts `json:"reactions"`
+	CreatedAt       time.Time             `json:"created_at"`
+	UpdatedAt       time.Time             `json:"updated_at"`
 }
 
 type DebateAnalyticsResponse struct {
-	ID              int32     `json:"id"`
-	DebateID        int32     `json:"debate_id"`
-	TotalVotes      int       `json:"total_votes"`
-	TotalComments   int       `json:"total_comments"`
-	EngagementScore float64   `json:"engagement_score"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              int32            `json:"id"`
+	DebateID        int32            `json:"debate_id"`
+	TotalVotes      int              `json:"total_votes"`
+	TotalComments   int              `json:"total_comments"`
+	EngagementScore float64          `json:"engagement_score"`
+	Stances         *StanceBreakdown `json:"stances,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
 }
 
 // Debate API handlers
@@ -141,8 +162,14 @@ func (c *Config) createDebate(w http.ResponseWriter, r *http.Request) {
 		Headline:    req.Headline,
 		Description: sql.NullString{String: req.Description, Valid: req.Description != ""},
 		AiGenerated: sql.NullBool{Bool: req.AIGenerated, Valid: true},
+		Locale:      ai.DefaultLocale,
 	})
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("A %s debate already exists for this match", req.DebateType))
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create debate: %v", err))
 		return
 	}
@@ -165,9 +192,9 @@ func (c *Config) createDebate(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// getDebate returns a debate, or its translation in the language the caller
+// prefers when Accept-Language names one
 func (c *Config) getDebate(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
 	debateIDStr := chi.URLParam(r, "id")
 	debateID, err := strconv.ParseInt(debateIDStr, 10, 32)
 	if err != nil {
@@ -175,117 +202,21 @@ func (c *Config) getDebate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get debate
-	debate, err := c.DB.GetDebate(ctx, int32(debateID))
-	if err != nil {
-		if err == sql.ErrNoRows {
-			respondWithError(w, http.StatusNotFound, "Debate not found")
-			return
-		}
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate: %v", err))
-		return
-	}
-
-	// Get debate cards
-	cards, err := c.DB.GetDebateCards(ctx, sql.NullInt32{Int32: debate.ID, Valid: true})
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate cards: %v", err))
-		return
-	}
-
-	// Get analytics
-	analytics, err := c.DB.GetDebateAnalytics(ctx, sql.NullInt32{Int32: debate.ID, Valid: true})
-	if err != nil && err != sql.ErrNoRows {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate analytics: %v", err))
-		return
-	}
-
-	// Build response
-	response := DebateResponse{
-		ID:          debate.ID,
-		MatchID:     debate.MatchID,
-		DebateType:  debate.DebateType,
-		Headline:    debate.Headline,
-		Description: debate.Description.String,
-		AIGenerated: debate.AiGenerated.Bool,
-		CreatedAt:   debate.CreatedAt.Time,
-		UpdatedAt:   debate.UpdatedAt.Time,
-	}
-
-	// Add cards with vote counts
-	cardIDs := make([]int32, len(cards))
-	for i, card := range cards {
-		cardIDs[i] = card.ID
-	}
-
-	if len(cardIDs) > 0 {
-		voteCounts, err := c.DB.GetVoteCounts(ctx, cardIDs)
+	id := int32(debateID)
+	if locales := preferredLocales(r.Header.Get("Accept-Language")); len(locales) > 0 {
+		id, err = c.debateVariantID(r.Context(), id, locales)
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get vote counts: %v", err))
-			return
-		}
-
-		// Build vote counts map
-		voteCountsMap := make(map[int32]VoteCounts)
-		for _, vc := range voteCounts {
-			if vc.DebateCardID.Valid {
-				counts := voteCountsMap[vc.DebateCardID.Int32]
-				switch vc.VoteType {
-				case "upvote":
-					counts.Upvotes = int(vc.Count)
-				case "downvote":
-					counts.Downvotes = int(vc.Count)
-				case "emoji":
-					if counts.Emojis == nil {
-						counts.Emojis = make(map[string]int)
-					}
-					if vc.Emoji.Valid {
-						counts.Emojis[vc.Emoji.String] = int(vc.Count)
-					}
-				}
-				voteCountsMap[vc.DebateCardID.Int32] = counts
-			}
-		}
-
-		// Build card responses
-		for _, card := range cards {
-			cardResponse := DebateCardResponse{
-				ID:          card.ID,
-				DebateID:    card.DebateID.Int32,
-				Stance:      card.Stance,
-				Title:       card.Title,
-				Description: card.Description.String,
-				AIGenerated: card.AiGenerated.Bool,
-				CreatedAt:   card.CreatedAt.Time,
-				UpdatedAt:   card.UpdatedAt.Time,
-				VoteCounts:  voteCountsMap[card.ID],
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "Debate not found")
+				return
 			}
-			response.Cards = append(response.Cards, cardResponse)
-		}
-	}
-
-	// Add analytics if available
-	if err == nil {
-		engagementScore := 0.0
-		if analytics.EngagementScore.Valid {
-			// Parse engagement score from string
-			if score, err := strconv.ParseFloat(analytics.EngagementScore.String, 64); err == nil {
-				engagementScore = score
-			}
-		}
-
-		response.Analytics = &DebateAnalyticsResponse{
-			ID:              analytics.ID,
-			DebateID:        analytics.DebateID.Int32,
-			TotalVotes:      int(analytics.TotalVotes.Int32),
-			TotalComments:   int(analytics.TotalComments.Int32),
-			EngagementScore: engagementScore,
-			CreatedAt:       analytics.CreatedAt.Time,
-			UpdatedAt:       analytics.UpdatedAt.Time,
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate: %v", err))
+			return
 		}
 	}
+	w.Header().Set("Vary", "Accept-Language")
 
-	respondWithJSON(w, http.StatusOK, response)
+	c.getDebateByID(w, r, id)
 }
 
 func (c *Config) getDebatesByMatch(w http.ResponseWriter, r *http.Request) {
@@ -303,10 +234,31 @@ func (c *Config) getDebatesByMatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// List one debate per family, in the caller's language when there is a
+	// translation into it
+	debates = pickDebateVariants(debates, preferredLocales(r.Header.Get("Accept-Language")))
+	w.Header().Set("Vary", "Accept-Language")
+
+	debateIDs := make([]int32, len(debates))
+	for i, debate := range debates {
+		debateIDs[i] = canonicalDebateID(debate)
+	}
+	tallies, err := c.DB.GetDebateStanceTallies(ctx, debateIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate stances: %v", err))
+		return
+	}
+	stances := newStanceBreakdowns(tallies)
+
 	// Convert to response format
 	var response []DebateResponse
 	for _, debate := range debates {
-		response = append(response, DebateResponse{
+		debateStances, err := c.localizeStanceBreakdown(ctx, debate, stances[canonicalDebateID(debate)])
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate stances: %v", err))
+			return
+		}
+		debateResponse := DebateResponse{
 			ID:          debate.ID,
 			MatchID:     debate.MatchID,
 			DebateType:  debate.DebateType,
@@ -315,7 +267,13 @@ func (c *Config) getDebatesByMatch(w http.ResponseWriter, r *http.Request) {
 			AIGenerated: debate.AiGenerated.Bool,
 			CreatedAt:   debate.CreatedAt.Time,
 			UpdatedAt:   debate.UpdatedAt.Time,
-		})
+			Stances:     debateStances,
+			Locale:      debate.Locale,
+		}
+		if debate.CanonicalDebateID.Valid {
+			debateResponse.CanonicalDebateID = &debate.CanonicalDebateID.Int32
+		}
+		response = append(response, debateResponse)
 	}
 
 	respondWithJSON(w, http.StatusOK, response)
@@ -380,30 +338,132 @@ func (c *Config) createVote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user ID from context (you'll need to implement authentication)
-	userID := int32(1) // TODO: Get from auth context
+	if req.VoteType == "emoji" && req.Emoji == "" {
+		respondWithError(w, http.StatusBadRequest, "emoji is required for emoji votes")
+		return
+	}
 
-	// Create vote
-	vote, err := c.DB.CreateVote(ctx, database.CreateVoteParams{
-		DebateCardID: sql.NullInt32{Int32: req.DebateCardID, Valid: true},
-		UserID:       sql.NullInt32{Int32: userID, Valid: true},
-		VoteType:     req.VoteType,
-		Emoji:        sql.NullString{String: req.Emoji, Valid: req.Emoji != ""},
-	})
+	// Votes on a translation's cards count towards the canonical cards
+	cardID, err := c.voteCardID(ctx, req.DebateCardID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Debate card not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate card: %v", err))
+		return
+	}
+	req.DebateCardID = cardID
+
+	user, _ := userFromContext(ctx)
+
+	// Create vote. Upvotes and downvotes are mutually exclusive, so casting
+	// one replaces the user's other directional vote on the card.
+	var vote database.Vote
+	if req.VoteType == "emoji" {
+		vote, err = c.DB.CreateVote(ctx, database.CreateVoteParams{
+			DebateCardID: sql.NullInt32{Int32: req.DebateCardID, Valid: true},
+			UserID:       sql.NullInt32{Int32: user.ID, Valid: true},
+			VoteType:     req.VoteType,
+			Emoji:        sql.NullString{String: req.Emoji, Valid: true},
+		})
+	} else {
+		vote, err = c.DB.CastDirectionalVote(ctx, database.CastDirectionalVoteParams{
+			DebateCardID: sql.NullInt32{Int32: req.DebateCardID, Valid: true},
+			UserID:       sql.NullInt32{Int32: user.ID, Valid: true},
+			VoteType:     req.VoteType,
+		})
+	}
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			respondWithError(w, http.StatusNotFound, "Debate card not found")
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create vote: %v", err))
 		return
 	}
 
 	// Update analytics
-	c.updateDebateAnalytics(ctx, req.DebateCardID)
+	c.updateDebateAnalyticsForCard(ctx, req.DebateCardID)
 
 	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
 		"message": "Vote created successfully",
 		"vote_id": vote.ID,
+		"vote":    newVoteResponse(vote),
 	})
 }
 
+// deleteVote retracts the caller's upvote or downvote on a card, or their
+// emoji votes when vote_type=emoji is given
+func (c *Config) deleteVote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	parsedCardID, err := strconv.ParseInt(chi.URLParam(r, "cardId"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid debate card ID")
+		return
+	}
+	cardID, err := c.voteCardID(ctx, int32(parsedCardID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Debate card not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate card: %v", err))
+		return
+	}
+
+	user, _ := userFromContext(ctx)
+	debateCardID := sql.NullInt32{Int32: cardID, Valid: true}
+	userID := sql.NullInt32{Int32: user.ID, Valid: true}
+
+	switch voteType := r.URL.Query().Get("vote_type"); voteType {
+	case "", "upvote", "downvote":
+		deleted, err := c.DB.DeleteDirectionalVote(ctx, database.DeleteDirectionalVoteParams{
+			DebateCardID: debateCardID,
+			UserID:       userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete vote: %v", err))
+			return
+		}
+		if deleted == 0 {
+			respondWithError(w, http.StatusNotFound, "Vote not found")
+			return
+		}
+	case "emoji":
+		err := c.DB.DeleteVote(ctx, database.DeleteVoteParams{
+			DebateCardID: debateCardID,
+			UserID:       userID,
+			VoteType:     voteType,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete vote: %v", err))
+			return
+		}
+	default:
+		respondWithError(w, http.StatusBadRequest, "vote_type must be 'upvote', 'downvote', or 'emoji'")
+		return
+	}
+
+	// Update analytics
+	c.updateDebateAnalyticsForCard(ctx, cardID)
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Vote removed successfully"})
+}
+
+func newVoteResponse(vote database.Vote) VoteResponse {
+	return VoteResponse{
+		ID:           vote.ID,
+		DebateCardID: vote.DebateCardID.Int32,
+		UserID:       vote.UserID.Int32,
+		VoteType:     vote.VoteType,
+		Emoji:        vote.Emoji.String,
+		CreatedAt:    vote.CreatedAt.Time,
+	}
+}
+
 func (c *Config) createComment(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -419,8 +479,10 @@ func (c *Config) createComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user ID from context (you'll need to implement authentication)
-	userID := int32(1) // TODO: Get from auth context
+	user, _ := userFromContext(ctx)
+
+	// Screen the content before it is stored; held comments are saved hidden
+	moderated := c.moderateComment(r, req.Content)
 
 	// Create comment
 	var parentCommentID sql.NullInt32
@@ -431,10 +493,12 @@ func (c *Config) createComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	comment, err := c.DB.CreateComment(ctx, database.CreateCommentParams{
-		DebateID:        sql.NullInt32{Int32: req.DebateID, Valid: true},
-		ParentCommentID: parentCommentID,
-		UserID:          sql.NullInt32{Int32: userID, Valid: true},
-		Content:         req.Content,
+		DebateID:         sql.NullInt32{Int32: req.DebateID, Valid: true},
+		ParentCommentID:  parentCommentID,
+		UserID:           sql.NullInt32{Int32: user.ID, Valid: true},
+		Content:          moderated.Content,
+		ModerationStatus: commentModerationStatus(moderated.Decision),
+		ModerationReason: sql.NullString{String: moderated.Reason, Valid: moderated.Reason != ""},
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create comment: %v", err))
@@ -444,9 +508,18 @@ func (c *Config) createComment(w http.ResponseWriter, r *http.Request) {
 	// Update analytics
 	c.updateDebateAnalytics(ctx, req.DebateID)
 
+	message := "Comment created successfully"
+	if comment.ModerationStatus == commentStatusHeld {
+		message = "Comment held for review"
+	} else if row, err := c.DB.GetComment(ctx, comment.ID); err != nil {
+		fmt.Printf("Failed to get comment: %v\n", err)
+	} else {
+		c.publishDebateEvent(ctx, req.DebateID, debateEventComment, newCommentResponse(database.GetCommentsRow(row)))
+	}
 	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
-		"message":    "Comment created successfully",
-		"comment_id": comment.ID,
+		"message":           message,
+		"comment_id":        comment.ID,
+		"moderation_status": comment.ModerationStatus,
 	})
 }
 
@@ -466,24 +539,21 @@ func (c *Config) getComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	commentIDs := make([]int32, len(comments))
+	for i, comment := range comments {
+		commentIDs[i] = comment.ID
+	}
+	reactions, err := c.getCommentReactions(ctx, commentIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get comment reactions: %v", err))
+		return
+	}
+
 	// Convert to response format
 	var response []CommentResponse
 	for _, comment := range comments {
-		commentResponse := CommentResponse{
-			ID:            comment.ID,
-			DebateID:      comment.DebateID.Int32,
-			UserID:        comment.UserID.Int32,
-			UserFirstName: comment.Firstname,
-			UserLastName:  comment.Lastname,
-			Content:       comment.Content,
-			CreatedAt:     comment.CreatedAt.Time,
-			UpdatedAt:     comment.UpdatedAt.Time,
-		}
-
-		if comment.ParentCommentID.Valid {
-			commentResponse.ParentCommentID = &comment.ParentCommentID.Int32
-		}
-
+		commentResponse := newCommentResponse(comment)
+		commentResponse.Reactions = reactions[comment.ID]
 		response = append(response, commentResponse)
 	}
 
@@ -494,7 +564,10 @@ func (c *Config) generateAIPrompt(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	if c.AIPromptGenerator == nil {
-		respondWithError(w, http.StatusNotImplemented, "AI prompt generation is not configured. Please set the OpenAI API key.")
+		respondWithError(w, http.StatusNotImplemented, "AI prompt generation is not configured. Please configure an LLM provider.")
+		return
+	}
+	if !c.checkLLMBudget(w, r) {
 		return
 	}
 
@@ -596,7 +669,7 @@ func (c *Config) generateDebate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	if c.AIPromptGenerator == nil {
-		respondWithError(w, http.StatusNotImplemented, "AI prompt generation is not configured. Please set the OpenAI API key.")
+		respondWithError(w, http.StatusNotImplemented, "AI prompt generation is not configured. Please configure an LLM provider.")
 		return
 	}
 
@@ -623,90 +696,176 @@ func (c *Config) generateDebate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if debate already exists for this match and type
-	existingDebates, err := c.DB.GetDebatesByMatch(ctx, req.MatchID)
-	if err == nil {
-		for _, existing := range existingDebates {
-			if existing.DebateType == req.DebateType {
-				if !req.ForceRegenerate {
+	locale := ai.DefaultLocale
+	if req.Locale != "" {
+		var ok bool
+		if locale, ok = supportedLocale(req.Locale); !ok {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("locale must be one of %s", strings.Join(supportedLocales(), ", ")))
+			return
+		}
+	}
+
+	// Check if debate already exists for this match, type and locale
+	if !req.ForceRegenerate {
+		existingDebates, err := c.DB.GetDebatesByMatch(ctx, req.MatchID)
+		if err == nil {
+			for _, existing := range existingDebates {
+				if existing.DebateType == req.DebateType && existing.Locale == locale {
 					// Return existing debate
 					c.getDebateByID(w, r, existing.ID)
 					return
-				} else {
-					// Soft delete existing debate to regenerate
-					err := c.DB.SoftDeleteDebate(ctx, existing.ID)
-					if err != nil {
-						respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to soft delete existing debate: %v", err))
-						return
-					}
-					fmt.Printf("Regenerating debate for match %s, type %s\n", req.MatchID, req.DebateType)
 				}
 			}
 		}
 	}
 
-	// Get basic match information
-	matchInfo, err := c.getMatchInfo(ctx, req.MatchID)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get match info: %v", err))
+	// Existing debates are served whatever the budget, but nothing new is
+	// generated once it is spent
+	if !c.checkLLMBudget(w, r) {
 		return
 	}
 
-	// Validate match status for debate type
-	if err := c.validateMatchStatusForDebateType(matchInfo.Status, req.DebateType); err != nil {
-		respondWithJSON(w, http.StatusOK, map[string]string{"info": err.Error()})
+	// Generation takes a while, so it runs as a job the client polls. A
+	// worker checks the match status before generating, and a regenerated
+	// debate only replaces the existing one once it has been stored.
+	job, err := c.enqueueDebateJob(ctx, requestDebateJobKey(), req.MatchID, req.DebateType, locale, req.ForceRegenerate)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to queue debate generation: %v", err))
 		return
 	}
 
+	w.Header().Set("Location", debateJobLocation(r, job.ID))
+	respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+		"message": "Debate generation queued",
+		"job":     newDebateJobResponse(job),
+	})
+}
+
+// debateJobLocation is the URL of a job's status under the path the debate
+// routes are mounted at, given the request to generate it
+func debateJobLocation(r *http.Request, jobID int32) string {
+	prefix := strings.TrimSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/generate")
+	return fmt.Sprintf("%s/jobs/%d", prefix, jobID)
+}
+
+// generateMatchDebate generates the debate a job asks for with the AI prompt
+// generator and stores it with storeMatchDebate
+func (c *Config) generateMatchDebate(ctx context.Context, job database.DebateJob, matchInfo *MatchInfo) (DebateResponse, error) {
+	matchID, debateType, locale := job.MatchID, job.DebateType, job.Locale
+
 	// Use the data aggregator to get comprehensive match data
 	aggregator := NewDebateDataAggregator(c)
-	matchData, err := aggregator.AggregateMatchData(ctx, c.buildMatchDataRequest(req.MatchID, matchInfo))
+	matchData, err := aggregator.AggregateMatchData(ctx, c.buildMatchDataRequest(matchID, matchInfo))
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to aggregate match data: %v", err))
-		return
+		return DebateResponse{}, fmt.Errorf("failed to aggregate match data: %w", err)
 	}
 
-	// Generate AI prompt
+	// Generate AI prompt, with the settings of an experiment variant when
+	// one is running for the debate type. Only English prompts are cached.
 	var prompt *ai.DebatePrompt
-	if req.DebateType == "pre_match" {
+	variant, promptVariant := c.experimentVariant(ctx, matchID, debateType)
+	if promptVariant == nil && locale != ai.DefaultLocale {
+		promptVariant = &ai.PromptVariant{}
+	}
+	if promptVariant != nil {
+		promptVariant.Locale = locale
+	}
+	switch {
+	case promptVariant != nil:
+		prompt, err = c.AIPromptGenerator.GenerateVariantPrompt(ctx, *matchData, debateType, *promptVariant)
+	case debateType == "pre_match":
 		prompt, err = c.AIPromptGenerator.GeneratePreMatchPrompt(ctx, *matchData)
-	} else {
+	default:
 		prompt, err = c.AIPromptGenerator.GeneratePostMatchPrompt(ctx, *matchData)
 	}
 
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to generate AI prompt: %v", err))
-		return
+		return DebateResponse{}, fmt.Errorf("failed to generate AI prompt: %w", err)
 	}
 
 	// Validate prompt structure
 	if prompt.Headline == "" || len(prompt.Cards) == 0 {
-		respondWithError(w, http.StatusInternalServerError, "Generated prompt is invalid (missing headline or cards)")
-		return
+		return DebateResponse{}, errors.New("generated prompt is invalid (missing headline or cards)")
+	}
+
+	return c.storeMatchDebate(ctx, job, prompt, matchData, variant)
+}
+
+// storeMatchDebate stores a job's generated debate with its cards and an
+// empty analytics record. The debate it replaces, if any, is retired and the
+// job completed in the same transaction, so either all of it is stored or
+// none of it is. The AI call before it is slow, so the transaction only
+// starts once there is something to store.
+func (c *Config) storeMatchDebate(ctx context.Context, job database.DebateJob, prompt *ai.DebatePrompt, matchData *ai.MatchData, variant *database.DebateExperimentVariant) (DebateResponse, error) {
+	matchID, debateType, locale := job.MatchID, job.DebateType, job.Locale
+
+	tx, err := c.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		return DebateResponse{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := c.DB.WithTx(tx)
+
+	if job.ForceRegenerate {
+		existingDebates, err := qtx.GetDebatesByMatch(ctx, matchID)
+		if err != nil {
+			return DebateResponse{}, fmt.Errorf("failed to get existing debates: %w", err)
+		}
+		for _, existing := range existingDebates {
+			if existing.DebateType != debateType {
+				continue
+			}
+			if err := qtx.SoftDeleteDebate(ctx, existing.ID); err != nil {
+				return DebateResponse{}, fmt.Errorf("failed to soft delete existing debate: %w", err)
+			}
+			fmt.Printf("Regenerating debate for match %s, type %s\n", matchID, debateType)
+		}
 	}
 
 	// Create the debate in the database
-	debate, err := c.DB.CreateDebate(ctx, database.CreateDebateParams{
-		MatchID:     req.MatchID,
-		DebateType:  req.DebateType,
-		Headline:    prompt.Headline,
-		Description: sql.NullString{String: prompt.Description, Valid: prompt.Description != ""},
-		AiGenerated: sql.NullBool{Bool: true, Valid: true},
+	debateParams := database.CreateDebateParams{
+		MatchID:               matchID,
+		DebateType:            debateType,
+		Headline:              prompt.Headline,
+		Description:           sql.NullString{String: prompt.Description, Valid: prompt.Description != ""},
+		AiGenerated:           sql.NullBool{Bool: true, Valid: true},
+		PromptTemplateVersion: sql.NullInt32{Int32: int32(prompt.TemplateVersion), Valid: true},
+		Locale:                locale,
+	}
+	if variant != nil {
+		debateParams.ExperimentVariantID = sql.NullInt32{Int32: variant.ID, Valid: true}
+	}
+	debate, err := qtx.CreateDebate(ctx, debateParams)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return DebateResponse{}, errCanonicalDebateExists
+		}
+		return DebateResponse{}, fmt.Errorf("failed to create debate: %w", err)
+	}
+
+	// Keep the match data so admins can preview prompt templates against it
+	matchDataJSON, err := json.Marshal(matchData)
+	if err != nil {
+		return DebateResponse{}, fmt.Errorf("failed to encode match data: %w", err)
+	}
+	err = qtx.CreateDebateMatchData(ctx, database.CreateDebateMatchDataParams{
+		DebateID:  debate.ID,
+		MatchData: matchDataJSON,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create debate: %v", err))
-		return
+		return DebateResponse{}, fmt.Errorf("failed to store match data: %w", err)
 	}
 
 	// Create analytics record
-	_, err = c.DB.CreateDebateAnalytics(ctx, database.CreateDebateAnalyticsParams{
+	_, err = qtx.CreateDebateAnalytics(ctx, database.CreateDebateAnalyticsParams{
 		DebateID:        sql.NullInt32{Int32: debate.ID, Valid: true},
 		TotalVotes:      sql.NullInt32{Int32: 0, Valid: true},
 		TotalComments:   sql.NullInt32{Int32: 0, Valid: true},
 		EngagementScore: sql.NullString{String: "0.0", Valid: true},
 	})
 	if err != nil {
-		fmt.Printf("Failed to create debate analytics: %v\n", err)
+		return DebateResponse{}, fmt.Errorf("failed to create debate analytics: %w", err)
 	}
 
 	// Create debate cards
@@ -725,7 +884,7 @@ func (c *Config) generateDebate(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Create the card in the database
-		dbCard, err := c.DB.CreateDebateCard(ctx, database.CreateDebateCardParams{
+		dbCard, err := qtx.CreateDebateCard(ctx, database.CreateDebateCardParams{
 			DebateID:    sql.NullInt32{Int32: debate.ID, Valid: true},
 			Stance:      card.Stance,
 			Title:       card.Title,
@@ -733,8 +892,7 @@ func (c *Config) generateDebate(w http.ResponseWriter, r *http.Request) {
 			AiGenerated: sql.NullBool{Bool: true, Valid: true},
 		})
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create debate card: %v", err))
-			return
+			return DebateResponse{}, fmt.Errorf("failed to create debate card: %w", err)
 		}
 
 		// Add to response
@@ -758,21 +916,30 @@ func (c *Config) generateDebate(w http.ResponseWriter, r *http.Request) {
 
 	// Ensure we have at least one card
 	if len(cardResponses) == 0 {
-		respondWithError(w, http.StatusInternalServerError, "No valid debate cards were created")
-		return
+		return DebateResponse{}, errors.New("no valid debate cards were created")
+	}
+
+	if err := completeDebateJob(ctx, qtx, job, debate.ID); err != nil {
+		return DebateResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return DebateResponse{}, fmt.Errorf("failed to commit debate: %w", err)
 	}
 
 	// Build the complete response
 	response := DebateResponse{
-		ID:          debate.ID,
-		MatchID:     debate.MatchID,
-		DebateType:  debate.DebateType,
-		Headline:    debate.Headline,
-		Description: debate.Description.String,
-		AIGenerated: debate.AiGenerated.Bool,
-		CreatedAt:   debate.CreatedAt.Time,
-		UpdatedAt:   debate.UpdatedAt.Time,
-		Cards:       cardResponses,
+		ID:                    debate.ID,
+		MatchID:               debate.MatchID,
+		DebateType:            debate.DebateType,
+		Headline:              debate.Headline,
+		Description:           debate.Description.String,
+		AIGenerated:           debate.AiGenerated.Bool,
+		CreatedAt:             debate.CreatedAt.Time,
+		UpdatedAt:             debate.UpdatedAt.Time,
+		Cards:                 cardResponses,
+		PromptTemplateVersion: &debate.PromptTemplateVersion.Int32,
+		Locale:                debate.Locale,
 		Analytics: &DebateAnalyticsResponse{
 			ID:              debate.ID,
 			DebateID:        debate.ID,
@@ -784,20 +951,14 @@ func (c *Config) generateDebate(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
-		"message": "Debate generated successfully",
-		"debate":  response,
-	})
+	return response, nil
 }
 
 // Helper function to get debate by ID (extracted from getDebate for reuse)
 func (c *Config) getDebateByID(w http.ResponseWriter, r *http.Request, debateID int32) {
-	ctx := r.Context()
-
-	// Get debate
-	debate, err := c.DB.GetDebate(ctx, debateID)
+	response, err := c.loadDebateResponse(r.Context(), debateID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			respondWithError(w, http.StatusNotFound, "Debate not found")
 			return
 		}
@@ -805,18 +966,31 @@ func (c *Config) getDebateByID(w http.ResponseWriter, r *http.Request, debateID
 		return
 	}
 
+	w.Header().Set("Content-Language", response.Locale)
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// loadDebateResponse builds a debate's full response: its cards with vote
+// counts and the caller's own votes, the stance poll and analytics. A
+// translation shows those of its canonical debate.
+func (c *Config) loadDebateResponse(ctx context.Context, debateID int32) (DebateResponse, error) {
+	// Get debate
+	debate, err := c.DB.GetDebate(ctx, debateID)
+	if err != nil {
+		return DebateResponse{}, err
+	}
+	canonicalID := canonicalDebateID(debate)
+
 	// Get debate cards
 	cards, err := c.DB.GetDebateCards(ctx, sql.NullInt32{Int32: debate.ID, Valid: true})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate cards: %v", err))
-		return
+		return DebateResponse{}, fmt.Errorf("failed to get debate cards: %w", err)
 	}
 
 	// Get analytics
-	analytics, err := c.DB.GetDebateAnalytics(ctx, sql.NullInt32{Int32: debate.ID, Valid: true})
+	analytics, err := c.DB.GetDebateAnalytics(ctx, sql.NullInt32{Int32: canonicalID, Valid: true})
 	if err != nil && err != sql.ErrNoRows {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate analytics: %v", err))
-		return
+		return DebateResponse{}, fmt.Errorf("failed to get debate analytics: %w", err)
 	}
 
 	// Build response
@@ -829,45 +1003,52 @@ func (c *Config) getDebateByID(w http.ResponseWriter, r *http.Request, debateID
 		AIGenerated: debate.AiGenerated.Bool,
 		CreatedAt:   debate.CreatedAt.Time,
 		UpdatedAt:   debate.UpdatedAt.Time,
+		Locale:      debate.Locale,
+	}
+	if debate.PromptTemplateVersion.Valid {
+		response.PromptTemplateVersion = &debate.PromptTemplateVersion.Int32
+	}
+	if debate.ExperimentVariantID.Valid {
+		response.ExperimentVariantID = &debate.ExperimentVariantID.Int32
+	}
+	if debate.CanonicalDebateID.Valid {
+		response.CanonicalDebateID = &debate.CanonicalDebateID.Int32
 	}
 
-	// Add cards with vote counts
+	// Add cards with vote counts, which are kept on the canonical cards
 	cardIDs := make([]int32, len(cards))
 	for i, card := range cards {
 		cardIDs[i] = card.ID
+		if card.CanonicalCardID.Valid {
+			cardIDs[i] = card.CanonicalCardID.Int32
+		}
 	}
 
 	if len(cardIDs) > 0 {
 		voteCounts, err := c.DB.GetVoteCounts(ctx, cardIDs)
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get vote counts: %v", err))
-			return
+			return DebateResponse{}, fmt.Errorf("failed to get vote counts: %w", err)
 		}
 
-		// Build vote counts map
-		voteCountsMap := make(map[int32]VoteCounts)
-		for _, vc := range voteCounts {
-			if vc.DebateCardID.Valid {
-				counts := voteCountsMap[vc.DebateCardID.Int32]
-				switch vc.VoteType {
-				case "upvote":
-					counts.Upvotes = int(vc.Count)
-				case "downvote":
-					counts.Downvotes = int(vc.Count)
-				case "emoji":
-					if counts.Emojis == nil {
-						counts.Emojis = make(map[string]int)
-					}
-					if vc.Emoji.Valid {
-						counts.Emojis[vc.Emoji.String] = int(vc.Count)
-					}
-				}
-				voteCountsMap[vc.DebateCardID.Int32] = counts
+		voteCountsMap := voteCountsByCard(voteCounts)
+
+		// Look up the caller's own votes so the UI can highlight them
+		userVotes := make(map[int32]VoteResponse)
+		if user, ok := userFromContext(ctx); ok {
+			votes, err := c.DB.GetUserDirectionalVotes(ctx, database.GetUserDirectionalVotesParams{
+				UserID:  sql.NullInt32{Int32: user.ID, Valid: true},
+				CardIds: cardIDs,
+			})
+			if err != nil {
+				return DebateResponse{}, fmt.Errorf("failed to get user votes: %w", err)
+			}
+			for _, vote := range votes {
+				userVotes[vote.DebateCardID.Int32] = newVoteResponse(vote)
 			}
 		}
 
 		// Build card responses
-		for _, card := range cards {
+		for i, card := range cards {
 			cardResponse := DebateCardResponse{
 				ID:          card.ID,
 				DebateID:    card.DebateID.Int32,
@@ -877,12 +1058,28 @@ func (c *Config) getDebateByID(w http.ResponseWriter, r *http.Request, debateID
 				AIGenerated: card.AiGenerated.Bool,
 				CreatedAt:   card.CreatedAt.Time,
 				UpdatedAt:   card.UpdatedAt.Time,
-				VoteCounts:  voteCountsMap[card.ID],
+				VoteCounts:  voteCountsMap[cardIDs[i]],
+			}
+			if vote, ok := userVotes[cardIDs[i]]; ok {
+				cardResponse.UserVote = &vote
+			}
+			if card.CanonicalCardID.Valid {
+				cardResponse.CanonicalCardID = &card.CanonicalCardID.Int32
 			}
 			response.Cards = append(response.Cards, cardResponse)
 		}
 	}
 
+	// Add the stance poll breakdown
+	stances, stancesErr := c.getStanceBreakdown(ctx, canonicalID)
+	if stancesErr != nil {
+		return DebateResponse{}, fmt.Errorf("failed to get debate stances: %w", stancesErr)
+	}
+	if debate.CanonicalDebateID.Valid {
+		stances = localizeStances(stances, debate.ID, translationCardIDs(cards))
+	}
+	response.Stances = stances
+
 	// Add analytics if available
 	if err == nil {
 		engagementScore := 0.0
@@ -899,12 +1096,13 @@ func (c *Config) getDebateByID(w http.ResponseWriter, r *http.Request, debateID
 			TotalVotes:      int(analytics.TotalVotes.Int32),
 			TotalComments:   int(analytics.TotalComments.Int32),
 			EngagementScore: engagementScore,
+			Stances:         stances,
 			CreatedAt:       analytics.CreatedAt.Time,
 			UpdatedAt:       analytics.UpdatedAt.Time,
 		}
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	return response, nil
 }
 
 // getMatchInfo gets basic match information
@@ -1052,12 +1250,44 @@ func (c *Config) getTopDebates(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Debates are ranked by their families' engagement, so only canonical
+	// debates are listed
 	debates, err := c.DB.GetTopDebates(ctx, limit)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get top debates: %v", err))
 		return
 	}
 
+	debateIDs := make([]int32, len(debates))
+	for i, debate := range debates {
+		debateIDs[i] = debate.ID
+	}
+	tallies, err := c.DB.GetDebateStanceTallies(ctx, debateIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate stances: %v", err))
+		return
+	}
+	stances := newStanceBreakdowns(tallies)
+
+	// Swap in translations into the caller's language
+	locales := preferredLocales(r.Header.Get("Accept-Language"))
+	families := make(map[int32][]database.Debate)
+	if len(locales) > 0 {
+		translations, err := c.DB.GetDebateTranslations(ctx, database.GetDebateTranslationsParams{
+			CanonicalDebateIds: debateIDs,
+			Locales:            locales,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate translations: %v", err))
+			return
+		}
+		for _, translation := range translations {
+			id := translation.CanonicalDebateID.Int32
+			families[id] = append(families[id], translation)
+		}
+	}
+	w.Header().Set("Vary", "Accept-Language")
+
 	// Convert to response format
 	var response []DebateResponse
 	for _, debate := range debates {
@@ -1070,6 +1300,24 @@ func (c *Config) getTopDebates(w http.ResponseWriter, r *http.Request) {
 			AIGenerated: debate.AiGenerated.Bool,
 			CreatedAt:   debate.CreatedAt.Time,
 			UpdatedAt:   debate.UpdatedAt.Time,
+			Locale:      debate.Locale,
+		}
+		debateStances := stances[debate.ID]
+
+		family := append([]database.Debate{{ID: debate.ID, Locale: debate.Locale}}, families[debate.ID]...)
+		if variant, ok := pickDebateVariant(family, locales); ok && variant.CanonicalDebateID.Valid {
+			debateResponse.ID = variant.ID
+			debateResponse.Headline = variant.Headline
+			debateResponse.Description = variant.Description.String
+			debateResponse.Locale = variant.Locale
+			debateResponse.CanonicalDebateID = &variant.CanonicalDebateID.Int32
+			debateResponse.CreatedAt = variant.CreatedAt.Time
+			debateResponse.UpdatedAt = variant.UpdatedAt.Time
+			debateStances, err = c.localizeStanceBreakdown(ctx, variant, debateStances)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get debate stances: %v", err))
+				return
+			}
 		}
 
 		if debate.TotalVotes.Valid {
@@ -1087,6 +1335,7 @@ func (c *Config) getTopDebates(w http.ResponseWriter, r *http.Request) {
 				TotalVotes:      int(debate.TotalVotes.Int32),
 				TotalComments:   int(debate.TotalComments.Int32),
 				EngagementScore: engagementScore,
+				Stances:         debateStances,
 				CreatedAt:       debate.CreatedAt.Time,
 				UpdatedAt:       debate.UpdatedAt.Time,
 			}
@@ -1098,16 +1347,64 @@ func (c *Config) getTopDebates(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-// Helper function to update debate analytics
-func (c *Config) updateDebateAnalytics(ctx context.Context, debateCardID int32) {
-	// Get the debate ID from the card
+// voteCountsByCard groups vote count rows by the card they belong to
+func voteCountsByCard(rows []database.GetVoteCountsRow) map[int32]VoteCounts {
+	voteCountsMap := make(map[int32]VoteCounts)
+	for _, vc := range rows {
+		if vc.DebateCardID.Valid {
+			counts := voteCountsMap[vc.DebateCardID.Int32]
+			switch vc.VoteType {
+			case "upvote":
+				counts.Upvotes = int(vc.Count)
+			case "downvote":
+				counts.Downvotes = int(vc.Count)
+			case "emoji":
+				if counts.Emojis == nil {
+					counts.Emojis = make(map[string]int)
+				}
+				if vc.Emoji.Valid {
+					counts.Emojis[vc.Emoji.String] = int(vc.Count)
+				}
+			}
+			voteCountsMap[vc.DebateCardID.Int32] = counts
+		}
+	}
+	return voteCountsMap
+}
+
+// updateDebateAnalyticsForCard updates the analytics of the debate a card
+// belongs to and streams the card's new vote counts
+func (c *Config) updateDebateAnalyticsForCard(ctx context.Context, debateCardID int32) {
 	card, err := c.DB.GetDebateCard(ctx, debateCardID)
 	if err != nil {
 		fmt.Printf("Failed to get debate card: %v\n", err)
 		return
 	}
 
-	debateID := card.DebateID.Int32
+	voteCounts, err := c.DB.GetVoteCounts(ctx, []int32{debateCardID})
+	if err != nil {
+		fmt.Printf("Failed to get vote counts: %v\n", err)
+	} else {
+		c.publishDebateEvent(ctx, card.DebateID.Int32, debateEventVotes, CardVoteCounts{
+			DebateCardID: debateCardID,
+			VoteCounts:   voteCountsByCard(voteCounts)[debateCardID],
+		})
+	}
+
+	c.updateDebateAnalytics(ctx, card.DebateID.Int32)
+}
+
+// Helper function to update debate analytics. A translation's activity counts
+// towards its canonical debate, which holds the analytics for all of them.
+func (c *Config) updateDebateAnalytics(ctx context.Context, debateID int32) {
+	if debate, err := c.DB.GetDebate(ctx, debateID); err == nil {
+		debateID = canonicalDebateID(debate)
+	}
+	family, err := c.DB.GetDebateFamily(ctx, debateID)
+	if err != nil {
+		fmt.Printf("Failed to get debate translations: %v\n", err)
+		return
+	}
 
 	// Get vote counts for all cards in this debate
 	cards, err := c.DB.GetDebateCards(ctx, sql.NullInt32{Int32: debateID, Valid: true})
@@ -1133,18 +1430,30 @@ func (c *Config) updateDebateAnalytics(ctx context.Context, debateCardID int32)
 		totalVotes += int(vc.Count)
 	}
 
-	// Get comment count
-	commentCount, err := c.DB.GetCommentCount(ctx, sql.NullInt32{Int32: debateID, Valid: true})
-	if err != nil {
-		fmt.Printf("Failed to get comment count: %v\n", err)
-		return
+	// Get comment and reaction counts across the debate and its translations
+	var commentCount, reactionCount int64
+	for _, member := range family {
+		count, err := c.DB.GetCommentCount(ctx, sql.NullInt32{Int32: member.ID, Valid: true})
+		if err != nil {
+			fmt.Printf("Failed to get comment count: %v\n", err)
+			return
+		}
+		commentCount += count
+
+		// Get reactions on the debate's comments
+		reactions, err := c.DB.GetDebateCommentReactionCount(ctx, sql.NullInt32{Int32: member.ID, Valid: true})
+		if err != nil {
+			fmt.Printf("Failed to get comment reaction count: %v\n", err)
+			return
+		}
+		reactionCount += reactions
 	}
 
-	// Calculate engagement score (votes + comments * 2 for comment weight)
-	engagementScore := float64(totalVotes) + float64(commentCount)*2.0
+	// Calculate engagement score (votes + comments * 2 for comment weight + comment reactions)
+	engagementScore := float64(totalVotes) + float64(commentCount)*2.0 + float64(reactionCount)
 
 	// Update analytics
-	_, err = c.DB.UpdateDebateAnalytics(ctx, database.UpdateDebateAnalyticsParams{
+	analytics, err := c.DB.UpdateDebateAnalytics(ctx, database.UpdateDebateAnalyticsParams{
 		DebateID:        sql.NullInt32{Int32: debateID, Valid: true},
 		TotalVotes:      sql.NullInt32{Int32: int32(totalVotes), Valid: true},
 		TotalComments:   sql.NullInt32{Int32: int32(commentCount), Valid: true},
@@ -1152,7 +1461,18 @@ func (c *Config) updateDebateAnalytics(ctx context.Context, debateCardID int32)
 	})
 	if err != nil {
 		fmt.Printf("Failed to update debate analytics: %v\n", err)
+		return
 	}
+
+	c.publishDebateEvent(ctx, debateID, debateEventAnalytics, DebateAnalyticsResponse{
+		ID:              analytics.ID,
+		DebateID:        debateID,
+		TotalVotes:      totalVotes,
+		TotalComments:   int(commentCount),
+		EngagementScore: engagementScore,
+		CreatedAt:       analytics.CreatedAt.Time,
+		UpdatedAt:       analytics.UpdatedAt.Time,
+	})
 }
 
 // checkDebateGenerationHealth checks if all components needed for debate generation are working
@@ -1219,9 +1539,6 @@ func (c *Config) hardDeleteDebate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: Add admin authentication check here
-	// For now, we'll allow the operation but log it
-
 	// Hard delete the debate
 	err = c.DB.DeleteDebate(ctx, int32(debateID))
 	if err != nil {
@@ -1233,10 +1550,63 @@ func (c *Config) hardDeleteDebate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user, _ := userFromContext(ctx)
+	c.recordModerationAction(ctx, database.CreateModerationActionParams{
+		ModeratorID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Action:      moderationActionHardDeleteDebate,
+		TargetType:  reportTargetDebate,
+		TargetID:    int32(debateID),
+	})
+
 	fmt.Printf("Hard deleted debate ID: %d\n", debateID)
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Debate permanently deleted"})
 }
 
+// listDeletedDebates lists soft-deleted debates so moderators can review them for restoration
+func (c *Config) listDeletedDebates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	debates, err := c.DB.ListDeletedDebates(ctx, database.ListDeletedDebatesParams{
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list deleted debates: %v", err))
+		return
+	}
+
+	response := make([]DebateResponse, 0, len(debates))
+	for _, debate := range debates {
+		deletedAt := debate.DeletedAt.Time
+		response = append(response, DebateResponse{
+			ID:          debate.ID,
+			MatchID:     debate.MatchID,
+			DebateType:  debate.DebateType,
+			Headline:    debate.Headline,
+			Description: debate.Description.String,
+			AIGenerated: debate.AiGenerated.Bool,
+			CreatedAt:   debate.CreatedAt.Time,
+			UpdatedAt:   debate.UpdatedAt.Time,
+			DeletedAt:   &deletedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
 // restoreDebate handles restoring a soft-deleted debate
 func (c *Config) restoreDebate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -1256,10 +1626,23 @@ func (c *Config) restoreDebate(w http.ResponseWriter, r *http.Request) {
 			respondWithError(w, http.StatusNotFound, "Debate not found")
 			return
 		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, "A live debate already replaces this one")
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to restore debate: %v", err))
 		return
 	}
 
+	user, _ := userFromContext(ctx)
+	c.recordModerationAction(ctx, database.CreateModerationActionParams{
+		ModeratorID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Action:      moderationActionRestoreDebate,
+		TargetType:  reportTargetDebate,
+		TargetID:    int32(debateID),
+	})
+
 	fmt.Printf("Restored debate ID: %d\n", debateID)
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Debate restored successfully"})
 }
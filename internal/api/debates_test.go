@@ -1,9 +1,19 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/ArronJLinton/fucci-api/internal/cache"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/go-chi/chi"
 )
 
 func TestDebateDataAggregator(t *testing.T) {
@@ -256,3 +266,117 @@ func TestMultipleEmojiVotesOnDebateCard(t *testing.T) {
 		t.Errorf("Expected 👍 emoji count to be 2 after second vote, got %d", voteCounts.Emojis["👍"])
 	}
 }
+
+func TestNewVoteResponse(t *testing.T) {
+	vote := database.Vote{
+		ID:           3,
+		DebateCardID: sql.NullInt32{Int32: 7, Valid: true},
+		UserID:       sql.NullInt32{Int32: 42, Valid: true},
+		VoteType:     "downvote",
+	}
+
+	response := newVoteResponse(vote)
+	if response.DebateCardID != 7 || response.UserID != 42 {
+		t.Errorf("Expected card 7 and user 42, got card %d and user %d", response.DebateCardID, response.UserID)
+	}
+	if response.VoteType != "downvote" {
+		t.Errorf("Expected VoteType to be 'downvote', got %s", response.VoteType)
+	}
+	if response.Emoji != "" {
+		t.Errorf("Expected no emoji, got %s", response.Emoji)
+	}
+}
+
+func TestDirectionalVotes(t *testing.T) {
+	config := newTestConfig(t)
+	_, cards := addTestDebate(t, config, database.Debate{MatchID: "1", DebateType: "pre_match"},
+		database.DebateCard{Stance: "agree"}, database.DebateCard{Stance: "disagree"})
+	cardID := cards[0].ID
+	user := addTestUser(t, config)
+
+	vote := func(voteType string) {
+		t.Helper()
+		rr := serveTestRequest(config.createVote, http.MethodPost, "/debates/votes", CreateVoteRequest{DebateCardID: cardID, VoteType: voteType}, user)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected %s to be created, got %d: %s", voteType, rr.Code, rr.Body.String())
+		}
+	}
+	counts := func() VoteCounts {
+		t.Helper()
+		rows, err := config.DB.GetVoteCounts(context.Background(), []int32{cardID})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return voteCountsByCard(rows)[cardID]
+	}
+
+	// Voting the same way twice keeps one vote
+	vote("upvote")
+	vote("upvote")
+	if got := counts(); got.Upvotes != 1 || got.Downvotes != 0 {
+		t.Errorf("Expected one upvote, got %+v", got)
+	}
+
+	// Switching sides replaces the upvote, and emoji votes sit alongside
+	vote("downvote")
+	rr := serveTestRequest(config.createVote, http.MethodPost, "/debates/votes", CreateVoteRequest{DebateCardID: cardID, VoteType: "emoji", Emoji: "🔥"}, user)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected the emoji vote to be created, got %d", rr.Code)
+	}
+	if got := counts(); got.Upvotes != 0 || got.Downvotes != 1 || got.Emojis["🔥"] != 1 {
+		t.Errorf("Expected one downvote and one emoji, got %+v", got)
+	}
+	if analytics, err := config.DB.GetDebateAnalytics(context.Background(), cards[0].DebateID); err != nil || analytics.TotalVotes.Int32 != 2 {
+		t.Errorf("Expected analytics to count 2 votes, got %d (%v)", analytics.TotalVotes.Int32, err)
+	}
+
+	// Retracting removes the directional vote only, once
+	retract := func() int {
+		return serveTestRequest(config.deleteVote, http.MethodDelete, "/debates/votes/1", nil, user, "cardId", strconv.Itoa(int(cardID))).Code
+	}
+	if code := retract(); code != http.StatusOK {
+		t.Fatalf("Expected the vote to be retracted, got %d", code)
+	}
+	if got := counts(); got.Upvotes != 0 || got.Downvotes != 0 || got.Emojis["🔥"] != 1 {
+		t.Errorf("Expected only the emoji vote to remain, got %+v", got)
+	}
+	if code := retract(); code != http.StatusNotFound {
+		t.Errorf("Expected 404 retracting a vote that is gone, got %d", code)
+	}
+
+	// Another user's vote is their own
+	rr = serveTestRequest(config.createVote, http.MethodPost, "/debates/votes", CreateVoteRequest{DebateCardID: cardID, VoteType: "upvote"}, addTestUser(t, config))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected the other user's vote to be created, got %d", rr.Code)
+	}
+	if got := counts(); got.Upvotes != 1 {
+		t.Errorf("Expected the other user's upvote to count, got %+v", got)
+	}
+}
+
+// serveTestRequest calls a handler as user, with a JSON body when body is
+// not nil and chi URL parameters given as name/value pairs
+func serveTestRequest(handler http.HandlerFunc, method, target string, body interface{}, user database.User, params ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			panic(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, target, reader)
+
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		rctx.URLParams.Add(params[i], params[i+1])
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	if user.ID != 0 {
+		ctx = withUser(ctx, user)
+	}
+
+	rr := httptest.NewRecorder()
+	handler(rr, req.WithContext(ctx))
+	return rr
+}
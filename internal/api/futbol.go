@@ -29,6 +29,17 @@ func (c *Config) getMatches(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	data, err := c.fetchMatches(ctx, date)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to get matches: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, data)
+}
+
+// fetchMatches gets the fixtures on a date, from the cache when possible
+func (c *Config) fetchMatches(ctx context.Context, date string) (GetMatchesAPIResponse, error) {
 	// Generate cache key
 	cacheKey := fmt.Sprintf("matches:%s", date)
 
@@ -40,14 +51,19 @@ func (c *Config) getMatches(w http.ResponseWriter, r *http.Request) {
 	} else if exists {
 		err = c.Cache.Get(ctx, cacheKey, &data)
 		if err == nil {
-			respondWithJSON(w, http.StatusOK, data)
-			return
+			return data, nil
 		}
 		log.Printf("Cache get error: %v\n", err)
 	}
 
+	// Use configurable base URL with fallback
+	baseURL := c.APIFootballBaseURL
+	if baseURL == "" {
+		baseURL = "https://api-football-v1.p.rapidapi.com/v3"
+	}
+
 	// If not in cache or error occurred, fetch from API
-	url := fmt.Sprintf("https://api-football-v1.p.rapidapi.com/v3/fixtures?date=%s", date)
+	url := fmt.Sprintf("%s/fixtures?date=%s", baseURL, date)
 	headers := map[string]string{
 		"Content-Type":   "application/json",
 		"x-rapidapi-key": c.FootballAPIKey,
@@ -55,23 +71,20 @@ func (c *Config) getMatches(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := HTTPRequest("GET", url, headers, nil)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error creating http request: %s", err))
-		return
+		return data, fmt.Errorf("error fetching matches: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read the raw response body for debugging
 	rawBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read response body: %s", err))
-		return
+		return data, fmt.Errorf("error reading matches response: %w", err)
 	}
 
 	// Create a new reader from the raw body for JSON decoding
 	err = json.Unmarshal(rawBody, &data)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse response from football api service: %s", err))
-		return
+		return data, fmt.Errorf("error parsing matches response: %w", err)
 	}
 
 	// Determine cache TTL based on match statuses
@@ -94,7 +107,7 @@ func (c *Config) getMatches(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Cache set error: %v\n", err)
 	}
 
-	respondWithJSON(w, http.StatusOK, data)
+	return data, nil
 }
 
 func (c *Config) getMatch(w http.ResponseWriter, r *http.Request) {
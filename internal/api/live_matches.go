@@ -0,0 +1,519 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/cache"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// Live match update types
+const (
+	liveUpdateSnapshot     = "snapshot"
+	liveUpdateScore        = "score"
+	liveUpdateStatus       = "status"
+	liveUpdateGoal         = "goal"
+	liveUpdateCard         = "card"
+	liveUpdateSubstitution = "substitution"
+	liveUpdateError        = "error"
+)
+
+const (
+	defaultLiveMatchPollInterval = 15 * time.Second
+	// Fixtures that have not kicked off change rarely, so they are polled
+	// less often
+	liveMatchIdlePollInterval = time.Minute
+)
+
+// API-Football short statuses of fixtures being played, and of fixtures that
+// will not change again
+var (
+	inPlayStatuses = map[string]bool{
+		"1H": true, "HT": true, "2H": true, "ET": true, "BT": true,
+		"P": true, "SUSP": true, "INT": true, "LIVE": true,
+	}
+	finishedStatuses = map[string]bool{
+		"FT": true, "AET": true, "PEN": true, "PST": true,
+		"CANC": true, "ABD": true, "AWD": true, "WO": true,
+	}
+)
+
+// liveEventTypes maps the API-Football event types that are streamed to their
+// update type
+var liveEventTypes = map[string]string{
+	"goal":  liveUpdateGoal,
+	"card":  liveUpdateCard,
+	"subst": liveUpdateSubstitution,
+}
+
+var errLiveMatchNotFound = errors.New("fixture not found")
+
+// LiveMatch is a fixture's score, status and events as of one poll
+type LiveMatch struct {
+	FixtureID  int              `json:"fixture_id"`
+	Status     string           `json:"status"`
+	StatusLong string           `json:"status_long"`
+	Elapsed    int              `json:"elapsed"`
+	HomeTeam   string           `json:"home_team"`
+	AwayTeam   string           `json:"away_team"`
+	HomeGoals  int              `json:"home_goals"`
+	AwayGoals  int              `json:"away_goals"`
+	Events     []LiveMatchEvent `json:"events"`
+}
+
+// LiveMatchEvent is a goal, card or substitution
+type LiveMatchEvent struct {
+	Type    string `json:"type"`
+	Detail  string `json:"detail"`
+	Elapsed int    `json:"elapsed"`
+	Extra   int    `json:"extra,omitempty"`
+	TeamID  int    `json:"team_id"`
+	Team    string `json:"team"`
+	Player  string `json:"player"`
+	Assist  string `json:"assist,omitempty"`
+}
+
+// LiveMatchUpdate is one message on a fixture's live stream
+type LiveMatchUpdate struct {
+	Type      string      `json:"type"`
+	FixtureID int         `json:"fixture_id"`
+	Data      interface{} `json:"data"`
+}
+
+// key identifies an event across polls; API-Football events carry no ID
+func (e LiveMatchEvent) key() string {
+	return fmt.Sprintf("%d+%d|%d|%s|%s|%s", e.Elapsed, e.Extra, e.TeamID, e.Player, e.Type, e.Detail)
+}
+
+// diffLiveMatch lists the updates that take a client from prev to next. A
+// client with no previous state gets a full snapshot.
+func diffLiveMatch(prev, next *LiveMatch) []LiveMatchUpdate {
+	if prev == nil {
+		return []LiveMatchUpdate{{Type: liveUpdateSnapshot, FixtureID: next.FixtureID, Data: next}}
+	}
+
+	var updates []LiveMatchUpdate
+
+	// Count rather than mark seen events so that two identical events in the
+	// same minute are both reported
+	seen := make(map[string]int)
+	for _, event := range prev.Events {
+		seen[event.key()]++
+	}
+	for _, event := range next.Events {
+		if seen[event.key()] > 0 {
+			seen[event.key()]--
+			continue
+		}
+		updates = append(updates, LiveMatchUpdate{Type: event.Type, FixtureID: next.FixtureID, Data: event})
+	}
+
+	// A goal overturned by VAR changes the score without a new event
+	if prev.HomeGoals != next.HomeGoals || prev.AwayGoals != next.AwayGoals {
+		updates = append(updates, LiveMatchUpdate{Type: liveUpdateScore, FixtureID: next.FixtureID, Data: map[string]int{
+			"home_goals": next.HomeGoals,
+			"away_goals": next.AwayGoals,
+			"elapsed":    next.Elapsed,
+		}})
+	}
+	if prev.Status != next.Status {
+		updates = append(updates, LiveMatchUpdate{Type: liveUpdateStatus, FixtureID: next.FixtureID, Data: map[string]interface{}{
+			"status":      next.Status,
+			"status_long": next.StatusLong,
+			"elapsed":     next.Elapsed,
+		}})
+	}
+	return updates
+}
+
+// liveMatchPoll is what the instance polling a fixture publishes to every
+// instance streaming it: the fixture's state, or that it does not exist
+type liveMatchPoll struct {
+	Match    *LiveMatch `json:"match,omitempty"`
+	NotFound bool       `json:"not_found,omitempty"`
+}
+
+func liveMatchChannel(fixtureID int) string {
+	return fmt.Sprintf("live_match:%d:polls", fixtureID)
+}
+
+func liveMatchLockKey(fixtureID int) string {
+	return fmt.Sprintf("live_match:%d:poller", fixtureID)
+}
+
+// newDefaultLocker uses Redis when the cache is backed by it and falls back
+// to process memory otherwise
+func newDefaultLocker(c cache.CacheInterface) cache.Locker {
+	if locker, ok := c.(cache.Locker); ok {
+		return locker
+	}
+	return cache.NewMemoryLocker()
+}
+
+// liveMatchPoller streams the fixtures clients are watching. Every instance
+// with clients on a fixture competes for the fixture's lock each interval;
+// the holder polls API-Football and publishes the result, and each instance
+// compares it with the last state it saw and sends the changes to its own
+// clients. Each fixture is polled once per interval however many clients and
+// instances watch it, and only while at least one does.
+type liveMatchPoller struct {
+	fetch        func(fixtureID int) (*LiveMatch, error)
+	pubsub       cache.PubSub
+	locker       cache.Locker
+	owner        string // Identifies this instance to the locker
+	interval     time.Duration
+	idleInterval time.Duration
+
+	mu       sync.Mutex
+	fixtures map[int]*liveFixture
+}
+
+type liveFixture struct {
+	subscription cache.Subscription
+	clients      map[chan []byte]struct{}
+	latest       *LiveMatch
+	stop         chan struct{}
+}
+
+func newLiveMatchPoller(fetch func(fixtureID int) (*LiveMatch, error), pubsub cache.PubSub, locker cache.Locker, interval time.Duration) *liveMatchPoller {
+	return &liveMatchPoller{
+		fetch:        fetch,
+		pubsub:       pubsub,
+		locker:       locker,
+		owner:        uuid.NewString(),
+		interval:     interval,
+		idleInterval: liveMatchIdlePollInterval,
+		fixtures:     make(map[int]*liveFixture),
+	}
+}
+
+// watch registers a client for a fixture's updates, subscribing to its polls
+// and joining the election for its poller for the first client on this
+// instance. The channel is closed once the match is over; the returned
+// function unregisters the client.
+func (p *liveMatchPoller) watch(fixtureID int) (<-chan []byte, func(), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fixture, ok := p.fixtures[fixtureID]
+	if !ok {
+		// The subscription outlives the request that opened it
+		subscription, err := p.pubsub.Subscribe(context.Background(), liveMatchChannel(fixtureID))
+		if err != nil {
+			return nil, nil, err
+		}
+		fixture = &liveFixture{subscription: subscription, clients: make(map[chan []byte]struct{}), stop: make(chan struct{})}
+		p.fixtures[fixtureID] = fixture
+		go p.receive(fixtureID, fixture)
+		go p.poll(fixtureID, fixture)
+	}
+
+	client := make(chan []byte, 16)
+	if fixture.latest != nil {
+		// Late joiners start from the current state rather than waiting for
+		// the next change
+		message, err := encodeLiveMatchUpdate(diffLiveMatch(nil, fixture.latest)[0])
+		if err != nil {
+			fmt.Printf("Failed to encode live match snapshot: %v\n", err)
+		} else {
+			client <- message
+		}
+	}
+	fixture.clients[client] = struct{}{}
+
+	unwatch := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, ok := fixture.clients[client]; !ok {
+			return // Already closed when the match ended
+		}
+		delete(fixture.clients, client)
+		if len(fixture.clients) == 0 && p.fixtures[fixtureID] == fixture {
+			delete(p.fixtures, fixtureID)
+			close(fixture.stop)
+			fixture.subscription.Close()
+		}
+	}
+	return client, unwatch, nil
+}
+
+// poll polls a fixture whenever this instance holds its lock, until the
+// fixture's last client here leaves or the match ends. Instances that do not
+// hold the lock try again each interval, so another takes over within a
+// couple of intervals of the poller going away.
+func (p *liveMatchPoller) poll(fixtureID int, fixture *liveFixture) {
+	ctx := context.Background()
+	lockKey := liveMatchLockKey(fixtureID)
+	defer p.locker.ReleaseLock(ctx, lockKey, p.owner)
+
+	for {
+		wait := p.interval
+		held, err := p.locker.AcquireLock(ctx, lockKey, p.owner, 2*p.interval)
+		if err != nil {
+			fmt.Printf("Failed to take the poller lock for fixture %d: %v\n", fixtureID, err)
+		} else if held {
+			wait = p.pollOnce(ctx, fixtureID)
+		}
+
+		select {
+		case <-fixture.stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// pollOnce fetches a fixture and publishes it to every instance streaming
+// it, returning how long to wait before polling again
+func (p *liveMatchPoller) pollOnce(ctx context.Context, fixtureID int) time.Duration {
+	var poll liveMatchPoll
+	match, err := p.fetch(fixtureID)
+	switch {
+	case errors.Is(err, errLiveMatchNotFound):
+		poll.NotFound = true
+	case err != nil:
+		fmt.Printf("Failed to poll fixture %d: %v\n", fixtureID, err)
+		return p.interval
+	default:
+		poll.Match = match
+	}
+
+	message, err := json.Marshal(poll)
+	if err != nil {
+		fmt.Printf("Failed to encode poll of fixture %d: %v\n", fixtureID, err)
+		return p.interval
+	}
+	if err := p.pubsub.Publish(ctx, liveMatchChannel(fixtureID), message); err != nil {
+		fmt.Printf("Failed to publish poll of fixture %d: %v\n", fixtureID, err)
+	}
+
+	if match != nil && !inPlayStatuses[match.Status] && !finishedStatuses[match.Status] {
+		// Hold the lock through the longer wait
+		if _, err := p.locker.AcquireLock(ctx, liveMatchLockKey(fixtureID), p.owner, p.idleInterval+p.interval); err != nil {
+			fmt.Printf("Failed to extend the poller lock for fixture %d: %v\n", fixtureID, err)
+		}
+		return p.idleInterval
+	}
+	return p.interval
+}
+
+// receive sends this instance's clients the changes in each published poll
+// of a fixture, ending their streams once the match is over
+func (p *liveMatchPoller) receive(fixtureID int, fixture *liveFixture) {
+	for message := range fixture.subscription.Messages() {
+		var poll liveMatchPoll
+		if err := json.Unmarshal(message, &poll); err != nil {
+			fmt.Printf("Failed to decode poll of fixture %d: %v\n", fixtureID, err)
+			continue
+		}
+		switch {
+		case poll.NotFound:
+			p.finish(fixtureID, fixture, LiveMatchUpdate{
+				Type:      liveUpdateError,
+				FixtureID: fixtureID,
+				Data:      map[string]string{"error": "Fixture not found"},
+			})
+		case poll.Match == nil:
+		case finishedStatuses[poll.Match.Status]:
+			p.finish(fixtureID, fixture, p.update(fixture, poll.Match)...)
+		default:
+			p.broadcast(fixture, p.update(fixture, poll.Match))
+		}
+	}
+
+	// The subscription closes when the match ends or its last client leaves,
+	// but also if the connection to the pub/sub drops. Then any clients left
+	// have their streams ended so that they reconnect.
+	p.finish(fixtureID, fixture)
+}
+
+// update records a fixture's latest state, returning what changed
+func (p *liveMatchPoller) update(fixture *liveFixture, match *LiveMatch) []LiveMatchUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	updates := diffLiveMatch(fixture.latest, match)
+	fixture.latest = match
+	return updates
+}
+
+// broadcast sends updates to every client of a fixture. A client too slow to
+// keep up misses updates rather than holding up the others.
+func (p *liveMatchPoller) broadcast(fixture *liveFixture, updates []LiveMatchUpdate) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, update := range updates {
+		message, err := encodeLiveMatchUpdate(update)
+		if err != nil {
+			fmt.Printf("Failed to encode live match update: %v\n", err)
+			continue
+		}
+		for client := range fixture.clients {
+			select {
+			case client <- message:
+			default:
+			}
+		}
+	}
+}
+
+// finish sends a fixture's last updates and ends its clients' streams
+func (p *liveMatchPoller) finish(fixtureID int, fixture *liveFixture, updates ...LiveMatchUpdate) {
+	p.broadcast(fixture, updates)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fixtures[fixtureID] == fixture {
+		delete(p.fixtures, fixtureID)
+		close(fixture.stop)
+		fixture.subscription.Close()
+	}
+	for client := range fixture.clients {
+		delete(fixture.clients, client)
+		close(client)
+	}
+}
+
+func encodeLiveMatchUpdate(update LiveMatchUpdate) ([]byte, error) {
+	message, err := json.Marshal(update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s update for fixture %d: %w", update.Type, update.FixtureID, err)
+	}
+	return message, nil
+}
+
+// fetchLiveMatch gets a fixture's current score, status and events
+func (c *Config) fetchLiveMatch(fixtureID int) (*LiveMatch, error) {
+	// Use configurable base URL with fallback
+	baseURL := c.APIFootballBaseURL
+	if baseURL == "" {
+		baseURL = "https://api-football-v1.p.rapidapi.com/v3"
+	}
+
+	url := fmt.Sprintf("%s/fixtures?id=%d", baseURL, fixtureID)
+	headers := map[string]string{
+		"Content-Type":   "application/json",
+		"x-rapidapi-key": c.FootballAPIKey,
+	}
+
+	resp, err := HTTPRequest("GET", url, headers, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching fixture: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixture response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("football api returned status %d", resp.StatusCode)
+	}
+
+	var fixtureResponse struct {
+		Response []struct {
+			Fixture struct {
+				ID     int `json:"id"`
+				Status struct {
+					Long    string `json:"long"`
+					Short   string `json:"short"`
+					Elapsed int    `json:"elapsed"`
+				} `json:"status"`
+			} `json:"fixture"`
+			Teams struct {
+				Home struct {
+					Name string `json:"name"`
+				} `json:"home"`
+				Away struct {
+					Name string `json:"name"`
+				} `json:"away"`
+			} `json:"teams"`
+			Goals struct {
+				Home int `json:"home"`
+				Away int `json:"away"`
+			} `json:"goals"`
+			Events []struct {
+				Time struct {
+					Elapsed int `json:"elapsed"`
+					Extra   int `json:"extra"`
+				} `json:"time"`
+				Team struct {
+					ID   int    `json:"id"`
+					Name string `json:"name"`
+				} `json:"team"`
+				Player struct {
+					Name string `json:"name"`
+				} `json:"player"`
+				Assist struct {
+					Name string `json:"name"`
+				} `json:"assist"`
+				Type   string `json:"type"`
+				Detail string `json:"detail"`
+			} `json:"events"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(body, &fixtureResponse); err != nil {
+		return nil, fmt.Errorf("error parsing fixture response: %w", err)
+	}
+	if len(fixtureResponse.Response) == 0 {
+		return nil, errLiveMatchNotFound
+	}
+
+	fixture := fixtureResponse.Response[0]
+	match := &LiveMatch{
+		FixtureID:  fixture.Fixture.ID,
+		Status:     fixture.Fixture.Status.Short,
+		StatusLong: fixture.Fixture.Status.Long,
+		Elapsed:    fixture.Fixture.Status.Elapsed,
+		HomeTeam:   fixture.Teams.Home.Name,
+		AwayTeam:   fixture.Teams.Away.Name,
+		HomeGoals:  fixture.Goals.Home,
+		AwayGoals:  fixture.Goals.Away,
+		Events:     []LiveMatchEvent{},
+	}
+	for _, event := range fixture.Events {
+		eventType, ok := liveEventTypes[strings.ToLower(event.Type)]
+		if !ok {
+			continue
+		}
+		match.Events = append(match.Events, LiveMatchEvent{
+			Type:    eventType,
+			Detail:  event.Detail,
+			Elapsed: event.Time.Elapsed,
+			Extra:   event.Time.Extra,
+			TeamID:  event.Team.ID,
+			Team:    event.Team.Name,
+			Player:  event.Player.Name,
+			Assist:  event.Assist.Name,
+		})
+	}
+	return match, nil
+}
+
+// streamLiveMatch streams a fixture's score, status and goal, card and
+// substitution events as server-sent events until the match is over
+func (c *Config) streamLiveMatch(w http.ResponseWriter, r *http.Request) {
+	fixtureID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || fixtureID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid match ID")
+		return
+	}
+
+	updates, unwatch, err := c.liveMatches.watch(fixtureID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to subscribe to match: %v", err))
+		return
+	}
+	defer unwatch()
+
+	streamSSE(w, r, updates)
+}
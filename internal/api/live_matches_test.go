@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/cache"
+)
+
+func TestDiffLiveMatch(t *testing.T) {
+	goal := LiveMatchEvent{Type: liveUpdateGoal, Detail: "Normal Goal", Elapsed: 23, TeamID: 1, Player: "Saka"}
+	card := LiveMatchEvent{Type: liveUpdateCard, Detail: "Yellow Card", Elapsed: 40, TeamID: 2, Player: "Rice"}
+
+	prev := &LiveMatch{FixtureID: 7, Status: "1H", Elapsed: 30, HomeGoals: 1, Events: []LiveMatchEvent{goal}}
+
+	updates := diffLiveMatch(nil, prev)
+	if len(updates) != 1 || updates[0].Type != liveUpdateSnapshot {
+		t.Fatalf("Expected a single snapshot for a new client, got %+v", updates)
+	}
+
+	next := &LiveMatch{FixtureID: 7, Status: "HT", Elapsed: 45, HomeGoals: 1, Events: []LiveMatchEvent{goal, card}}
+	updates = diffLiveMatch(prev, next)
+	if len(updates) != 2 {
+		t.Fatalf("Expected card and status updates, got %+v", updates)
+	}
+	if updates[0].Type != liveUpdateCard || updates[1].Type != liveUpdateStatus {
+		t.Errorf("Expected card then status, got %s then %s", updates[0].Type, updates[1].Type)
+	}
+
+	// A goal ruled out by VAR only changes the score
+	overturned := &LiveMatch{FixtureID: 7, Status: "HT", Elapsed: 45, Events: []LiveMatchEvent{card}}
+	updates = diffLiveMatch(next, overturned)
+	if len(updates) != 1 || updates[0].Type != liveUpdateScore {
+		t.Errorf("Expected a single score update, got %+v", updates)
+	}
+
+	if updates := diffLiveMatch(next, next); len(updates) != 0 {
+		t.Errorf("Expected no updates for an unchanged match, got %+v", updates)
+	}
+}
+
+func TestLiveMatchPollerSharesPolls(t *testing.T) {
+	var mu sync.Mutex
+	status := "1H"
+	fetches := make(map[string]int)
+	newInstance := func(pubsub cache.PubSub, locker cache.Locker, name string) *liveMatchPoller {
+		return newLiveMatchPoller(func(fixtureID int) (*LiveMatch, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			fetches[name]++
+			return &LiveMatch{FixtureID: fixtureID, Status: status}, nil
+		}, pubsub, locker, 50*time.Millisecond)
+	}
+
+	// Two instances sharing a pub/sub and a locker, as they would Redis
+	pubsub, locker := cache.NewMemoryPubSub(), cache.NewMemoryLocker()
+	instanceA := newInstance(pubsub, locker, "a")
+	instanceB := newInstance(pubsub, locker, "b")
+
+	first, unwatchFirst, err := instanceA.watch(7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer unwatchFirst()
+	second, unwatchSecond, _ := instanceA.watch(7)
+	defer unwatchSecond()
+	third, unwatchThird, _ := instanceB.watch(7)
+	defer unwatchThird()
+	instanceA.mu.Lock()
+	if len(instanceA.fixtures) != 1 || len(instanceA.fixtures[7].clients) != 2 {
+		t.Error("Expected both clients to share one fixture on their instance")
+	}
+	instanceA.mu.Unlock()
+
+	for i, updates := range []<-chan []byte{first, second, third} {
+		select {
+		case message := <-updates:
+			var update LiveMatchUpdate
+			json.Unmarshal(message, &update)
+			if update.Type != liveUpdateSnapshot {
+				t.Errorf("Expected client %d to start with a snapshot, got %s", i+1, update.Type)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected client %d to receive a snapshot", i+1)
+		}
+	}
+	mu.Lock()
+	if fetches["a"] > 0 && fetches["b"] > 0 {
+		t.Errorf("Expected one instance to poll the fixture, got %v", fetches)
+	}
+	mu.Unlock()
+
+	// Full time ends every client's stream on every instance
+	mu.Lock()
+	status = "FT"
+	mu.Unlock()
+	for i, updates := range []<-chan []byte{first, second, third} {
+		timeout := time.After(time.Second)
+	drain:
+		for {
+			select {
+			case _, ok := <-updates:
+				if !ok {
+					break drain
+				}
+			case <-timeout:
+				t.Fatalf("Expected client %d's stream to close at full time", i+1)
+			}
+		}
+	}
+
+	for _, instance := range []*liveMatchPoller{instanceA, instanceB} {
+		instance.mu.Lock()
+		if len(instance.fixtures) != 0 {
+			t.Error("Expected the finished fixture to be dropped")
+		}
+		instance.mu.Unlock()
+	}
+}
+
+func TestFetchLiveMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "7" {
+			w.Write([]byte(`{"response": []}`))
+			return
+		}
+		w.Write([]byte(`{"response": [{
+			"fixture": {"id": 7, "status": {"long": "First Half", "short": "1H", "elapsed": 31}},
+			"teams": {"home": {"name": "Arsenal"}, "away": {"name": "Chelsea"}},
+			"goals": {"home": 1, "away": null},
+			"events": [
+				{"time": {"elapsed": 23, "extra": null}, "team": {"id": 42, "name": "Arsenal"}, "player": {"name": "Saka"}, "assist": {"name": "Odegaard"}, "type": "Goal", "detail": "Normal Goal"},
+				{"time": {"elapsed": 28, "extra": null}, "team": {"id": 42, "name": "Arsenal"}, "player": {"name": "Saka"}, "assist": {"name": null}, "type": "Var", "detail": "Goal confirmed"}
+			]
+		}]}`))
+	}))
+	defer server.Close()
+
+	config := &Config{APIFootballBaseURL: server.URL}
+
+	match, err := config.fetchLiveMatch(7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if match.Status != "1H" || match.HomeGoals != 1 || match.AwayGoals != 0 || match.HomeTeam != "Arsenal" {
+		t.Errorf("Unexpected match %+v", match)
+	}
+	if len(match.Events) != 1 || match.Events[0].Type != liveUpdateGoal || match.Events[0].Assist != "Odegaard" {
+		t.Errorf("Expected only the goal event, got %+v", match.Events)
+	}
+
+	if _, err := config.fetchLiveMatch(8); err != errLiveMatchNotFound {
+		t.Errorf("Expected errLiveMatchNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/go-chi/chi"
+)
+
+// LLM usage report periods and how far back reports go by default and at most
+const (
+	llmUsagePeriodDay     = "day"
+	llmUsagePeriodMonth   = "month"
+	defaultLLMUsageDays   = 30
+	maxLLMUsageDays       = 366
+	defaultLLMUsageMonths = 12
+	maxLLMUsageMonths     = 36
+)
+
+type LLMUsageRow struct {
+	PeriodStart      time.Time `json:"period_start"`
+	Model            string    `json:"model"`
+	Endpoint         string    `json:"endpoint"`
+	Calls            int64     `json:"calls"`
+	FailedCalls      int64     `json:"failed_calls"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	AvgLatencyMs     float64   `json:"avg_latency_ms"`
+}
+
+// LLMBudgetResponse is the current month's spend against the monthly budget
+type LLMBudgetResponse struct {
+	MonthlyBudgetUSD float64   `json:"monthly_budget_usd"`
+	SpentUSD         float64   `json:"spent_usd"`
+	RemainingUSD     float64   `json:"remaining_usd"`
+	Exhausted        bool      `json:"exhausted"`
+	ResetsAt         time.Time `json:"resets_at"`
+}
+
+type LLMUsageReportResponse struct {
+	Period       string             `json:"period"` // "day" or "month"
+	Since        time.Time          `json:"since"`
+	Until        time.Time          `json:"until"`
+	Rows         []LLMUsageRow      `json:"rows"`
+	TotalCalls   int64              `json:"total_calls"`
+	TotalCostUSD float64            `json:"total_cost_usd"`
+	Budget       *LLMBudgetResponse `json:"budget,omitempty"` // Set when a monthly budget is configured
+}
+
+type llmEndpointKey struct{}
+
+// withLLMEndpoint names what the LLM calls made with ctx are for, for work
+// done outside the request that caused it
+func withLLMEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, llmEndpointKey{}, endpoint)
+}
+
+// llmEndpoint names what the LLM calls made with ctx are for: the name set
+// by withLLMEndpoint, or else the method and route of the request being
+// served
+func llmEndpoint(ctx context.Context) string {
+	if endpoint, ok := ctx.Value(llmEndpointKey{}).(string); ok {
+		return endpoint
+	}
+	if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+		return rctx.RouteMethod + " " + rctx.RoutePattern()
+	}
+	return "unknown"
+}
+
+// llmCallStore stores the LLM calls the metered provider records
+type llmCallStore struct {
+	db *database.Queries
+}
+
+func (s llmCallStore) RecordLLMCall(ctx context.Context, call ai.LLMCall) {
+	var callError sql.NullString
+	if call.Err != nil {
+		callError = sql.NullString{String: call.Err.Error(), Valid: true}
+	}
+	// The call may have failed because ctx was cancelled, which must not
+	// stop it being recorded
+	err := s.db.CreateLLMCall(context.WithoutCancel(ctx), database.CreateLLMCallParams{
+		Provider:         call.Provider,
+		Model:            call.Model,
+		Endpoint:         llmEndpoint(ctx),
+		PromptTokens:     int32(call.Usage.PromptTokens),
+		CompletionTokens: int32(call.Usage.CompletionTokens),
+		CostUsd:          fmt.Sprintf("%.6f", call.CostUSD),
+		LatencyMs:        int32(call.Latency.Milliseconds()),
+		Error:            callError,
+	})
+	if err != nil {
+		fmt.Printf("Failed to record LLM call: %v\n", err)
+	}
+}
+
+// monthStart is the start of the UTC calendar month t falls in
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// llmBudget reports the current month's LLM spend against the monthly
+// budget. It returns nil when no budget is configured.
+func (c *Config) llmBudget(ctx context.Context, now time.Time) (*LLMBudgetResponse, error) {
+	if c.LLMMonthlyBudget <= 0 {
+		return nil, nil
+	}
+	since := monthStart(now)
+	spent, err := c.DB.GetLLMSpendSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	return newLLMBudgetResponse(c.LLMMonthlyBudget, spent, since), nil
+}
+
+func newLLMBudgetResponse(budget, spent float64, since time.Time) *LLMBudgetResponse {
+	return &LLMBudgetResponse{
+		MonthlyBudgetUSD: budget,
+		SpentUSD:         spent,
+		RemainingUSD:     math.Max(budget-spent, 0),
+		Exhausted:        spent >= budget,
+		ResetsAt:         since.AddDate(0, 1, 0),
+	}
+}
+
+// checkLLMBudget writes a 503 and returns false once this month's LLM budget
+// is spent. Generation carries on if the spend cannot be read.
+func (c *Config) checkLLMBudget(w http.ResponseWriter, r *http.Request) bool {
+	now := time.Now()
+	budget, err := c.llmBudget(r.Context(), now)
+	if err != nil {
+		fmt.Printf("Failed to check LLM budget: %v\n", err)
+		return true
+	}
+	if budget == nil || !budget.Exhausted {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(budget.ResetsAt.Sub(now).Seconds()))))
+	respondWithError(w, http.StatusServiceUnavailable, fmt.Sprintf(
+		"The monthly AI budget of $%.2f has been used up ($%.2f spent). Debate generation resumes on %s.",
+		budget.MonthlyBudgetUSD, budget.SpentUSD, budget.ResetsAt.Format("January 2, 2006"),
+	))
+	return false
+}
+
+// llmUsageRange is the span a report covers: the last n whole days or
+// calendar months up to now, in UTC
+func llmUsageRange(period string, n int, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	if period == llmUsagePeriodMonth {
+		start := monthStart(now)
+		return start.AddDate(0, 1-n, 0), start.AddDate(0, 1, 0)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return today.AddDate(0, 0, 1-n), today.AddDate(0, 0, 1)
+}
+
+// getDailyLLMUsage reports LLM calls and their cost per day, model and
+// endpoint over the last ?days (default 30)
+func (c *Config) getDailyLLMUsage(w http.ResponseWriter, r *http.Request) {
+	c.getLLMUsageReport(w, r, llmUsagePeriodDay, "days", defaultLLMUsageDays, maxLLMUsageDays)
+}
+
+// getMonthlyLLMUsage reports LLM calls and their cost per calendar month,
+// model and endpoint over the last ?months (default 12)
+func (c *Config) getMonthlyLLMUsage(w http.ResponseWriter, r *http.Request) {
+	c.getLLMUsageReport(w, r, llmUsagePeriodMonth, "months", defaultLLMUsageMonths, maxLLMUsageMonths)
+}
+
+func (c *Config) getLLMUsageReport(w http.ResponseWriter, r *http.Request, period, param string, defaultN, maxN int) {
+	ctx := r.Context()
+
+	n := defaultN
+	if s := r.URL.Query().Get(param); s != "" {
+		parsed, err := strconv.Atoi(s)
+		if err != nil || parsed <= 0 || parsed > maxN {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("%s must be between 1 and %d", param, maxN))
+			return
+		}
+		n = parsed
+	}
+
+	now := time.Now()
+	since, until := llmUsageRange(period, n, now)
+	rows, err := c.DB.GetLLMUsageReport(ctx, database.GetLLMUsageReportParams{
+		Period: period,
+		Since:  since,
+		Until:  until,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get LLM usage: %v", err))
+		return
+	}
+
+	response := LLMUsageReportResponse{
+		Period: period,
+		Since:  since,
+		Until:  until,
+		Rows:   make([]LLMUsageRow, len(rows)),
+	}
+	for i, row := range rows {
+		response.Rows[i] = LLMUsageRow{
+			PeriodStart:      row.PeriodStart,
+			Model:            row.Model,
+			Endpoint:         row.Endpoint,
+			Calls:            row.Calls,
+			FailedCalls:      row.FailedCalls,
+			PromptTokens:     row.PromptTokens,
+			CompletionTokens: row.CompletionTokens,
+			CostUSD:          row.CostUsd,
+			AvgLatencyMs:     row.AvgLatencyMs,
+		}
+		response.TotalCalls += row.Calls
+		response.TotalCostUSD += row.CostUsd
+	}
+
+	response.Budget, err = c.llmBudget(ctx, now)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get LLM budget: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// debateJobEndpoint names what a debate job's LLM calls are for by where the
+// job came from, e.g. "debate_job:request" or "debate_job:scheduled"
+func debateJobEndpoint(job database.DebateJob) string {
+	origin, _, _ := strings.Cut(job.IdempotencyKey, ":")
+	return "debate_job:" + origin
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/go-chi/chi"
+)
+
+func TestLLMEndpoint(t *testing.T) {
+	if got := llmEndpoint(context.Background()); got != "unknown" {
+		t.Errorf("Expected unknown without a route, got %q", got)
+	}
+
+	rctx := chi.NewRouteContext()
+	rctx.RouteMethod = http.MethodPost
+	rctx.RoutePatterns = []string{"/debates/generate"}
+	ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+	if got := llmEndpoint(ctx); got != "POST /debates/generate" {
+		t.Errorf("Expected the request's route, got %q", got)
+	}
+
+	if got := llmEndpoint(withLLMEndpoint(ctx, "debate_job:scheduled")); got != "debate_job:scheduled" {
+		t.Errorf("Expected the endpoint set on the context, got %q", got)
+	}
+}
+
+func TestLLMUsageRange(t *testing.T) {
+	now := time.Date(2026, 3, 15, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		period string
+		n      int
+		since  time.Time
+		until  time.Time
+	}{
+		{llmUsagePeriodDay, 1, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{llmUsagePeriodDay, 30, time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{llmUsagePeriodMonth, 1, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{llmUsagePeriodMonth, 12, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		since, until := llmUsageRange(tt.period, tt.n, now)
+		if !since.Equal(tt.since) || !until.Equal(tt.until) {
+			t.Errorf("llmUsageRange(%s, %d) = %v, %v; expected %v, %v", tt.period, tt.n, since, until, tt.since, tt.until)
+		}
+	}
+}
+
+func TestNewLLMBudgetResponse(t *testing.T) {
+	since := monthStart(time.Date(2026, 12, 20, 9, 0, 0, 0, time.UTC))
+
+	budget := newLLMBudgetResponse(50, 12.5, since)
+	if budget.Exhausted || budget.RemainingUSD != 37.5 {
+		t.Errorf("Expected $37.50 remaining, got %+v", budget)
+	}
+	if want := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC); !budget.ResetsAt.Equal(want) {
+		t.Errorf("Expected the budget to reset on %v, got %v", want, budget.ResetsAt)
+	}
+
+	budget = newLLMBudgetResponse(50, 50.75, since)
+	if !budget.Exhausted || budget.RemainingUSD != 0 {
+		t.Errorf("Expected an exhausted budget with nothing remaining, got %+v", budget)
+	}
+}
+
+func TestDebateJobEndpoint(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{requestDebateJobKey(), "debate_job:request"},
+		{scheduledDebateJobKey(1035037, "post_match"), "debate_job:scheduled"},
+	}
+	for _, tt := range tests {
+		if got := debateJobEndpoint(database.DebateJob{IdempotencyKey: tt.key}); got != tt.want {
+			t.Errorf("debateJobEndpoint(%q) = %q; expected %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/moderation"
+	"github.com/go-chi/chi"
+)
+
+// Comment moderation statuses, matching the comments.moderation_status check
+// constraint
+const (
+	commentStatusAllowed  = "allowed"
+	commentStatusMasked   = "masked"
+	commentStatusHeld     = "held"
+	commentStatusApproved = "approved"
+)
+
+// newModerator builds the default comment moderation pipeline: the wordlists,
+// followed by the LLM classifier when it is enabled and an LLM provider is
+// configured
+func (c *Config) newModerator() *moderation.Pipeline {
+	wordlist := c.ModerationWordlist
+	if wordlist == nil {
+		wordlist = moderation.DefaultWordlistFilter()
+	}
+	filters := []moderation.Filter{wordlist}
+	if c.ModerationLLM && c.LLMProvider != nil {
+		filters = append(filters, budgetedFilter{config: c, filter: moderation.NewLLMClassifier(c.LLMProvider)})
+	}
+	return moderation.NewPipeline(filters...)
+}
+
+// budgetedFilter runs a filter that calls the LLM only while the monthly LLM
+// budget lasts. Once it is spent, content is left to the other filters until
+// the budget resets.
+type budgetedFilter struct {
+	config *Config
+	filter moderation.Filter
+}
+
+func (f budgetedFilter) Check(ctx context.Context, content, locale string) (moderation.Result, error) {
+	budget, err := f.config.llmBudget(ctx, time.Now())
+	if err != nil {
+		fmt.Printf("Failed to check LLM budget: %v\n", err)
+	} else if budget != nil && budget.Exhausted {
+		return moderation.Result{Decision: moderation.DecisionAllow, Content: content}, nil
+	}
+	return f.filter.Check(ctx, content, locale)
+}
+
+// requestLocale is the caller's preferred language from Accept-Language,
+// defaulting to English
+func requestLocale(r *http.Request) string {
+	header := r.Header.Get("Accept-Language")
+	tag := strings.TrimSpace(strings.Split(strings.Split(header, ",")[0], ";")[0])
+	if tag == "" || tag == "*" {
+		return moderation.DefaultLocale
+	}
+	return strings.ToLower(tag)
+}
+
+// commentModerationStatus maps a moderation decision to the status stored on
+// the comment
+func commentModerationStatus(decision moderation.Decision) string {
+	switch decision {
+	case moderation.DecisionMask:
+		return commentStatusMasked
+	case moderation.DecisionHold:
+		return commentStatusHeld
+	default:
+		return commentStatusAllowed
+	}
+}
+
+// moderateComment screens comment content in the caller's locale. Filters
+// that fail are logged and skipped rather than blocking the comment.
+func (c *Config) moderateComment(r *http.Request, content string) moderation.Result {
+	result, err := c.Moderator.Moderate(r.Context(), content, requestLocale(r))
+	if err != nil {
+		fmt.Printf("Comment moderation filter failed: %v\n", err)
+	}
+	return result
+}
+
+// approveComment publishes a comment that moderation held for review
+func (c *Config) approveComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	commentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	approved, err := c.DB.ApproveHeldComment(ctx, int32(commentID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to approve comment: %v", err))
+		return
+	}
+	if approved == 0 {
+		respondWithError(w, http.StatusNotFound, "Held comment not found")
+		return
+	}
+
+	c.recordModerationAction(ctx, database.CreateModerationActionParams{
+		ModeratorID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Action:      moderationActionApproveComment,
+		TargetType:  reportTargetComment,
+		TargetID:    int32(commentID),
+	})
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Comment approved successfully"})
+}
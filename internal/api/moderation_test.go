@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/moderation"
+)
+
+func TestRequestLocale(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "en"},
+		{"*", "en"},
+		{"es-MX,es;q=0.9,en;q=0.8", "es-mx"},
+		{"pt;q=0.9", "pt"},
+		{" FR ", "fr"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/debates/comments", nil)
+		if tt.header != "" {
+			r.Header.Set("Accept-Language", tt.header)
+		}
+		if got := requestLocale(r); got != tt.expected {
+			t.Errorf("requestLocale(%q) = %q, expected %q", tt.header, got, tt.expected)
+		}
+	}
+}
+
+func TestModeratorSkipsClassifierWhenBudgetSpent(t *testing.T) {
+	config := newTestConfig(t)
+	provider := &ai.FakeProvider{Reply: `{"decision": "hold", "reason": "abusive"}`}
+	config.LLMProvider = provider
+	config.ModerationLLM = true
+	config.LLMMonthlyBudget = 50
+	moderator := config.newModerator()
+
+	result, err := moderator.Moderate(context.Background(), "What a match", "en")
+	if err != nil || result.Decision != moderation.DecisionHold {
+		t.Fatalf("Expected the classifier to hold the comment, got %s (%v)", result.Decision, err)
+	}
+
+	err = config.DB.CreateLLMCall(context.Background(), database.CreateLLMCallParams{
+		Provider: ai.ProviderFake,
+		Model:    "fake",
+		Endpoint: "debates/generate",
+		CostUsd:  "50",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Once the budget is spent, only the wordlists run
+	result, err = moderator.Moderate(context.Background(), "What a match", "en")
+	if err != nil || result.Decision != moderation.DecisionAllow {
+		t.Errorf("Expected the comment to be allowed by the wordlists, got %s (%v)", result.Decision, err)
+	}
+	if requests := len(provider.Requests()); requests != 1 {
+		t.Errorf("Expected the classifier not to be called once the budget is spent, got %d calls", requests)
+	}
+}
@@ -9,13 +9,15 @@ import (
 	"net/http"
 
 	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/policy"
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
 )
 
 // PlayerProfileService provides business logic for player profiles
 type PlayerProfileService struct {
-	DB *database.Queries
+	DB     *database.Queries
+	Policy *policy.Engine
 }
 
 // CreatePlayerProfileRequest represents the JSON request for creating a player profile
@@ -35,6 +37,24 @@ type CreatePlayerProfileRequest struct {
 	Physical  int32      `json:"physical"`
 }
 
+// UpdatePlayerProfileRequest represents the JSON request for updating a
+// player profile. Every field is replaced; a null team_id takes the player
+// off their team.
+type UpdatePlayerProfileRequest struct {
+	TeamID    *uuid.UUID `json:"team_id"`
+	Position  string     `json:"position"`
+	Age       int32      `json:"age"`
+	Country   string     `json:"country"`
+	HeightCm  int32      `json:"height_cm"`
+	Pace      int32      `json:"pace"`
+	Shooting  int32      `json:"shooting"`
+	Passing   int32      `json:"passing"`
+	Stamina   int32      `json:"stamina"`
+	Dribbling int32      `json:"dribbling"`
+	Defending int32      `json:"defending"`
+	Physical  int32      `json:"physical"`
+}
+
 // Handler: Create player profile
 func (svc *PlayerProfileService) CreatePlayerProfile(w http.ResponseWriter, r *http.Request) {
 	var req CreatePlayerProfileRequest
@@ -47,6 +67,12 @@ func (svc *PlayerProfileService) CreatePlayerProfile(w http.ResponseWriter, r *h
 	// Debug: Log the request
 	log.Printf("Creating player profile for user_id: %d", req.UserID)
 
+	currentUser, _ := userFromContext(r.Context())
+	if err := svc.Policy.AuthorizePlayerProfile(currentUser, req.UserID, policy.ActionCreate); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
+
 	// Debug: Check if user exists first
 	user, err := svc.DB.GetUser(r.Context(), req.UserID)
 	if err != nil {
@@ -65,6 +91,9 @@ func (svc *PlayerProfileService) CreatePlayerProfile(w http.ResponseWriter, r *h
 	// Convert to database params
 	var teamID uuid.NullUUID
 	if req.TeamID != nil {
+		if !svc.authorizeTeam(w, r, *req.TeamID) {
+			return
+		}
 		teamID = uuid.NullUUID{UUID: *req.TeamID, Valid: true}
 	}
 
@@ -117,12 +146,46 @@ func (svc *PlayerProfileService) GetPlayerProfile(w http.ResponseWriter, r *http
 
 // Handler: Update player profile
 func (svc *PlayerProfileService) UpdatePlayerProfile(w http.ResponseWriter, r *http.Request) {
-	var req database.UpdatePlayerProfileParams
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	var req UpdatePlayerProfileRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request", http.StatusBadRequest)
 		return
 	}
-	profile, err := svc.DB.UpdatePlayerProfile(r.Context(), req)
+	current, ok := svc.authorizeProfile(w, r, id, policy.ActionUpdate)
+	if !ok {
+		return
+	}
+
+	// Players may edit their own profile, but joining a team is up to the
+	// team's league
+	var teamID uuid.NullUUID
+	if req.TeamID != nil {
+		teamID = uuid.NullUUID{UUID: *req.TeamID, Valid: true}
+	}
+	if teamID.Valid && teamID != current.TeamID && !svc.authorizeTeam(w, r, teamID.UUID) {
+		return
+	}
+
+	profile, err := svc.DB.UpdatePlayerProfile(r.Context(), database.UpdatePlayerProfileParams{
+		ID:        id,
+		TeamID:    teamID,
+		Position:  req.Position,
+		Age:       req.Age,
+		Country:   req.Country,
+		HeightCm:  req.HeightCm,
+		Pace:      req.Pace,
+		Shooting:  req.Shooting,
+		Passing:   req.Passing,
+		Stamina:   req.Stamina,
+		Dribbling: req.Dribbling,
+		Defending: req.Defending,
+		Physical:  req.Physical,
+	})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -138,6 +201,9 @@ func (svc *PlayerProfileService) DeletePlayerProfile(w http.ResponseWriter, r *h
 		http.Error(w, "invalid id", http.StatusBadRequest)
 		return
 	}
+	if _, ok := svc.authorizeProfile(w, r, id, policy.ActionDelete); !ok {
+		return
+	}
 	if err := svc.DB.DeletePlayerProfile(r.Context(), id); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -145,6 +211,46 @@ func (svc *PlayerProfileService) DeletePlayerProfile(w http.ResponseWriter, r *h
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// authorizeProfile loads a profile and checks the current user may act on
+// it, writing the error response when they may not
+func (svc *PlayerProfileService) authorizeProfile(w http.ResponseWriter, r *http.Request, id uuid.UUID, action policy.Action) (database.PlayerProfile, bool) {
+	profile, err := svc.DB.GetPlayerProfile(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "not found", http.StatusNotFound)
+			return database.PlayerProfile{}, false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return database.PlayerProfile{}, false
+	}
+	currentUser, _ := userFromContext(r.Context())
+	if err := svc.Policy.AuthorizePlayerProfile(currentUser, profile.UserID, action); err != nil {
+		respondWithPolicyError(w, err)
+		return database.PlayerProfile{}, false
+	}
+	return profile, true
+}
+
+// authorizeTeam checks that a team exists and the current user may put a
+// player on it, writing the error response when not
+func (svc *PlayerProfileService) authorizeTeam(w http.ResponseWriter, r *http.Request, teamID uuid.UUID) bool {
+	team, err := svc.DB.GetTeam(r.Context(), teamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "team not found", http.StatusNotFound)
+			return false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	currentUser, _ := userFromContext(r.Context())
+	if err := svc.Policy.AuthorizeTeamAssignment(r.Context(), currentUser, team.LeagueID, "player profile"); err != nil {
+		respondWithPolicyError(w, err)
+		return false
+	}
+	return true
+}
+
 // RecalculateIsVerified sets is_verified=true if 3+ unique verifications exist
 func (svc *PlayerProfileService) RecalculateIsVerified(ctx context.Context, profileID string) error {
 	id, err := uuid.Parse(profileID)
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/go-chi/chi"
+	"github.com/lib/pq"
+)
+
+// debateTypes are the debate types prompt templates are kept for
+var debateTypes = []string{"pre_match", "post_match"}
+
+type CreatePromptTemplateRequest struct {
+	DebateType     string `json:"debate_type"`
+	SystemTemplate string `json:"system_template"`
+	UserTemplate   string `json:"user_template"`
+	Notes          string `json:"notes"`
+}
+
+// PreviewPromptTemplateRequest picks the stored debate whose match data a
+// template is previewed against. Generate also sends the rendered prompts to
+// the model.
+type PreviewPromptTemplateRequest struct {
+	DebateID int32 `json:"debate_id"`
+	Generate bool  `json:"generate"`
+}
+
+type PromptTemplateResponse struct {
+	ID             int32      `json:"id,omitempty"` // Unset for built-in templates
+	DebateType     string     `json:"debate_type"`
+	Version        int32      `json:"version"`
+	SystemTemplate string     `json:"system_template"`
+	UserTemplate   string     `json:"user_template"`
+	Notes          string     `json:"notes,omitempty"`
+	Active         bool       `json:"active"`
+	CreatedBy      *int32     `json:"created_by,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	ActivatedAt    *time.Time `json:"activated_at,omitempty"`
+}
+
+type PromptTemplatePreviewResponse struct {
+	TemplateID   int32            `json:"template_id"`
+	Version      int32            `json:"version"`
+	DebateID     int32            `json:"debate_id"`
+	SystemPrompt string           `json:"system_prompt"`
+	UserPrompt   string           `json:"user_prompt"`
+	Prompt       *ai.DebatePrompt `json:"prompt,omitempty"` // Set when generate was requested
+}
+
+// promptTemplateStore serves the active prompt templates to the prompt
+// generator
+type promptTemplateStore struct {
+	db *database.Queries
+}
+
+func (s promptTemplateStore) ActivePromptTemplate(ctx context.Context, debateType string) (*ai.PromptTemplate, error) {
+	tmpl, err := s.db.GetActivePromptTemplate(ctx, debateType)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return newAIPromptTemplate(tmpl), nil
+}
+
+func newAIPromptTemplate(tmpl database.PromptTemplate) *ai.PromptTemplate {
+	return &ai.PromptTemplate{
+		DebateType: tmpl.DebateType,
+		Version:    int(tmpl.Version),
+		System:     tmpl.SystemTemplate,
+		User:       tmpl.UserTemplate,
+	}
+}
+
+func newPromptTemplateResponse(tmpl database.PromptTemplate) PromptTemplateResponse {
+	response := PromptTemplateResponse{
+		ID:             tmpl.ID,
+		DebateType:     tmpl.DebateType,
+		Version:        tmpl.Version,
+		SystemTemplate: tmpl.SystemTemplate,
+		UserTemplate:   tmpl.UserTemplate,
+		Notes:          tmpl.Notes.String,
+		Active:         tmpl.Active,
+		CreatedAt:      &tmpl.CreatedAt,
+	}
+	if tmpl.CreatedBy.Valid {
+		response.CreatedBy = &tmpl.CreatedBy.Int32
+	}
+	if tmpl.ActivatedAt.Valid {
+		response.ActivatedAt = &tmpl.ActivatedAt.Time
+	}
+	return response
+}
+
+// listPromptTemplates returns every stored template version, newest first
+// for each debate type
+func (c *Config) listPromptTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := c.DB.ListPromptTemplates(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list prompt templates: %v", err))
+		return
+	}
+
+	response := make([]PromptTemplateResponse, len(templates))
+	for i, tmpl := range templates {
+		response[i] = newPromptTemplateResponse(tmpl)
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getDefaultPromptTemplates returns the built-in templates, a starting point
+// for new versions
+func (c *Config) getDefaultPromptTemplates(w http.ResponseWriter, r *http.Request) {
+	response := make([]PromptTemplateResponse, 0, len(debateTypes))
+	for _, debateType := range debateTypes {
+		tmpl, err := ai.DefaultPromptTemplate(debateType)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load default prompt template: %v", err))
+			return
+		}
+		response = append(response, PromptTemplateResponse{
+			DebateType:     tmpl.DebateType,
+			Version:        int32(tmpl.Version),
+			SystemTemplate: tmpl.System,
+			UserTemplate:   tmpl.User,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// createPromptTemplate saves a new, inactive template version after checking
+// that it renders
+func (c *Config) createPromptTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	var req CreatePromptTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.DebateType != "pre_match" && req.DebateType != "post_match" {
+		respondWithError(w, http.StatusBadRequest, "debate_type must be 'pre_match' or 'post_match'")
+		return
+	}
+	tmpl := ai.PromptTemplate{
+		DebateType: req.DebateType,
+		System:     req.SystemTemplate,
+		User:       req.UserTemplate,
+	}
+	if err := tmpl.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid template: %v", err))
+		return
+	}
+	req.Notes = strings.TrimSpace(req.Notes)
+
+	created, err := c.DB.CreatePromptTemplate(ctx, database.CreatePromptTemplateParams{
+		DebateType:     req.DebateType,
+		SystemTemplate: req.SystemTemplate,
+		UserTemplate:   req.UserTemplate,
+		Notes:          sql.NullString{String: req.Notes, Valid: req.Notes != ""},
+		CreatedBy:      sql.NullInt32{Int32: user.ID, Valid: true},
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("Another %s template version was created at the same time, try again", req.DebateType))
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create prompt template: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newPromptTemplateResponse(created))
+}
+
+// getPromptTemplateFromURL loads the template named by the {id} URL
+// parameter, writing the error response when it cannot
+func (c *Config) getPromptTemplateFromURL(w http.ResponseWriter, r *http.Request) (database.PromptTemplate, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid prompt template ID")
+		return database.PromptTemplate{}, false
+	}
+
+	tmpl, err := c.DB.GetPromptTemplate(r.Context(), int32(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Prompt template not found")
+			return database.PromptTemplate{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get prompt template: %v", err))
+		return database.PromptTemplate{}, false
+	}
+	return tmpl, true
+}
+
+// previewPromptTemplate renders a template against the match data a stored
+// debate was generated from, and optionally generates a debate with it,
+// without saving anything
+func (c *Config) previewPromptTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tmpl, ok := c.getPromptTemplateFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req PreviewPromptTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.DebateID <= 0 {
+		respondWithError(w, http.StatusBadRequest, "debate_id is required")
+		return
+	}
+
+	snapshot, err := c.DB.GetDebateMatchData(ctx, req.DebateID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "No match data is stored for this debate")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get match data: %v", err))
+		return
+	}
+	var matchData ai.MatchData
+	if err := json.Unmarshal(snapshot, &matchData); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to decode match data: %v", err))
+		return
+	}
+
+	aiTemplate := newAIPromptTemplate(tmpl)
+	systemPrompt, userPrompt, err := aiTemplate.Render(matchData)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to render template: %v", err))
+		return
+	}
+	response := PromptTemplatePreviewResponse{
+		TemplateID:   tmpl.ID,
+		Version:      tmpl.Version,
+		DebateID:     req.DebateID,
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+	}
+
+	if req.Generate {
+		if c.AIPromptGenerator == nil {
+			respondWithError(w, http.StatusServiceUnavailable, "AI prompt generation is not configured")
+			return
+		}
+		prompt, err := c.AIPromptGenerator.GenerateWithTemplate(ctx, aiTemplate, matchData)
+		if err != nil {
+			respondWithError(w, http.StatusBadGateway, fmt.Sprintf("Failed to generate preview: %v", err))
+			return
+		}
+		response.Prompt = prompt
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// activatePromptTemplate makes a template the one new debates of its type
+// are generated with, replacing the active one
+func (c *Config) activatePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tmpl, ok := c.getPromptTemplateFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := c.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to begin transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := c.DB.WithTx(tx)
+
+	if err := qtx.DeactivatePromptTemplates(ctx, tmpl.DebateType); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to deactivate prompt templates: %v", err))
+		return
+	}
+	activated, err := qtx.ActivatePromptTemplate(ctx, tmpl.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("Another %s template was activated at the same time", tmpl.DebateType))
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to activate prompt template: %v", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newPromptTemplateResponse(activated))
+}
+
+// deactivatePromptTemplate reverts a template's debate type to the built-in
+// template
+func (c *Config) deactivatePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tmpl, ok := c.getPromptTemplateFromURL(w, r)
+	if !ok {
+		return
+	}
+	if !tmpl.Active {
+		respondWithError(w, http.StatusConflict, "Prompt template is not active")
+		return
+	}
+
+	if err := c.DB.DeactivatePromptTemplates(ctx, tmpl.DebateType); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to deactivate prompt template: %v", err))
+		return
+	}
+
+	tmpl.Active = false
+	respondWithJSON(w, http.StatusOK, newPromptTemplateResponse(tmpl))
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/cache"
+)
+
+// RateLimit is a request budget for a group of routes
+type RateLimit struct {
+	Name     string
+	Requests int
+	Window   time.Duration
+}
+
+// Per-route budgets. Both routes fan out to paid upstream APIs.
+var (
+	debateGenerationLimit = RateLimit{Name: "debates_generate", Requests: 10, Window: time.Hour}
+	googleSearchLimit     = RateLimit{Name: "google_search", Requests: 30, Window: time.Minute}
+)
+
+// rateLimit enforces limit per client, identified by API key, then user, then
+// IP address. Limiter errors let the request through rather than failing it.
+func (c *Config) rateLimit(limit RateLimit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := fmt.Sprintf("ratelimit:%s:%s", limit.Name, c.rateLimitIdentity(r))
+			result, err := c.RateLimiter.Allow(r.Context(), key, limit.Requests, limit.Window)
+			if err != nil {
+				fmt.Printf("Rate limit check failed: %v\n", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			resetSeconds := int(math.Ceil(result.ResetAfter.Seconds()))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(result.Remaining, 0)))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(resetSeconds))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(max(resetSeconds, 1)))
+				respondWithError(w, http.StatusTooManyRequests, "Rate limit exceeded. Please try again later.")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitIdentity names the client a request is counted against
+func (c *Config) rateLimitIdentity(r *http.Request) string {
+	if apiKey, ok := apiKeyFromContext(r.Context()); ok {
+		return "key:" + apiKey.ID.String()
+	}
+	if user, ok := userFromContext(r.Context()); ok {
+		return "user:" + strconv.Itoa(int(user.ID))
+	}
+	return "ip:" + c.clientIP(r)
+}
+
+// clientIP returns the caller's address. X-Forwarded-For is only believed
+// when the connection comes from a trusted proxy; its entries are read from
+// the right, since each proxy appends the address it saw, and the first one
+// that is not itself a trusted proxy is the client. Entries further left
+// were written by the client and may be forged.
+func (c *Config) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !c.isTrustedProxy(host) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !c.isTrustedProxy(hop) {
+			return hop
+		}
+		host = hop
+	}
+	return host
+}
+
+func (c *Config) isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range c.TrustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies reads a comma-separated list of proxy addresses and
+// CIDR ranges, e.g. "10.0.0.0/8,192.168.1.10"
+func ParseTrustedProxies(s string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// newDefaultRateLimiter uses Redis when the cache is backed by it and falls
+// back to process memory otherwise
+func newDefaultRateLimiter(c cache.CacheInterface) cache.RateLimiter {
+	if limiter, ok := c.(cache.RateLimiter); ok {
+		return cache.NewFallbackRateLimiter(limiter, cache.NewMemoryRateLimiter())
+	}
+	return cache.NewMemoryRateLimiter()
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/cache"
+	"github.com/ArronJLinton/fucci-api/internal/database"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	config := &Config{RateLimiter: cache.NewMemoryRateLimiter()}
+	limit := RateLimit{Name: "test", Requests: 2, Window: time.Minute}
+	handler := config.rateLimit(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		rr := send("10.0.0.1:1234")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to pass, got %d", i+1, rr.Code)
+		}
+		if rr.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Errorf("Expected X-RateLimit-Limit 2, got %s", rr.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+
+	rr := send("10.0.0.1:5678")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rr.Code)
+	}
+	if rr.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("Expected X-RateLimit-Remaining 0, got %s", rr.Header().Get("X-RateLimit-Remaining"))
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on 429")
+	}
+
+	if rr := send("10.0.0.2:1234"); rr.Code != http.StatusOK {
+		t.Errorf("Expected a different client to pass, got %d", rr.Code)
+	}
+}
+
+func TestRateLimitIdentity(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	config := &Config{TrustedProxies: proxies}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"untrusted peer", "203.0.113.9:1234", "1.2.3.4", "ip:203.0.113.9"},
+		{"trusted proxy", "10.0.0.1:1234", "1.2.3.4, 5.6.7.8", "ip:5.6.7.8"},
+		{"chain of trusted proxies", "10.0.0.1:1234", "9.9.9.9, 5.6.7.8, 192.168.1.10", "ip:5.6.7.8"},
+		{"trusted proxy without header", "192.168.1.10:1234", "", "ip:192.168.1.10"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if got := config.rateLimitIdentity(req); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(withUser(req.Context(), database.User{ID: 7}))
+	if got := config.rateLimitIdentity(req); got != "user:7" {
+		t.Errorf("Expected user identity, got %s", got)
+	}
+
+	if _, err := ParseTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("Expected an error for an invalid range")
+	}
+}
@@ -0,0 +1,589 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/go-chi/chi"
+)
+
+// defaultReportHideThreshold is how many open reports hide a target when
+// Config.ReportHideThreshold is unset
+const defaultReportHideThreshold = 5
+
+// maxReportDetailsLength caps the free text a reporter may add
+const maxReportDetailsLength = 1000
+
+// Report target types
+const (
+	reportTargetDebate     = "debate"
+	reportTargetDebateCard = "debate_card"
+	reportTargetComment    = "comment"
+)
+
+// Report statuses
+const (
+	reportStatusOpen      = "open"
+	reportStatusActioned  = "actioned"
+	reportStatusDismissed = "dismissed"
+)
+
+// reportReasons matches the reports.reason check constraint
+var reportReasons = map[string]bool{
+	"spam":           true,
+	"harassment":     true,
+	"hate_speech":    true,
+	"misinformation": true,
+	"off_topic":      true,
+	"other":          true,
+}
+
+// Moderation actions recorded in the audit trail
+const (
+	moderationActionAutoHide         = "auto_hide"
+	moderationActionHide             = "hide"
+	moderationActionDismiss          = "dismiss"
+	moderationActionDeleteComment    = "delete_comment"
+	moderationActionRestoreComment   = "restore_comment"
+	moderationActionRestoreDebate    = "restore_debate"
+	moderationActionHardDeleteDebate = "hard_delete_debate"
+	moderationActionApproveComment   = "approve_comment"
+)
+
+type CreateReportRequest struct {
+	TargetType string `json:"target_type"`
+	TargetID   int32  `json:"target_id"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details"`
+}
+
+type ResolveReportRequest struct {
+	Resolution string `json:"resolution"` // "actioned" hides the target, "dismissed" clears it
+	Notes      string `json:"notes"`
+}
+
+type ReportResponse struct {
+	ID          int32      `json:"id"`
+	ReporterID  int32      `json:"reporter_id"`
+	TargetType  string     `json:"target_type"`
+	TargetID    int32      `json:"target_id"`
+	Reason      string     `json:"reason"`
+	Details     string     `json:"details,omitempty"`
+	Status      string     `json:"status"`
+	OpenReports int        `json:"open_reports,omitempty"` // Open reports on the same target
+	ResolvedBy  *int32     `json:"resolved_by,omitempty"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ModerationActionResponse is one entry in the moderation audit trail. A nil
+// ModeratorID marks an automatic action.
+type ModerationActionResponse struct {
+	ID          int32     `json:"id"`
+	ModeratorID *int32    `json:"moderator_id,omitempty"`
+	Action      string    `json:"action"`
+	TargetType  string    `json:"target_type"`
+	TargetID    int32     `json:"target_id"`
+	ReportID    *int32    `json:"report_id,omitempty"`
+	Notes       string    `json:"notes,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// validateCreateReportRequest checks a report's fields, returning the message
+// to send back when one is invalid
+func validateCreateReportRequest(req CreateReportRequest) (string, bool) {
+	switch req.TargetType {
+	case reportTargetDebate, reportTargetDebateCard, reportTargetComment:
+	default:
+		return "target_type must be 'debate', 'debate_card' or 'comment'", false
+	}
+	if req.TargetID <= 0 {
+		return "target_id is required", false
+	}
+	if !reportReasons[req.Reason] {
+		return "reason must be one of 'spam', 'harassment', 'hate_speech', 'misinformation', 'off_topic' or 'other'", false
+	}
+	if len(req.Details) > maxReportDetailsLength {
+		return fmt.Sprintf("details must be at most %d characters", maxReportDetailsLength), false
+	}
+	return "", true
+}
+
+func newReportResponse(report database.Report) ReportResponse {
+	response := ReportResponse{
+		ID:         report.ID,
+		ReporterID: report.ReporterID,
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+		Reason:     report.Reason,
+		Details:    report.Details.String,
+		Status:     report.Status,
+		CreatedAt:  report.CreatedAt,
+	}
+	if report.ResolvedBy.Valid {
+		response.ResolvedBy = &report.ResolvedBy.Int32
+	}
+	if report.ResolvedAt.Valid {
+		response.ResolvedAt = &report.ResolvedAt.Time
+	}
+	return response
+}
+
+// ReportTargetResponse is the reported content as an admin sees it while
+// triaging, including content that moderation has hidden
+type ReportTargetResponse struct {
+	TargetType   string     `json:"target_type"`
+	TargetID     int32      `json:"target_id"`
+	DebateID     int32      `json:"debate_id"` // The debate itself, or the one a card or comment belongs to
+	Headline     string     `json:"headline,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	Content      string     `json:"content,omitempty"` // Debate or card description, or comment text
+	HiddenAt     *time.Time `json:"hidden_at,omitempty"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	DebateHidden bool       `json:"debate_hidden"` // A card or comment's debate is hidden
+}
+
+// visible reports whether users can still see the target
+func (t ReportTargetResponse) visible() bool {
+	return t.HiddenAt == nil && t.DeletedAt == nil && !t.DebateHidden
+}
+
+// loadReportTarget reads a report target whether or not it is hidden. It
+// returns sql.ErrNoRows when the target, or the debate it belongs to, no
+// longer exists.
+func (c *Config) loadReportTarget(ctx context.Context, targetType string, targetID int32) (ReportTargetResponse, error) {
+	target := ReportTargetResponse{TargetType: targetType, TargetID: targetID}
+	switch targetType {
+	case reportTargetDebate:
+		target.DebateID = targetID
+	case reportTargetDebateCard:
+		card, err := c.DB.GetDebateCard(ctx, targetID)
+		if err != nil {
+			return ReportTargetResponse{}, err
+		}
+		target.DebateID = card.DebateID.Int32
+		target.Title = card.Title
+		target.Content = card.Description.String
+		target.HiddenAt = nullTimePtr(card.HiddenAt)
+	case reportTargetComment:
+		comment, err := c.DB.GetComment(ctx, targetID)
+		if err != nil {
+			return ReportTargetResponse{}, err
+		}
+		target.DebateID = comment.DebateID.Int32
+		target.Content = comment.Content
+		target.HiddenAt = nullTimePtr(comment.HiddenAt)
+		target.DeletedAt = nullTimePtr(comment.DeletedAt)
+	default:
+		return ReportTargetResponse{}, sql.ErrNoRows
+	}
+
+	debate, err := c.DB.GetDebateIncludingHidden(ctx, target.DebateID)
+	if err != nil {
+		return ReportTargetResponse{}, err
+	}
+	if targetType == reportTargetDebate {
+		target.Headline = debate.Headline
+		target.Content = debate.Description.String
+		target.HiddenAt = nullTimePtr(debate.HiddenAt)
+	} else {
+		target.DebateHidden = debate.HiddenAt.Valid
+	}
+	return target, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// reportTargetVisible reports whether the target of a report exists and is
+// still shown to users. Cards and comments are not shown once their debate
+// is hidden.
+func (c *Config) reportTargetVisible(ctx context.Context, targetType string, targetID int32) (bool, error) {
+	target, err := c.loadReportTarget(ctx, targetType, targetID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return target.visible(), nil
+}
+
+// setTargetHidden hides or unhides a report target, returning whether its
+// state changed
+func setTargetHidden(ctx context.Context, q *database.Queries, targetType string, targetID int32, hidden bool) (bool, error) {
+	var changed int64
+	var err error
+	switch targetType {
+	case reportTargetDebate:
+		changed, err = q.SetDebateHidden(ctx, database.SetDebateHiddenParams{Hidden: hidden, ID: targetID})
+	case reportTargetDebateCard:
+		changed, err = q.SetDebateCardHidden(ctx, database.SetDebateCardHiddenParams{Hidden: hidden, ID: targetID})
+	case reportTargetComment:
+		changed, err = q.SetCommentHidden(ctx, database.SetCommentHiddenParams{Hidden: hidden, ID: targetID})
+	default:
+		return false, fmt.Errorf("unknown report target type %q", targetType)
+	}
+	return changed > 0, err
+}
+
+// recordModerationAction appends to the moderation audit trail. Failures are
+// logged rather than failing the action they describe.
+func (c *Config) recordModerationAction(ctx context.Context, params database.CreateModerationActionParams) {
+	if _, err := c.DB.CreateModerationAction(ctx, params); err != nil {
+		fmt.Printf("Failed to record moderation action: %v\n", err)
+	}
+}
+
+// createReport files the caller's report against a debate, card or comment.
+// Once a target's open reports reach the configured threshold it is hidden
+// until an admin triages it.
+func (c *Config) createReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	var req CreateReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.TargetType = strings.TrimSpace(req.TargetType)
+	req.Reason = strings.TrimSpace(req.Reason)
+	req.Details = strings.TrimSpace(req.Details)
+	if message, ok := validateCreateReportRequest(req); !ok {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	visible, err := c.reportTargetVisible(ctx, req.TargetType, req.TargetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get report target: %v", err))
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "Report target not found")
+		return
+	}
+
+	report, err := c.DB.CreateReport(ctx, database.CreateReportParams{
+		ReporterID: user.ID,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Reason:     req.Reason,
+		Details:    sql.NullString{String: req.Details, Valid: req.Details != ""},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusConflict, "You have already reported this content")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create report: %v", err))
+		return
+	}
+
+	c.applyReportThreshold(ctx, report)
+
+	respondWithJSON(w, http.StatusCreated, newReportResponse(report))
+}
+
+// applyReportThreshold hides a report's target once it has gathered enough
+// open reports. The report itself has already been filed, so failures are
+// only logged.
+func (c *Config) applyReportThreshold(ctx context.Context, report database.Report) {
+	open, err := c.DB.CountOpenReports(ctx, database.CountOpenReportsParams{
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+	})
+	if err != nil {
+		fmt.Printf("Failed to count open reports: %v\n", err)
+		return
+	}
+	if open < int64(c.ReportHideThreshold) {
+		return
+	}
+
+	hidden, err := setTargetHidden(ctx, c.DB, report.TargetType, report.TargetID, true)
+	if err != nil {
+		fmt.Printf("Failed to hide reported %s %d: %v\n", report.TargetType, report.TargetID, err)
+		return
+	}
+	if !hidden {
+		return
+	}
+
+	c.recordModerationAction(ctx, database.CreateModerationActionParams{
+		Action:     moderationActionAutoHide,
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+		ReportID:   sql.NullInt32{Int32: report.ID, Valid: true},
+		Notes:      sql.NullString{String: fmt.Sprintf("%d open reports", open), Valid: true},
+	})
+}
+
+// listReports lists reports for admin triage, filtered by status (open by
+// default) and target type
+func (c *Config) listReports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	if status == "" {
+		status = reportStatusOpen
+	}
+	if status == "all" {
+		status = ""
+	}
+	if status != "" && status != reportStatusOpen && status != reportStatusActioned && status != reportStatusDismissed {
+		respondWithError(w, http.StatusBadRequest, "status must be 'open', 'actioned', 'dismissed' or 'all'")
+		return
+	}
+	targetType := query.Get("target_type")
+	if targetType != "" && targetType != reportTargetDebate && targetType != reportTargetDebateCard && targetType != reportTargetComment {
+		respondWithError(w, http.StatusBadRequest, "target_type must be 'debate', 'debate_card' or 'comment'")
+		return
+	}
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	reports, err := c.DB.ListReports(r.Context(), database.ListReportsParams{
+		Status:     status,
+		TargetType: targetType,
+		PageSize:   int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list reports: %v", err))
+		return
+	}
+
+	response := make([]ReportResponse, 0, len(reports))
+	for _, report := range reports {
+		item := newReportResponse(database.Report{
+			ID:         report.ID,
+			ReporterID: report.ReporterID,
+			TargetType: report.TargetType,
+			TargetID:   report.TargetID,
+			Reason:     report.Reason,
+			Details:    report.Details,
+			Status:     report.Status,
+			ResolvedBy: report.ResolvedBy,
+			ResolvedAt: report.ResolvedAt,
+			CreatedAt:  report.CreatedAt,
+		})
+		item.OpenReports = int(report.OpenReports)
+		response = append(response, item)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// getReportTarget shows an admin the content a report is about, even once it
+// has been hidden
+func (c *Config) getReportTarget(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	reportID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	report, err := c.DB.GetReport(ctx, int32(reportID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Report not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get report: %v", err))
+		return
+	}
+
+	target, err := c.loadReportTarget(ctx, report.TargetType, report.TargetID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Report target not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get report target: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, target)
+}
+
+// resolveReport triages an open report. Actioning it hides the target;
+// dismissing it unhides a target that was hidden automatically. Either way
+// every open report on the target is closed with the same resolution.
+func (c *Config) resolveReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := userFromContext(ctx)
+
+	reportID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var req ResolveReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Resolution != reportStatusActioned && req.Resolution != reportStatusDismissed {
+		respondWithError(w, http.StatusBadRequest, "resolution must be 'actioned' or 'dismissed'")
+		return
+	}
+	req.Notes = strings.TrimSpace(req.Notes)
+
+	report, err := c.DB.GetReport(ctx, int32(reportID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "Report not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get report: %v", err))
+		return
+	}
+	if report.Status != reportStatusOpen {
+		respondWithError(w, http.StatusConflict, "Report has already been resolved")
+		return
+	}
+
+	action := moderationActionHide
+	if req.Resolution == reportStatusDismissed {
+		action = moderationActionDismiss
+	}
+
+	tx, err := c.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to begin transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := c.DB.WithTx(tx)
+
+	if _, err := setTargetHidden(ctx, qtx, report.TargetType, report.TargetID, req.Resolution == reportStatusActioned); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update report target: %v", err))
+		return
+	}
+
+	resolved, err := qtx.ResolveReportsForTarget(ctx, database.ResolveReportsForTargetParams{
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+		Status:     req.Resolution,
+		ResolvedBy: sql.NullInt32{Int32: user.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to resolve reports: %v", err))
+		return
+	}
+
+	_, err = qtx.CreateModerationAction(ctx, database.CreateModerationActionParams{
+		ModeratorID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Action:      action,
+		TargetType:  report.TargetType,
+		TargetID:    report.TargetID,
+		ReportID:    sql.NullInt32{Int32: report.ID, Valid: true},
+		Notes:       sql.NullString{String: req.Notes, Valid: req.Notes != ""},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to record moderation action: %v", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":          "Report resolved successfully",
+		"resolution":       req.Resolution,
+		"reports_resolved": resolved,
+	})
+}
+
+// listModerationActions returns the moderation audit trail, optionally for a
+// single target
+func (c *Config) listModerationActions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	targetType := query.Get("target_type")
+	if targetType != "" && targetType != reportTargetDebate && targetType != reportTargetDebateCard && targetType != reportTargetComment {
+		respondWithError(w, http.StatusBadRequest, "target_type must be 'debate', 'debate_card' or 'comment'")
+		return
+	}
+	var targetID int64
+	if s := query.Get("target_id"); s != "" {
+		var err error
+		targetID, err = strconv.ParseInt(s, 10, 32)
+		if err != nil || targetID <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid target ID")
+			return
+		}
+	}
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	actions, err := c.DB.ListModerationActions(r.Context(), database.ListModerationActionsParams{
+		TargetType: targetType,
+		TargetID:   int32(targetID),
+		PageSize:   int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list moderation actions: %v", err))
+		return
+	}
+
+	response := make([]ModerationActionResponse, 0, len(actions))
+	for _, action := range actions {
+		item := ModerationActionResponse{
+			ID:         action.ID,
+			Action:     action.Action,
+			TargetType: action.TargetType,
+			TargetID:   action.TargetID,
+			Notes:      action.Notes.String,
+			CreatedAt:  action.CreatedAt,
+		}
+		if action.ModeratorID.Valid {
+			item.ModeratorID = &action.ModeratorID.Int32
+		}
+		if action.ReportID.Valid {
+			item.ReportID = &action.ReportID.Int32
+		}
+		response = append(response, item)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
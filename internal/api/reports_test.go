@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+)
+
+func TestValidateCreateReportRequest(t *testing.T) {
+	valid := CreateReportRequest{TargetType: "comment", TargetID: 3, Reason: "spam"}
+	if message, ok := validateCreateReportRequest(valid); !ok {
+		t.Errorf("Expected valid report, got %q", message)
+	}
+
+	tests := []struct {
+		name string
+		req  CreateReportRequest
+	}{
+		{"unknown target type", CreateReportRequest{TargetType: "user", TargetID: 3, Reason: "spam"}},
+		{"missing target id", CreateReportRequest{TargetType: "debate", Reason: "spam"}},
+		{"unknown reason", CreateReportRequest{TargetType: "debate_card", TargetID: 3, Reason: "boring"}},
+		{"details too long", CreateReportRequest{TargetType: "comment", TargetID: 3, Reason: "other", Details: strings.Repeat("a", maxReportDetailsLength+1)}},
+	}
+	for _, tt := range tests {
+		if _, ok := validateCreateReportRequest(tt.req); ok {
+			t.Errorf("%s: expected report to be rejected", tt.name)
+		}
+	}
+}
+
+func TestReportTargetVisible(t *testing.T) {
+	config := newTestConfig(t)
+	hidden := sql.NullTime{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), Valid: true}
+	live, liveCards := addTestDebate(t, config, database.Debate{Headline: "Live"},
+		database.DebateCard{Stance: "agree", Title: "Shown"}, database.DebateCard{Stance: "disagree", Title: "Hidden", HiddenAt: hidden})
+	hiddenDebate, hiddenCards := addTestDebate(t, config, database.Debate{DebateType: "pre_match", Headline: "Hidden", HiddenAt: hidden},
+		database.DebateCard{Stance: "agree", Title: "On a hidden debate"})
+
+	tests := []struct {
+		name       string
+		targetType string
+		targetID   int32
+		want       bool
+	}{
+		{"live debate", reportTargetDebate, live.ID, true},
+		{"hidden debate", reportTargetDebate, hiddenDebate.ID, false},
+		{"live card", reportTargetDebateCard, liveCards[0].ID, true},
+		{"hidden card", reportTargetDebateCard, liveCards[1].ID, false},
+		{"card on a hidden debate", reportTargetDebateCard, hiddenCards[0].ID, false},
+		{"missing card", reportTargetDebateCard, 999, false},
+	}
+	for _, tt := range tests {
+		got, err := config.reportTargetVisible(context.Background(), tt.targetType, tt.targetID)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected visible to be %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	// Admins still see hidden targets while triaging
+	target, err := config.loadReportTarget(context.Background(), reportTargetDebateCard, hiddenCards[0].ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if target.Title != "On a hidden debate" || target.DebateID != hiddenDebate.ID || !target.DebateHidden {
+		t.Errorf("Expected the card on its hidden debate, got %+v", target)
+	}
+	target, err = config.loadReportTarget(context.Background(), reportTargetDebate, hiddenDebate.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if target.Headline != "Hidden" || target.HiddenAt == nil {
+		t.Errorf("Expected the hidden debate, got %+v", target)
+	}
+}
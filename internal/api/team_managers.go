@@ -1,25 +1,26 @@
 package api
 
 import (
-	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/policy"
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
 )
 
 // TeamManagersService handles team manager-related operations
 type TeamManagersService struct {
-	db *database.Queries
+	db    *database.Queries
+	authz *policy.Engine
 }
 
 // NewTeamManagersService creates a new team managers service
-func NewTeamManagersService(db *database.Queries) *TeamManagersService {
-	return &TeamManagersService{db: db}
+func NewTeamManagersService(db *database.Queries, authz *policy.Engine) *TeamManagersService {
+	return &TeamManagersService{db: db, authz: authz}
 }
 
 // CreateTeamManager creates a new team manager
@@ -65,9 +66,9 @@ func (s *TeamManagersService) CreateTeamManager(w http.ResponseWriter, r *http.R
 	}
 
 	// Check if current user has permission to create managers in this league
-	currentUserID := getUserIDFromContext(r)
-	if !s.hasManagerPermission(r.Context(), currentUserID, leagueIDParsed, "create") {
-		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+	currentUser, _ := userFromContext(r.Context())
+	if err := s.authz.AuthorizeManager(r.Context(), currentUser, leagueIDParsed, req.UserID, policy.ActionCreate); err != nil {
+		respondWithPolicyError(w, err)
 		return
 	}
 
@@ -82,17 +83,9 @@ func (s *TeamManagersService) CreateTeamManager(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Check if team exists (if provided)
-	if teamID != nil {
-		_, err := s.db.GetTeam(r.Context(), *teamID)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				http.Error(w, "Team not found", http.StatusNotFound)
-				return
-			}
-			http.Error(w, "Failed to get team", http.StatusInternalServerError)
-			return
-		}
+	// Check if team exists (if provided) and is in the manager's league
+	if teamID != nil && !s.checkManagerTeam(w, r, currentUser, leagueIDParsed, *teamID) {
+		return
 	}
 
 	// Check if user is already a manager in this league
@@ -260,9 +253,9 @@ func (s *TeamManagersService) UpdateTeamManager(w http.ResponseWriter, r *http.R
 	}
 
 	// Check permissions
-	currentUserID := getUserIDFromContext(r)
-	if !s.hasManagerPermission(r.Context(), currentUserID, manager.LeagueID, "update") {
-		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+	currentUser, _ := userFromContext(r.Context())
+	if err := s.authz.AuthorizeManager(r.Context(), currentUser, manager.LeagueID, manager.UserID, policy.ActionUpdate); err != nil {
+		respondWithPolicyError(w, err)
 		return
 	}
 
@@ -305,17 +298,10 @@ func (s *TeamManagersService) UpdateTeamManager(w http.ResponseWriter, r *http.R
 		params.Bio = manager.Bio
 	}
 
-	// Validate team exists if changing
-	if req.TeamID != nil && params.TeamID.Valid {
-		_, err := s.db.GetTeam(r.Context(), params.TeamID.UUID)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				http.Error(w, "Team not found", http.StatusNotFound)
-				return
-			}
-			http.Error(w, "Failed to get team", http.StatusInternalServerError)
-			return
-		}
+	// Moving the manager onto a team needs rights over that team, not just
+	// over the manager record, which managers may update themselves
+	if params.TeamID.Valid && params.TeamID != manager.TeamID && !s.checkManagerTeam(w, r, currentUser, manager.LeagueID, params.TeamID.UUID) {
+		return
 	}
 
 	// Update team manager
@@ -329,6 +315,30 @@ func (s *TeamManagersService) UpdateTeamManager(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(updatedManager)
 }
 
+// checkManagerTeam checks that a manager in a league may be put on a team:
+// the team exists, is in the league, and the current user may assign it.
+// It writes the error response when not.
+func (s *TeamManagersService) checkManagerTeam(w http.ResponseWriter, r *http.Request, currentUser database.User, leagueID, teamID uuid.UUID) bool {
+	team, err := s.db.GetTeam(r.Context(), teamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Team not found", http.StatusNotFound)
+			return false
+		}
+		http.Error(w, "Failed to get team", http.StatusInternalServerError)
+		return false
+	}
+	if !team.LeagueID.Valid || team.LeagueID.UUID != leagueID {
+		http.Error(w, "Team is not in the manager's league", http.StatusBadRequest)
+		return false
+	}
+	if err := s.authz.AuthorizeTeamAssignment(r.Context(), currentUser, team.LeagueID, "team manager"); err != nil {
+		respondWithPolicyError(w, err)
+		return false
+	}
+	return true
+}
+
 // DeleteTeamManager deletes a team manager
 func (s *TeamManagersService) DeleteTeamManager(w http.ResponseWriter, r *http.Request) {
 	managerID, err := uuid.Parse(chi.URLParam(r, "id"))
@@ -349,9 +359,9 @@ func (s *TeamManagersService) DeleteTeamManager(w http.ResponseWriter, r *http.R
 	}
 
 	// Check permissions
-	currentUserID := getUserIDFromContext(r)
-	if !s.hasManagerPermission(r.Context(), currentUserID, manager.LeagueID, "delete") {
-		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+	currentUser, _ := userFromContext(r.Context())
+	if err := s.authz.AuthorizeManager(r.Context(), currentUser, manager.LeagueID, manager.UserID, policy.ActionDelete); err != nil {
+		respondWithPolicyError(w, err)
 		return
 	}
 
@@ -434,10 +444,3 @@ func (s *TeamManagersService) GetManagerStats(w http.ResponseWriter, r *http.Req
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
-
-// hasManagerPermission checks if user has permission for manager operations
-func (s *TeamManagersService) hasManagerPermission(ctx context.Context, userID, leagueID uuid.UUID, operation string) bool {
-	// For now, return true for all operations since we're using placeholder user IDs
-	// In a real implementation, this would check user permissions properly
-	return true
-}
@@ -1,25 +1,26 @@
 package api
 
 import (
-	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/policy"
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
 )
 
 // TeamsService handles team-related operations
 type TeamsService struct {
-	db *database.Queries
+	db    *database.Queries
+	authz *policy.Engine
 }
 
 // NewTeamsService creates a new teams service
-func NewTeamsService(db *database.Queries) *TeamsService {
-	return &TeamsService{db: db}
+func NewTeamsService(db *database.Queries, authz *policy.Engine) *TeamsService {
+	return &TeamsService{db: db, authz: authz}
 }
 
 // CreateTeam creates a new team
@@ -65,21 +66,11 @@ func (s *TeamsService) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if user has permission to create teams in this league
-	// if !s.hasTeamPermission(r.Context(), userID, leagueID, "create") {
-	// 	http.Error(w, "Insufficient permissions", http.StatusForbidden)
-	// 	return
-	// }
-
-	// Check if league exists
-	// league, err := s.db.GetLeague(r.Context(), leagueID)
-	// if err != nil {
-	// 	if err == sql.ErrNoRows {
-	// 		http.Error(w, "League not found", http.StatusNotFound)
-	// 		return
-	// 	}
-	// 	http.Error(w, "Failed to get league", http.StatusInternalServerError)
-	// 	return
-	// }
+	user, _ := userFromContext(r.Context())
+	if err := s.authz.AuthorizeTeam(r.Context(), user, uuid.NullUUID{UUID: leagueID, Valid: true}, uuid.Nil, policy.ActionCreate); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
 
 	// Check if manager exists (if provided)
 	if managerID != nil {
@@ -252,10 +243,11 @@ func (s *TeamsService) UpdateTeam(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check permissions
-	// if !s.hasTeamPermission(r.Context(), userID, team.LeagueID, "update") {
-	// 	http.Error(w, "Insufficient permissions", http.StatusForbidden)
-	// 	return
-	// }
+	user, _ := userFromContext(r.Context())
+	if err := s.authz.AuthorizeTeam(r.Context(), user, team.LeagueID, team.ID, policy.ActionUpdate); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
 
 	// Prepare update parameters
 	params := database.UpdateTeamParams{
@@ -350,6 +342,14 @@ func (s *TeamsService) UpdateTeam(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Moving a team requires the right to create teams in the target league
+	if req.LeagueID != nil && params.LeagueID != team.LeagueID {
+		if err := s.authz.AuthorizeTeam(r.Context(), user, params.LeagueID, team.ID, policy.ActionCreate); err != nil {
+			respondWithPolicyError(w, err)
+			return
+		}
+	}
+
 	// Validate manager exists if changing
 	if req.ManagerID != nil && params.ManagerID.UUID != uuid.Nil {
 		_, err := s.db.GetTeamManager(r.Context(), params.ManagerID.UUID)
@@ -382,11 +382,23 @@ func (s *TeamsService) DeleteTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Get team to check permissions
+	team, err := s.db.GetTeam(r.Context(), teamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Team not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get team", http.StatusInternalServerError)
+		return
+	}
+
 	// Check permissions
-	// if !s.hasTeamPermission(r.Context(), userID, team.LeagueID, "delete") {
-	// 	http.Error(w, "Insufficient permissions", http.StatusForbidden)
-	// 	return
-	// }
+	user, _ := userFromContext(r.Context())
+	if err := s.authz.AuthorizeTeam(r.Context(), user, team.LeagueID, team.ID, policy.ActionDelete); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
 
 	err = s.db.DeleteTeam(r.Context(), teamID)
 	if err != nil {
@@ -453,10 +465,3 @@ func (s *TeamsService) GetTeamStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
-
-// Simplify hasTeamPermission function
-func (s *TeamsService) hasTeamPermission(ctx context.Context, userID, leagueID uuid.UUID, operation string) bool {
-	// For now, return true for all operations since we're using placeholder user IDs
-	// In a real implementation, this would check user permissions properly
-	return true
-}
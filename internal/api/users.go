@@ -1,39 +1,523 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ArronJLinton/fucci-api/internal/auth"
 	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/policy"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-func (config *Config) handleCreateUser(w http.ResponseWriter, r *http.Request) {
-	decoder := json.NewDecoder(r.Body)
-	params := database.CreateUserParams{}
-	err := decoder.Decode(&params)
+type SignupRequest struct {
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type UserResponse struct {
+	ID        int32     `json:"id"`
+	Firstname string    `json:"firstname"`
+	Lastname  string    `json:"lastname"`
+	Email     string    `json:"email"`
+	IsAdmin   bool      `json:"is_admin"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type UpdateUserRequest struct {
+	Firstname *string `json:"firstname"`
+	Lastname  *string `json:"lastname"`
+	Email     *string `json:"email"`
+	IsAdmin   *bool   `json:"is_admin"`
+}
+
+// PublicUserResponse is what users see of each other in the directory
+type PublicUserResponse struct {
+	ID        int32     `json:"id"`
+	Firstname string    `json:"firstname"`
+	Lastname  string    `json:"lastname"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuthResponse struct {
+	User         UserResponse `json:"user"`
+	AccessToken  string       `json:"access_token"`
+	RefreshToken string       `json:"refresh_token"`
+	TokenType    string       `json:"token_type"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+}
+
+func newUserResponse(user database.User) UserResponse {
+	return UserResponse{
+		ID:        user.ID,
+		Firstname: user.Firstname,
+		Lastname:  user.Lastname,
+		Email:     user.Email,
+		IsAdmin:   user.IsAdmin,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+func newPublicUserResponse(user database.User) PublicUserResponse {
+	return PublicUserResponse{
+		ID:        user.ID,
+		Firstname: user.Firstname,
+		Lastname:  user.Lastname,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// userResponseFor renders user as seen by viewer: admins and the user
+// themselves get every field, everyone else the public fields only
+func userResponseFor(viewer, user database.User) interface{} {
+	if viewer.IsAdmin || viewer.ID == user.ID {
+		return newUserResponse(user)
+	}
+	return newPublicUserResponse(user)
+}
+
+// handleListUsers lists users, optionally filtered by a name or email prefix
+func (config *Config) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	viewer, _ := userFromContext(r.Context())
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	// Only admins may match on email, since other users cannot see emails
+	users, err := config.DB.SearchUsers(r.Context(), database.SearchUsersParams{
+		Search:       escapeLikePattern(strings.TrimSpace(r.URL.Query().Get("search"))),
+		IncludeEmail: viewer.IsAdmin,
+		PageSize:     int32(limit),
+		PageOffset:   int32(offset),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error listing users: %s", err))
+		return
+	}
+
+	response := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		response = append(response, userResponseFor(viewer, user))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handleGetUser returns a single user
+func (config *Config) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	viewer, _ := userFromContext(r.Context())
+
+	user, ok := config.loadUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, userResponseFor(viewer, user))
+}
+
+// handleUpdateUser updates a user's profile. Users may edit themselves;
+// only admins may edit others or change is_admin.
+func (config *Config) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	viewer, _ := userFromContext(ctx)
+
+	user, ok := config.loadUserParam(w, r)
+	if !ok {
+		return
+	}
+	if err := config.Policy.AuthorizeUser(viewer, user.ID, policy.ActionUpdate); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.IsAdmin != nil && *req.IsAdmin != user.IsAdmin && !viewer.IsAdmin {
+		respondWithError(w, http.StatusForbidden, "Only admins may change is_admin")
+		return
+	}
+
+	params := database.UpdateUserParams{
+		ID:        user.ID,
+		Firstname: user.Firstname,
+		Lastname:  user.Lastname,
+		Email:     user.Email,
+		IsAdmin:   user.IsAdmin,
+	}
+	if req.Firstname != nil {
+		params.Firstname = strings.TrimSpace(*req.Firstname)
+	}
+	if req.Lastname != nil {
+		params.Lastname = strings.TrimSpace(*req.Lastname)
+	}
+	if req.Email != nil {
+		params.Email = strings.ToLower(strings.TrimSpace(*req.Email))
+	}
+	if req.IsAdmin != nil {
+		params.IsAdmin = *req.IsAdmin
+	}
+
+	if params.Firstname == "" || params.Lastname == "" || params.Email == "" {
+		respondWithError(w, http.StatusBadRequest, "firstname, lastname, and email cannot be empty")
+		return
+	}
+	if !strings.Contains(params.Email, "@") {
+		respondWithError(w, http.StatusBadRequest, "email is invalid")
+		return
+	}
+
+	updated, err := config.DB.UpdateUser(ctx, params)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, "An account with this email already exists")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update user: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newUserResponse(updated))
+}
+
+// handleDeleteUser deletes a user account along with everything it owns
+func (config *Config) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	viewer, _ := userFromContext(ctx)
+
+	user, ok := config.loadUserParam(w, r)
+	if !ok {
+		return
+	}
+	if err := config.Policy.AuthorizeUser(viewer, user.ID, policy.ActionDelete); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
+
+	if err := config.DB.DeleteUser(ctx, user.ID); err != nil {
+		// Records such as the leagues a user created are not deleted with them
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("User cannot be deleted while %s still reference it", pqErr.Table))
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete user: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "User deleted successfully"})
+}
+
+// loadUserParam loads the user named by the {id} URL parameter, writing the
+// error response when it cannot
+func (config *Config) loadUserParam(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return database.User{}, false
+	}
+
+	user, err := config.DB.GetUser(r.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "User not found")
+			return database.User{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user: %v", err))
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// escapeLikePattern escapes LIKE wildcards so user input matches literally
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// handleSignup registers an email/password account and starts a session
+func (config *Config) handleSignup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if config.TokenIssuer == nil {
+		respondWithError(w, http.StatusNotImplemented, "Token issuing is not configured. Please set the JWT secret.")
+		return
+	}
+
+	var req SignupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if req.Firstname == "" || req.Lastname == "" || req.Email == "" || req.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "firstname, lastname, email, and password are required")
+		return
+	}
+	if !strings.Contains(req.Email, "@") {
+		respondWithError(w, http.StatusBadRequest, "email is invalid")
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error parsing JSON: %s", err))
+		if errors.Is(err, auth.ErrPasswordTooShort) || errors.Is(err, auth.ErrPasswordTooLong) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	user, err := config.DB.CreateUser(r.Context(), database.CreateUserParams{
-		Firstname: params.Firstname,
-		Lastname:  params.Lastname,
-		Email:     params.Email,
+	user, err := config.DB.CreateUserWithPassword(ctx, database.CreateUserWithPasswordParams{
+		Firstname:    req.Firstname,
+		Lastname:     req.Lastname,
+		Email:        req.Email,
+		PasswordHash: sql.NullString{String: passwordHash, Valid: true},
 	})
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error creating user: %s", err))
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			respondWithError(w, http.StatusConflict, "An account with this email already exists")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create user: %v", err))
 		return
 	}
-	respondWithJSON(w, http.StatusOK, user)
+
+	response, _, err := config.issueTokens(ctx, config.DB, user, uuid.New())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start session: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response)
 }
 
-func (config *Config) handleListAllUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := config.DB.ListUsers(r.Context())
+// handleLogin exchanges email/password credentials for a token pair
+func (config *Config) handleLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if config.TokenIssuer == nil {
+		respondWithError(w, http.StatusNotImplemented, "Token issuing is not configured. Please set the JWT secret.")
+		return
+	}
+
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Email == "" || req.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "email and password are required")
+		return
+	}
+
+	user, err := config.DB.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error listing users: %s", err))
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user: %v", err))
+		return
+	}
+
+	if !user.PasswordHash.Valid || !auth.CheckPassword(user.PasswordHash.String, req.Password) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+
+	response, _, err := config.issueTokens(ctx, config.DB, user, uuid.New())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start session: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handleRefresh rotates a refresh token. Presenting a token that was already
+// rotated revokes its whole family, since it means the token was stolen.
+func (config *Config) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if config.TokenIssuer == nil {
+		respondWithError(w, http.StatusNotImplemented, "Token issuing is not configured. Please set the JWT secret.")
+		return
+	}
+
+	var req RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		respondWithError(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	tx, err := config.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := config.DB.WithTx(tx)
+
+	stored, err := qtx.GetRefreshTokenByHashForUpdate(ctx, auth.HashToken(req.RefreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get refresh token: %v", err))
+		return
+	}
+
+	if stored.RevokedAt.Valid {
+		// Reuse of a rotated token: revoke every session descended from the same login
+		if err := qtx.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err == nil {
+			tx.Commit()
+		}
+		respondWithError(w, http.StatusUnauthorized, "Refresh token has been revoked")
+		return
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		respondWithError(w, http.StatusUnauthorized, "Refresh token has expired")
 		return
 	}
-	respondWithJSON(w, http.StatusOK, users)
+
+	user, err := qtx.GetUser(ctx, stored.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user: %v", err))
+		return
+	}
+
+	response, newTokenID, err := config.issueTokens(ctx, qtx, user, stored.FamilyID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to issue tokens: %v", err))
+		return
+	}
+
+	err = qtx.RevokeRefreshToken(ctx, database.RevokeRefreshTokenParams{
+		ID:         stored.ID,
+		ReplacedBy: uuid.NullUUID{UUID: newTokenID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to revoke refresh token: %v", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handleLogout revokes every refresh token in the presented token's family
+func (config *Config) handleLogout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		respondWithError(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	tx, err := config.DBConn.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start transaction: %v", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := config.DB.WithTx(tx)
+
+	stored, err := qtx.GetRefreshTokenByHashForUpdate(ctx, auth.HashToken(req.RefreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get refresh token: %v", err))
+		return
+	}
+
+	if err := qtx.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to revoke session: %v", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to commit transaction: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+}
+
+// issueTokens signs an access token and persists a new refresh token in the given family
+func (config *Config) issueTokens(ctx context.Context, db *database.Queries, user database.User, familyID uuid.UUID) (*AuthResponse, uuid.UUID, error) {
+	accessToken, expiresAt, err := config.TokenIssuer.IssueAccessToken(user.ID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	stored, err := db.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &AuthResponse{
+		User:         newUserResponse(user),
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresAt:    expiresAt,
+	}, stored.ID, nil
 }
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+)
+
+func TestUserResponseFor(t *testing.T) {
+	user := database.User{ID: 1, Firstname: "Ada", Lastname: "Lovelace", Email: "ada@example.com"}
+
+	t.Run("self sees every field", func(t *testing.T) {
+		if _, ok := userResponseFor(user, user).(UserResponse); !ok {
+			t.Error("Expected users to see their own full profile")
+		}
+	})
+
+	t.Run("admin sees every field", func(t *testing.T) {
+		admin := database.User{ID: 2, IsAdmin: true}
+		response, ok := userResponseFor(admin, user).(UserResponse)
+		if !ok {
+			t.Fatal("Expected admins to see the full profile")
+		}
+		if response.Email != user.Email {
+			t.Errorf("Expected email %s, got %s", user.Email, response.Email)
+		}
+	})
+
+	t.Run("others see public fields", func(t *testing.T) {
+		other := database.User{ID: 3}
+		if _, ok := userResponseFor(other, user).(PublicUserResponse); !ok {
+			t.Error("Expected other users to see the public profile only")
+		}
+	})
+}
+
+func TestEscapeLikePattern(t *testing.T) {
+	tests := map[string]string{
+		"ada":     "ada",
+		"50%":     `50\%`,
+		"a_b":     `a\_b`,
+		`back\sl`: `back\\sl`,
+	}
+	for input, want := range tests {
+		if got := escapeLikePattern(input); got != want {
+			t.Errorf("escapeLikePattern(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
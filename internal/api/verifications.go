@@ -5,12 +5,14 @@ import (
 	"net/http"
 
 	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/policy"
 	"github.com/google/uuid"
 )
 
 type VerificationService struct {
 	DB               *database.Queries
 	PlayerProfileSvc *PlayerProfileService
+	Policy           *policy.Engine
 }
 
 // Handler: Add verification
@@ -28,6 +30,11 @@ func (svc *VerificationService) AddVerification(w http.ResponseWriter, r *http.R
 		http.Error(w, "invalid profile id", http.StatusBadRequest)
 		return
 	}
+	currentUser, _ := userFromContext(r.Context())
+	if err := svc.Policy.AuthorizeVerification(currentUser, int32(req.VerifierUserID), policy.ActionCreate); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
 	params := database.CreateVerificationParams{
 		PlayerProfileID: profileID,
 		VerifierUserID:  int32(req.VerifierUserID),
@@ -56,6 +63,11 @@ func (svc *VerificationService) RemoveVerification(w http.ResponseWriter, r *htt
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	currentUser, _ := userFromContext(r.Context())
+	if err := svc.Policy.AuthorizeVerification(currentUser, verification.VerifierUserID, policy.ActionDelete); err != nil {
+		respondWithPolicyError(w, err)
+		return
+	}
 	if err := svc.DB.DeleteVerification(r.Context(), id); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1,23 +1,63 @@
 package auth
 
 import (
-	"errors"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
 	"net/http"
 	"strings"
 )
 
+// API key scopes granted to partner integrations
+const (
+	ScopeFutbolRead   = "futbol:read"
+	ScopeDebatesRead  = "debates:read"
+	ScopeDebatesWrite = "debates:write"
+)
+
+const (
+	apiKeyMarker       = "fk_"
+	apiKeyPrefixLength = 8
+)
+
+// Scopes lists every scope an API key may be granted
+var Scopes = []string{ScopeFutbolRead, ScopeDebatesRead, ScopeDebatesWrite}
+
+// ValidScope reports whether scope is a known API key scope
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAPIKey extracts the key from an "Authorization: ApiKey <key>" header
 func GetAPIKey(headers http.Header) (string, error) {
 	val := headers.Get("Authorization")
 	if val == "" {
-		return "", errors.New("no authentication info found")
+		return "", ErrNoAuthHeader
 	}
 
-	vals := strings.Split(val, "")
+	vals := strings.Fields(val)
 	if len(vals) != 2 {
-		return "", errors.New("malformed auth headers")
+		return "", ErrMalformedHeader
 	}
 	if vals[0] != "ApiKey" {
-		return "", errors.New("malformed first part of auth header")
+		return "", fmt.Errorf("%w: expected ApiKey scheme", ErrMalformedHeader)
 	}
 	return vals[1], nil
 }
+
+// NewAPIKey generates a plaintext API key, the short prefix shown to users
+// for identification, and the hash to persist.
+func NewAPIKey() (key string, prefix string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key = apiKeyMarker + base64.RawURLEncoding.EncodeToString(b)
+	prefix = key[:len(apiKeyMarker)+apiKeyPrefixLength]
+	return key, prefix, HashToken(key), nil
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr error
+	}{
+		{"missing header", "", "", ErrNoAuthHeader},
+		{"valid header", "ApiKey fk_abc123", "fk_abc123", nil},
+		{"wrong scheme", "Bearer fk_abc123", "", ErrMalformedHeader},
+		{"missing key", "ApiKey", "", ErrMalformedHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tt.header != "" {
+				headers.Set("Authorization", tt.header)
+			}
+			got, err := GetAPIKey(headers)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected key %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, hash, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("Failed to generate API key: %v", err)
+	}
+	if !strings.HasPrefix(key, "fk_") {
+		t.Errorf("Expected key to start with fk_, got %s", key)
+	}
+	if !strings.HasPrefix(key, prefix) {
+		t.Errorf("Expected prefix %s to be the start of the key", prefix)
+	}
+	if HashToken(key) != hash {
+		t.Error("Expected stored hash to match the hash of the key")
+	}
+}
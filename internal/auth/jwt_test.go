@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signHS256(t *testing.T, secret string, claims jwt.RegisteredClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestJWTValidatorHS256(t *testing.T) {
+	validator, err := NewJWTValidator(JWTConfig{Secret: "test-secret", Issuer: "fucci"})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		token := signHS256(t, "test-secret", jwt.RegisteredClaims{
+			Subject:   "42",
+			Issuer:    "fucci",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+
+		claims, err := validator.Validate(token)
+		if err != nil {
+			t.Fatalf("Expected token to be valid, got %v", err)
+		}
+		if claims.Subject != "42" {
+			t.Errorf("Expected subject '42', got %s", claims.Subject)
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		token := signHS256(t, "other-secret", jwt.RegisteredClaims{
+			Subject:   "42",
+			Issuer:    "fucci",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+		if _, err := validator.Validate(token); err == nil {
+			t.Error("Expected token signed with the wrong secret to be rejected")
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token := signHS256(t, "test-secret", jwt.RegisteredClaims{
+			Subject:   "42",
+			Issuer:    "fucci",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		})
+		if _, err := validator.Validate(token); err == nil {
+			t.Error("Expected expired token to be rejected")
+		}
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		token := signHS256(t, "test-secret", jwt.RegisteredClaims{
+			Subject:   "42",
+			Issuer:    "someone-else",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+		if _, err := validator.Validate(token); err == nil {
+			t.Error("Expected token with the wrong issuer to be rejected")
+		}
+	})
+}
+
+func TestJWTValidatorRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	doc := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	data, _ := json.Marshal(doc)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write jwks file: %v", err)
+	}
+
+	validator, err := NewJWTValidator(JWTConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Subject:   "7",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	claims, err := validator.Validate(signed)
+	if err != nil {
+		t.Fatalf("Expected token to be valid, got %v", err)
+	}
+	if claims.Subject != "7" {
+		t.Errorf("Expected subject '7', got %s", claims.Subject)
+	}
+
+	// HS256 tokens must be rejected when only a JWKS is configured
+	hsToken := signHS256(t, "anything", jwt.RegisteredClaims{
+		Subject:   "7",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	if _, err := validator.Validate(hsToken); err == nil {
+		t.Error("Expected HS256 token to be rejected by an RS256-only validator")
+	}
+}
+
+func TestGetBearerToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr error
+	}{
+		{"missing header", "", "", ErrNoAuthHeader},
+		{"valid header", "Bearer abc.def.ghi", "abc.def.ghi", nil},
+		{"wrong scheme", "Basic abc", "", ErrMalformedHeader},
+		{"missing token", "Bearer", "", ErrMalformedHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tt.header != "" {
+				headers.Set("Authorization", tt.header)
+			}
+			got, err := GetBearerToken(headers)
+			if err != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected token %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIssuedAccessTokenValidates(t *testing.T) {
+	issuer, err := NewTokenIssuer("test-secret", "fucci", "fucci-mobile")
+	if err != nil {
+		t.Fatalf("Failed to create issuer: %v", err)
+	}
+	validator, err := NewJWTValidator(JWTConfig{Secret: "test-secret", Issuer: "fucci", Audience: "fucci-mobile"})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	token, _, err := issuer.IssueAccessToken(12)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	claims, err := validator.Validate(token)
+	if err != nil {
+		t.Fatalf("Expected issued token to be valid, got %v", err)
+	}
+	if claims.Subject != "12" {
+		t.Errorf("Expected subject '12', got %s", claims.Subject)
+	}
+}
+
+func TestRefreshTokenHash(t *testing.T) {
+	token, hash, err := NewRefreshToken()
+	if err != nil {
+		t.Fatalf("Failed to generate refresh token: %v", err)
+	}
+	if HashToken(token) != hash {
+		t.Error("Expected stored hash to match the hash of the token")
+	}
+
+	other, _, _ := NewRefreshToken()
+	if other == token {
+		t.Error("Expected refresh tokens to be unique")
+	}
+}
+
+func TestPasswordHashing(t *testing.T) {
+	if _, err := HashPassword("short"); err != ErrPasswordTooShort {
+		t.Errorf("Expected ErrPasswordTooShort, got %v", err)
+	}
+	if _, err := HashPassword(strings.Repeat("a", MaxPasswordLength+1)); err != ErrPasswordTooLong {
+		t.Errorf("Expected ErrPasswordTooLong, got %v", err)
+	}
+
+	hash, err := HashPassword("correct horse battery")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	if !CheckPassword(hash, "correct horse battery") {
+		t.Error("Expected password to match its hash")
+	}
+	if CheckPassword(hash, "wrong password") {
+		t.Error("Expected wrong password not to match")
+	}
+}
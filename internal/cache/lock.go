@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Locker hands out named, expiring locks so that only one instance does a
+// piece of shared work at a time
+type Locker interface {
+	// AcquireLock takes the lock for owner, or extends it when owner already
+	// holds it, reporting whether owner holds it for the next ttl
+	AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLock gives up owner's hold on the lock. It does nothing when
+	// someone else holds it.
+	ReleaseLock(ctx context.Context, key, owner string) error
+}
+
+// acquireLockScript extends the lock when ARGV[1] already holds it and
+// otherwise takes it if it is free. It returns 1 when ARGV[1] holds it.
+var acquireLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseLockScript deletes the lock only if ARGV[1] holds it
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// AcquireLock implements Locker using a Redis key holding the owner
+func (c *Cache) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	held, err := acquireLockScript.Run(ctx, c.client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock %s: %v", key, err)
+	}
+	return held == 1, nil
+}
+
+// ReleaseLock implements Locker
+func (c *Cache) ReleaseLock(ctx context.Context, key, owner string) error {
+	if err := releaseLockScript.Run(ctx, c.client, []string{key}, owner).Err(); err != nil {
+		return fmt.Errorf("failed to release lock %s: %v", key, err)
+	}
+	return nil
+}
+
+// MemoryLocker is a process-local Locker. Locks only exclude owners on the
+// same instance, so it is only meant as a fallback when Redis is
+// unavailable.
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+}
+
+type memoryLock struct {
+	owner   string
+	expires time.Time
+}
+
+// NewMemoryLocker creates an in-memory Locker with no locks held
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: make(map[string]memoryLock)}
+}
+
+// AcquireLock implements Locker
+func (m *MemoryLocker) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if lock, ok := m.locks[key]; ok && lock.owner != owner && now.Before(lock.expires) {
+		return false, nil
+	}
+	m.locks[key] = memoryLock{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLock implements Locker
+func (m *MemoryLocker) ReleaseLock(ctx context.Context, key, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lock, ok := m.locks[key]; ok && lock.owner == owner {
+		delete(m.locks, key)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLocker(t *testing.T) {
+	locker := NewMemoryLocker()
+	ctx := context.Background()
+
+	if held, _ := locker.AcquireLock(ctx, "poll:1", "a", time.Minute); !held {
+		t.Fatal("Expected a to take the free lock")
+	}
+	if held, _ := locker.AcquireLock(ctx, "poll:1", "b", time.Minute); held {
+		t.Error("Expected b to be refused while a holds the lock")
+	}
+	if held, _ := locker.AcquireLock(ctx, "poll:1", "a", time.Minute); !held {
+		t.Error("Expected a to extend its own lock")
+	}
+
+	// Releasing someone else's lock does nothing
+	locker.ReleaseLock(ctx, "poll:1", "b")
+	if held, _ := locker.AcquireLock(ctx, "poll:1", "b", time.Minute); held {
+		t.Error("Expected the lock to survive a release by b")
+	}
+
+	locker.ReleaseLock(ctx, "poll:1", "a")
+	if held, _ := locker.AcquireLock(ctx, "poll:1", "b", time.Millisecond); !held {
+		t.Fatal("Expected b to take the released lock")
+	}
+
+	// An expired lock is free again
+	time.Sleep(5 * time.Millisecond)
+	if held, _ := locker.AcquireLock(ctx, "poll:1", "a", time.Minute); !held {
+		t.Error("Expected a to take the expired lock")
+	}
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// subscriptionBuffer is how many messages a subscriber may fall behind by
+// before further messages are dropped for it
+const subscriptionBuffer = 64
+
+// PubSub delivers each published message to every current subscriber of its
+// channel
+type PubSub interface {
+	Publish(ctx context.Context, channel string, message []byte) error
+	Subscribe(ctx context.Context, channel string) (Subscription, error)
+}
+
+// Subscription receives a channel's messages until it is closed, which also
+// closes Messages
+type Subscription interface {
+	Messages() <-chan []byte
+	Close() error
+}
+
+// Publish implements PubSub using Redis, reaching subscribers on every
+// instance
+func (c *Cache) Publish(ctx context.Context, channel string, message []byte) error {
+	if err := c.client.Publish(ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %v", channel, err)
+	}
+	return nil
+}
+
+// Subscribe implements PubSub using Redis. It returns once Redis has
+// confirmed the subscription, so no later publish is missed.
+func (c *Cache) Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	pubsub := c.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %v", channel, err)
+	}
+
+	messages := make(chan []byte, subscriptionBuffer)
+	go func() {
+		defer close(messages)
+		for msg := range pubsub.Channel() {
+			select {
+			case messages <- []byte(msg.Payload):
+			default:
+			}
+		}
+	}()
+
+	return &redisSubscription{pubsub: pubsub, messages: messages}, nil
+}
+
+type redisSubscription struct {
+	pubsub   *redis.PubSub
+	messages chan []byte
+}
+
+func (s *redisSubscription) Messages() <-chan []byte {
+	return s.messages
+}
+
+func (s *redisSubscription) Close() error {
+	return s.pubsub.Close()
+}
+
+// MemoryPubSub is a process-local PubSub. Messages only reach subscribers on
+// the same instance, so it is only meant as a fallback when Redis is
+// unavailable.
+type MemoryPubSub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*memorySubscription]struct{}
+}
+
+// NewMemoryPubSub creates an in-memory PubSub with no subscribers
+func NewMemoryPubSub() *MemoryPubSub {
+	return &MemoryPubSub{subscribers: make(map[string]map[*memorySubscription]struct{})}
+}
+
+// Publish implements PubSub. Subscribers that have fallen too far behind miss
+// the message rather than blocking the publisher.
+func (m *MemoryPubSub) Publish(ctx context.Context, channel string, message []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for sub := range m.subscribers[channel] {
+		select {
+		case sub.messages <- message:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe implements PubSub
+func (m *MemoryPubSub) Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub := &memorySubscription{
+		pubsub:   m,
+		channel:  channel,
+		messages: make(chan []byte, subscriptionBuffer),
+	}
+	if m.subscribers[channel] == nil {
+		m.subscribers[channel] = make(map[*memorySubscription]struct{})
+	}
+	m.subscribers[channel][sub] = struct{}{}
+	return sub, nil
+}
+
+type memorySubscription struct {
+	pubsub   *MemoryPubSub
+	channel  string
+	messages chan []byte
+	closed   bool
+}
+
+func (s *memorySubscription) Messages() <-chan []byte {
+	return s.messages
+}
+
+func (s *memorySubscription) Close() error {
+	s.pubsub.mu.Lock()
+	defer s.pubsub.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	delete(s.pubsub.subscribers[s.channel], s)
+	if len(s.pubsub.subscribers[s.channel]) == 0 {
+		delete(s.pubsub.subscribers, s.channel)
+	}
+	close(s.messages)
+	return nil
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryPubSub(t *testing.T) {
+	pubsub := NewMemoryPubSub()
+	ctx := context.Background()
+
+	sub, err := pubsub.Subscribe(ctx, "debate:1:events")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	other, _ := pubsub.Subscribe(ctx, "debate:2:events")
+
+	if err := pubsub.Publish(ctx, "debate:1:events", []byte("hello")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	select {
+	case message := <-sub.Messages():
+		if string(message) != "hello" {
+			t.Errorf("Expected message hello, got %s", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the subscriber to receive the message")
+	}
+
+	select {
+	case message := <-other.Messages():
+		t.Errorf("Expected no message on another channel, got %s", message)
+	default:
+	}
+
+	// Closing twice is harmless and stops delivery
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.Messages(); ok {
+		t.Error("Expected Messages to be closed")
+	}
+	if err := pubsub.Publish(ctx, "debate:1:events", []byte("again")); err != nil {
+		t.Errorf("Expected publishing without subscribers to succeed, got %v", err)
+	}
+}
+
+func TestMemoryPubSubSlowSubscriber(t *testing.T) {
+	pubsub := NewMemoryPubSub()
+	ctx := context.Background()
+
+	sub, _ := pubsub.Subscribe(ctx, "events")
+	defer sub.Close()
+
+	// Publishing never blocks on a subscriber that is not reading
+	for i := 0; i < subscriptionBuffer+10; i++ {
+		pubsub.Publish(ctx, "events", []byte("message"))
+	}
+	if len(sub.Messages()) != subscriptionBuffer {
+		t.Errorf("Expected %d buffered messages, got %d", subscriptionBuffer, len(sub.Messages()))
+	}
+}
@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitResult describes the outcome of a rate limit check
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // Until the oldest request in the window expires
+}
+
+// RateLimiter enforces a sliding window of at most limit requests per window
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// slidingWindowScript records a request in a sorted set scored by time in
+// milliseconds, after dropping entries that fell out of the window. It returns
+// {allowed, remaining, reset_after_ms}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	count = count + 1
+	allowed = 1
+end
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+// Allow implements RateLimiter using a Redis sorted set per key
+func (c *Cache) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	now := time.Now().UnixMilli()
+	member := fmt.Sprintf("%d-%d", now, rand.Int63())
+
+	res, err := slidingWindowScript.Run(ctx, c.client, []string{key}, now, window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to run rate limit script: %v", err)
+	}
+	if len(res) != 3 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+
+	return RateLimitResult{
+		Allowed:    res[0] == 1,
+		Limit:      limit,
+		Remaining:  int(res[1]),
+		ResetAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
+
+// MemoryRateLimiter is a process-local RateLimiter. Limits are per instance,
+// so it is only meant as a fallback when Redis is unavailable.
+type MemoryRateLimiter struct {
+	mu       sync.Mutex
+	requests map[string]*memoryWindow
+	calls    int
+}
+
+// memoryWindow is the requests made under one key inside its window
+type memoryWindow struct {
+	times  []time.Time
+	window time.Duration
+}
+
+// NewMemoryRateLimiter creates an empty in-memory rate limiter
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{requests: make(map[string]*memoryWindow)}
+}
+
+// Allow implements RateLimiter
+func (m *MemoryRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.calls++
+	if m.calls%1000 == 0 {
+		m.sweep(now)
+	}
+
+	entry, ok := m.requests[key]
+	if !ok {
+		entry = &memoryWindow{}
+		m.requests[key] = entry
+	}
+	entry.window = window
+	entry.times = prune(entry.times, now.Add(-window))
+	allowed := len(entry.times) < limit
+	if allowed {
+		entry.times = append(entry.times, now)
+	}
+
+	resetAfter := window
+	if len(entry.times) > 0 {
+		resetAfter = entry.times[0].Add(window).Sub(now)
+	}
+
+	return RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  limit - len(entry.times),
+		ResetAfter: resetAfter,
+	}, nil
+}
+
+// sweep drops keys with no requests left inside their own window so idle
+// clients do not accumulate
+func (m *MemoryRateLimiter) sweep(now time.Time) {
+	for key, entry := range m.requests {
+		if len(prune(entry.times, now.Add(-entry.window))) == 0 {
+			delete(m.requests, key)
+		}
+	}
+}
+
+// prune drops the timestamps at or before cutoff from a sorted slice
+func prune(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// FallbackRateLimiter uses Primary and switches to Fallback when Primary
+// errors, retrying Primary after a cooldown so a Redis outage does not add a
+// connection timeout to every request.
+type FallbackRateLimiter struct {
+	Primary  RateLimiter
+	Fallback RateLimiter
+	Cooldown time.Duration
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+// NewFallbackRateLimiter creates a limiter that prefers primary
+func NewFallbackRateLimiter(primary, fallback RateLimiter) *FallbackRateLimiter {
+	return &FallbackRateLimiter{Primary: primary, Fallback: fallback, Cooldown: 30 * time.Second}
+}
+
+// Allow implements RateLimiter
+func (f *FallbackRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	f.mu.Lock()
+	primaryDown := time.Now().Before(f.downUntil)
+	f.mu.Unlock()
+
+	if !primaryDown {
+		result, err := f.Primary.Allow(ctx, key, limit, window)
+		if err == nil {
+			return result, nil
+		}
+		log.Printf("Rate limiter falling back to memory: %v", err)
+		f.mu.Lock()
+		f.downUntil = time.Now().Add(f.Cooldown)
+		f.mu.Unlock()
+	}
+
+	return f.Fallback.Allow(ctx, key, limit, window)
+}
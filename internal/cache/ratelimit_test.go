@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimiter(t *testing.T) {
+	limiter := NewMemoryRateLimiter()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(ctx, "client", 3, time.Minute)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+		if result.Remaining != 2-i {
+			t.Errorf("Expected %d remaining, got %d", 2-i, result.Remaining)
+		}
+	}
+
+	result, _ := limiter.Allow(ctx, "client", 3, time.Minute)
+	if result.Allowed {
+		t.Error("Expected fourth request to be rejected")
+	}
+	if result.ResetAfter <= 0 || result.ResetAfter > time.Minute {
+		t.Errorf("Expected reset within the window, got %v", result.ResetAfter)
+	}
+
+	// Other keys have their own budget
+	if result, _ := limiter.Allow(ctx, "other", 3, time.Minute); !result.Allowed {
+		t.Error("Expected a different key to be allowed")
+	}
+}
+
+func TestMemoryRateLimiterWindowSlides(t *testing.T) {
+	limiter := NewMemoryRateLimiter()
+	ctx := context.Background()
+
+	limiter.Allow(ctx, "client", 1, 20*time.Millisecond)
+	if result, _ := limiter.Allow(ctx, "client", 1, 20*time.Millisecond); result.Allowed {
+		t.Fatal("Expected second request inside the window to be rejected")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if result, _ := limiter.Allow(ctx, "client", 1, 20*time.Millisecond); !result.Allowed {
+		t.Error("Expected request after the window to be allowed")
+	}
+}
+
+func TestMemoryRateLimiterSweepKeepsLongerWindows(t *testing.T) {
+	limiter := NewMemoryRateLimiter()
+	ctx := context.Background()
+
+	limiter.Allow(ctx, "hourly", 1, time.Hour)
+	limiter.Allow(ctx, "minutely", 1, time.Minute)
+
+	// Two minutes on, only the minute window has emptied
+	limiter.sweep(time.Now().Add(2 * time.Minute))
+	if _, ok := limiter.requests["minutely"]; ok {
+		t.Error("Expected the idle minute window to be swept")
+	}
+	if result, _ := limiter.Allow(ctx, "hourly", 1, time.Hour); result.Allowed {
+		t.Error("Expected the hour window to survive a sweep and keep rejecting")
+	}
+}
+
+type failingRateLimiter struct {
+	calls int
+}
+
+func (f *failingRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	f.calls++
+	return RateLimitResult{}, errors.New("redis unavailable")
+}
+
+func TestFallbackRateLimiter(t *testing.T) {
+	primary := &failingRateLimiter{}
+	limiter := NewFallbackRateLimiter(primary, NewMemoryRateLimiter())
+	ctx := context.Background()
+
+	result, err := limiter.Allow(ctx, "client", 1, time.Minute)
+	if err != nil {
+		t.Fatalf("Expected fallback to absorb the error, got %v", err)
+	}
+	if !result.Allowed {
+		t.Error("Expected first request to be allowed by the fallback")
+	}
+
+	result, _ = limiter.Allow(ctx, "client", 1, time.Minute)
+	if result.Allowed {
+		t.Error("Expected fallback to enforce the limit")
+	}
+	if primary.calls != 1 {
+		t.Errorf("Expected primary to be skipped during the cooldown, got %d calls", primary.calls)
+	}
+}
@@ -22,6 +22,11 @@ func InitConfig(logger *otelzap.Logger) Config {
 	viper.SetDefault("db_url", "")
 	viper.SetDefault("redis_url", "")
 	viper.SetDefault("openai_base_url", "https://api.openai.com/v1")
+	viper.SetDefault("llm_provider", "openai")
+	viper.SetDefault("llm_structured_output", "json_mode")
+	viper.SetDefault("report_hide_threshold", 5)
+	viper.SetDefault("live_match_poll_seconds", 15)
+	viper.SetDefault("pre_match_debate_hours", 6)
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -32,11 +37,30 @@ func InitConfig(logger *otelzap.Logger) Config {
 	}
 
 	return Config{
-		DB_URL:           viper.GetString("db_url"),
-		FOOTBALL_API_KEY: viper.GetString("football_api_key"),
-		RAPID_API_KEY:    viper.GetString("rapid_api_key"),
-		REDIS_URL:        viper.GetString("redis_url"),
-		OPENAI_API_KEY:   viper.GetString("openai_api_key"),
-		OPENAI_BASE_URL:  viper.GetString("openai_base_url"),
+		DB_URL:                   viper.GetString("db_url"),
+		FOOTBALL_API_KEY:         viper.GetString("football_api_key"),
+		RAPID_API_KEY:            viper.GetString("rapid_api_key"),
+		REDIS_URL:                viper.GetString("redis_url"),
+		OPENAI_API_KEY:           viper.GetString("openai_api_key"),
+		OPENAI_BASE_URL:          viper.GetString("openai_base_url"),
+		ANTHROPIC_API_KEY:        viper.GetString("anthropic_api_key"),
+		ANTHROPIC_BASE_URL:       viper.GetString("anthropic_base_url"),
+		OLLAMA_BASE_URL:          viper.GetString("ollama_base_url"),
+		LLM_PROVIDER:             viper.GetString("llm_provider"),
+		LLM_MODEL:                viper.GetString("llm_model"),
+		LLM_STRUCTURED_OUTPUT:    viper.GetString("llm_structured_output"),
+		LLM_MODEL_PRICES:         viper.GetString("llm_model_prices"),
+		LLM_MONTHLY_BUDGET_USD:   viper.GetFloat64("llm_monthly_budget_usd"),
+		JWT_SECRET:               viper.GetString("jwt_secret"),
+		JWT_JWKS_FILE:            viper.GetString("jwt_jwks_file"),
+		JWT_ISSUER:               viper.GetString("jwt_issuer"),
+		JWT_AUDIENCE:             viper.GetString("jwt_audience"),
+		TRUSTED_PROXIES:          viper.GetString("trusted_proxies"),
+		REPORT_HIDE_THRESHOLD:    viper.GetInt("report_hide_threshold"),
+		MODERATION_WORDLIST_FILE: viper.GetString("moderation_wordlist_file"),
+		MODERATION_LLM_ENABLED:   viper.GetBool("moderation_llm_enabled"),
+		LIVE_MATCH_POLL_SECONDS:  viper.GetInt("live_match_poll_seconds"),
+		DEBATE_SCHEDULER_LEAGUES: viper.GetString("debate_scheduler_leagues"),
+		PRE_MATCH_DEBATE_HOURS:   viper.GetInt("pre_match_debate_hours"),
 	}
 }
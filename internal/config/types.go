@@ -1,10 +1,29 @@
 package config
 
 type Config struct {
-	DB_URL           string
-	FOOTBALL_API_KEY string
-	RAPID_API_KEY    string
-	REDIS_URL        string
-	OPENAI_API_KEY   string
-	OPENAI_BASE_URL  string
+	DB_URL                   string
+	FOOTBALL_API_KEY         string
+	RAPID_API_KEY            string
+	REDIS_URL                string
+	OPENAI_API_KEY           string
+	OPENAI_BASE_URL          string
+	ANTHROPIC_API_KEY        string
+	ANTHROPIC_BASE_URL       string
+	OLLAMA_BASE_URL          string
+	LLM_PROVIDER             string
+	LLM_MODEL                string
+	LLM_STRUCTURED_OUTPUT    string
+	LLM_MODEL_PRICES         string
+	LLM_MONTHLY_BUDGET_USD   float64
+	JWT_SECRET               string
+	JWT_JWKS_FILE            string
+	JWT_ISSUER               string
+	JWT_AUDIENCE             string
+	TRUSTED_PROXIES          string
+	REPORT_HIDE_THRESHOLD    int
+	MODERATION_WORDLIST_FILE string
+	MODERATION_LLM_ENABLED   bool
+	LIVE_MATCH_POLL_SECONDS  int
+	DEBATE_SCHEDULER_LEAGUES string
+	PRE_MATCH_DEBATE_HOURS   int
 }
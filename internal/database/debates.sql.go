@@ -8,21 +8,52 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"github.com/lib/pq"
 )
 
+const castDirectionalVote = `-- name: CastDirectionalVote :one
+INSERT INTO votes (debate_card_id, user_id, vote_type)
+VALUES ($1, $2, $3)
+ON CONFLICT (debate_card_id, user_id) WHERE vote_type IN ('upvote', 'downvote')
+DO UPDATE SET vote_type = EXCLUDED.vote_type, created_at = CURRENT_TIMESTAMP
+RETURNING id, debate_card_id, user_id, vote_type, emoji, created_at
+`
+
+type CastDirectionalVoteParams struct {
+	DebateCardID sql.NullInt32
+	UserID       sql.NullInt32
+	VoteType     string
+}
+
+func (q *Queries) CastDirectionalVote(ctx context.Context, arg CastDirectionalVoteParams) (Vote, error) {
+	row := q.db.QueryRowContext(ctx, castDirectionalVote, arg.DebateCardID, arg.UserID, arg.VoteType)
+	var i Vote
+	err := row.Scan(
+		&i.ID,
+		&i.DebateCardID,
+		&i.UserID,
+		&i.VoteType,
+		&i.Emoji,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createComment = `-- name: CreateComment :one
-INSERT INTO comments (debate_id, parent_comment_id, user_id, content)
-VALUES ($1, $2, $3, $4)
-RETURNING id, debate_id, parent_comment_id, user_id, content, created_at, updated_at
+INSERT INTO comments (debate_id, parent_comment_id, user_id, content, moderation_status, moderation_reason, hidden_at)
+VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $5 = 'held' THEN CURRENT_TIMESTAMP END)
+RETURNING id, debate_id, parent_comment_id, user_id, content, created_at, updated_at, deleted_at, deleted_by, hidden_at, moderation_status, moderation_reason
 `
 
 type CreateCommentParams struct {
-	DebateID        sql.NullInt32
-	ParentCommentID sql.NullInt32
-	UserID          sql.NullInt32
-	Content         string
+	DebateID         sql.NullInt32
+	ParentCommentID  sql.NullInt32
+	UserID           sql.NullInt32
+	Content          string
+	ModerationStatus string
+	ModerationReason sql.NullString
 }
 
 func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error) {
@@ -31,6 +62,8 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (C
 		arg.ParentCommentID,
 		arg.UserID,
 		arg.Content,
+		arg.ModerationStatus,
+		arg.ModerationReason,
 	)
 	var i Comment
 	err := row.Scan(
@@ -41,22 +74,31 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (C
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.HiddenAt,
+		&i.ModerationStatus,
+		&i.ModerationReason,
 	)
 	return i, err
 }
 
 const createDebate = `-- name: CreateDebate :one
-INSERT INTO debates (match_id, debate_type, headline, description, ai_generated)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at
+INSERT INTO debates (match_id, debate_type, headline, description, ai_generated, prompt_template_version, experiment_variant_id, locale, canonical_debate_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at, hidden_at, prompt_template_version, experiment_variant_id, locale, canonical_debate_id
 `
 
 type CreateDebateParams struct {
-	MatchID     string
-	DebateType  string
-	Headline    string
-	Description sql.NullString
-	AiGenerated sql.NullBool
+	MatchID               string
+	DebateType            string
+	Headline              string
+	Description           sql.NullString
+	AiGenerated           sql.NullBool
+	PromptTemplateVersion sql.NullInt32
+	ExperimentVariantID   sql.NullInt32
+	Locale                string
+	CanonicalDebateID     sql.NullInt32
 }
 
 func (q *Queries) CreateDebate(ctx context.Context, arg CreateDebateParams) (Debate, error) {
@@ -66,6 +108,10 @@ func (q *Queries) CreateDebate(ctx context.Context, arg CreateDebateParams) (Deb
 		arg.Headline,
 		arg.Description,
 		arg.AiGenerated,
+		arg.PromptTemplateVersion,
+		arg.ExperimentVariantID,
+		arg.Locale,
+		arg.CanonicalDebateID,
 	)
 	var i Debate
 	err := row.Scan(
@@ -78,6 +124,11 @@ func (q *Queries) CreateDebate(ctx context.Context, arg CreateDebateParams) (Deb
 		&i.DeletedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.PromptTemplateVersion,
+		&i.ExperimentVariantID,
+		&i.Locale,
+		&i.CanonicalDebateID,
 	)
 	return i, err
 }
@@ -122,17 +173,18 @@ func (q *Queries) CreateDebateAnalytics(ctx context.Context, arg CreateDebateAna
 }
 
 const createDebateCard = `-- name: CreateDebateCard :one
-INSERT INTO debate_cards (debate_id, stance, title, description, ai_generated)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, debate_id, stance, title, description, ai_generated, created_at, updated_at
+INSERT INTO debate_cards (debate_id, stance, title, description, ai_generated, canonical_card_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, debate_id, stance, title, description, ai_generated, created_at, updated_at, hidden_at, canonical_card_id
 `
 
 type CreateDebateCardParams struct {
-	DebateID    sql.NullInt32
-	Stance      string
-	Title       string
-	Description sql.NullString
-	AiGenerated sql.NullBool
+	DebateID        sql.NullInt32
+	Stance          string
+	Title           string
+	Description     sql.NullString
+	AiGenerated     sql.NullBool
+	CanonicalCardID sql.NullInt32
 }
 
 func (q *Queries) CreateDebateCard(ctx context.Context, arg CreateDebateCardParams) (DebateCard, error) {
@@ -142,6 +194,7 @@ func (q *Queries) CreateDebateCard(ctx context.Context, arg CreateDebateCardPara
 		arg.Title,
 		arg.Description,
 		arg.AiGenerated,
+		arg.CanonicalCardID,
 	)
 	var i DebateCard
 	err := row.Scan(
@@ -153,10 +206,26 @@ func (q *Queries) CreateDebateCard(ctx context.Context, arg CreateDebateCardPara
 		&i.AiGenerated,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.CanonicalCardID,
 	)
 	return i, err
 }
 
+const createDebateMatchData = `-- name: CreateDebateMatchData :exec
+INSERT INTO debate_match_data (debate_id, match_data) VALUES ($1, $2)
+`
+
+type CreateDebateMatchDataParams struct {
+	DebateID  int32
+	MatchData json.RawMessage
+}
+
+func (q *Queries) CreateDebateMatchData(ctx context.Context, arg CreateDebateMatchDataParams) error {
+	_, err := q.db.ExecContext(ctx, createDebateMatchData, arg.DebateID, arg.MatchData)
+	return err
+}
+
 const createVote = `-- name: CreateVote :one
 INSERT INTO votes (debate_card_id, user_id, vote_type, emoji)
 VALUES ($1, $2, $3, $4)
@@ -218,6 +287,23 @@ func (q *Queries) DeleteDebateCard(ctx context.Context, id int32) error {
 	return err
 }
 
+const deleteDirectionalVote = `-- name: DeleteDirectionalVote :execrows
+DELETE FROM votes WHERE debate_card_id = $1 AND user_id = $2 AND vote_type IN ('upvote', 'downvote')
+`
+
+type DeleteDirectionalVoteParams struct {
+	DebateCardID sql.NullInt32
+	UserID       sql.NullInt32
+}
+
+func (q *Queries) DeleteDirectionalVote(ctx context.Context, arg DeleteDirectionalVoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDirectionalVote, arg.DebateCardID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteVote = `-- name: DeleteVote :exec
 DELETE FROM votes WHERE debate_card_id = $1 AND user_id = $2 AND vote_type = $3
 `
@@ -235,7 +321,7 @@ func (q *Queries) DeleteVote(ctx context.Context, arg DeleteVoteParams) error {
 
 const getComment = `-- name: GetComment :one
 SELECT 
-    c.id, c.debate_id, c.parent_comment_id, c.user_id, c.content, c.created_at, c.updated_at,
+    c.id, c.debate_id, c.parent_comment_id, c.user_id, c.content, c.created_at, c.updated_at, c.deleted_at, c.deleted_by, c.hidden_at, c.moderation_status, c.moderation_reason,
     u.firstname,
     u.lastname
 FROM comments c
@@ -244,15 +330,20 @@ WHERE c.id = $1
 `
 
 type GetCommentRow struct {
-	ID              int32
-	DebateID        sql.NullInt32
-	ParentCommentID sql.NullInt32
-	UserID          sql.NullInt32
-	Content         string
-	CreatedAt       sql.NullTime
-	UpdatedAt       sql.NullTime
-	Firstname       string
-	Lastname        string
+	ID               int32
+	DebateID         sql.NullInt32
+	ParentCommentID  sql.NullInt32
+	UserID           sql.NullInt32
+	Content          string
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	DeletedAt        sql.NullTime
+	DeletedBy        sql.NullInt32
+	HiddenAt         sql.NullTime
+	ModerationStatus string
+	ModerationReason sql.NullString
+	Firstname        string
+	Lastname         string
 }
 
 func (q *Queries) GetComment(ctx context.Context, id int32) (GetCommentRow, error) {
@@ -266,6 +357,11 @@ func (q *Queries) GetComment(ctx context.Context, id int32) (GetCommentRow, erro
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.HiddenAt,
+		&i.ModerationStatus,
+		&i.ModerationReason,
 		&i.Firstname,
 		&i.Lastname,
 	)
@@ -273,7 +369,7 @@ func (q *Queries) GetComment(ctx context.Context, id int32) (GetCommentRow, erro
 }
 
 const getCommentCount = `-- name: GetCommentCount :one
-SELECT COUNT(*) FROM comments WHERE debate_id = $1
+SELECT COUNT(*) FROM comments WHERE debate_id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetCommentCount(ctx context.Context, debateID sql.NullInt32) (int64, error) {
@@ -285,7 +381,7 @@ func (q *Queries) GetCommentCount(ctx context.Context, debateID sql.NullInt32) (
 
 const getComments = `-- name: GetComments :many
 SELECT 
-    c.id, c.debate_id, c.parent_comment_id, c.user_id, c.content, c.created_at, c.updated_at,
+    c.id, c.debate_id, c.parent_comment_id, c.user_id, c.content, c.created_at, c.updated_at, c.deleted_at, c.deleted_by, c.hidden_at, c.moderation_status, c.moderation_reason,
     u.firstname,
     u.lastname
 FROM comments c
@@ -295,15 +391,20 @@ ORDER BY c.created_at ASC
 `
 
 type GetCommentsRow struct {
-	ID              int32
-	DebateID        sql.NullInt32
-	ParentCommentID sql.NullInt32
-	UserID          sql.NullInt32
-	Content         string
-	CreatedAt       sql.NullTime
-	UpdatedAt       sql.NullTime
-	Firstname       string
-	Lastname        string
+	ID               int32
+	DebateID         sql.NullInt32
+	ParentCommentID  sql.NullInt32
+	UserID           sql.NullInt32
+	Content          string
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	DeletedAt        sql.NullTime
+	DeletedBy        sql.NullInt32
+	HiddenAt         sql.NullTime
+	ModerationStatus string
+	ModerationReason sql.NullString
+	Firstname        string
+	Lastname         string
 }
 
 func (q *Queries) GetComments(ctx context.Context, debateID sql.NullInt32) ([]GetCommentsRow, error) {
@@ -323,6 +424,11 @@ func (q *Queries) GetComments(ctx context.Context, debateID sql.NullInt32) ([]Ge
 			&i.Content,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.HiddenAt,
+			&i.ModerationStatus,
+			&i.ModerationReason,
 			&i.Firstname,
 			&i.Lastname,
 		); err != nil {
@@ -340,7 +446,7 @@ func (q *Queries) GetComments(ctx context.Context, debateID sql.NullInt32) ([]Ge
 }
 
 const getDebate = `-- name: GetDebate :one
-SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at FROM debates WHERE id = $1 AND deleted_at IS NULL
+SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at, hidden_at, prompt_template_version, experiment_variant_id, locale, canonical_debate_id FROM debates WHERE id = $1 AND deleted_at IS NULL AND hidden_at IS NULL
 `
 
 func (q *Queries) GetDebate(ctx context.Context, id int32) (Debate, error) {
@@ -356,6 +462,11 @@ func (q *Queries) GetDebate(ctx context.Context, id int32) (Debate, error) {
 		&i.DeletedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.PromptTemplateVersion,
+		&i.ExperimentVariantID,
+		&i.Locale,
+		&i.CanonicalDebateID,
 	)
 	return i, err
 }
@@ -380,7 +491,7 @@ func (q *Queries) GetDebateAnalytics(ctx context.Context, debateID sql.NullInt32
 }
 
 const getDebateCard = `-- name: GetDebateCard :one
-SELECT id, debate_id, stance, title, description, ai_generated, created_at, updated_at FROM debate_cards WHERE id = $1
+SELECT id, debate_id, stance, title, description, ai_generated, created_at, updated_at, hidden_at, canonical_card_id FROM debate_cards WHERE id = $1
 `
 
 func (q *Queries) GetDebateCard(ctx context.Context, id int32) (DebateCard, error) {
@@ -395,12 +506,14 @@ func (q *Queries) GetDebateCard(ctx context.Context, id int32) (DebateCard, erro
 		&i.AiGenerated,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.CanonicalCardID,
 	)
 	return i, err
 }
 
 const getDebateCards = `-- name: GetDebateCards :many
-SELECT id, debate_id, stance, title, description, ai_generated, created_at, updated_at FROM debate_cards WHERE debate_id = $1 ORDER BY stance
+SELECT id, debate_id, stance, title, description, ai_generated, created_at, updated_at, hidden_at, canonical_card_id FROM debate_cards WHERE debate_id = $1 AND hidden_at IS NULL ORDER BY stance
 `
 
 func (q *Queries) GetDebateCards(ctx context.Context, debateID sql.NullInt32) ([]DebateCard, error) {
@@ -421,6 +534,140 @@ func (q *Queries) GetDebateCards(ctx context.Context, debateID sql.NullInt32) ([
 			&i.AiGenerated,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.CanonicalCardID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDebateFamily = `-- name: GetDebateFamily :many
+SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at, hidden_at, prompt_template_version, experiment_variant_id, locale, canonical_debate_id FROM debates
+WHERE (id = $1 OR canonical_debate_id = $1) AND deleted_at IS NULL AND hidden_at IS NULL
+ORDER BY id
+`
+
+// A canonical debate and its translations
+func (q *Queries) GetDebateFamily(ctx context.Context, id int32) ([]Debate, error) {
+	rows, err := q.db.QueryContext(ctx, getDebateFamily, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Debate
+	for rows.Next() {
+		var i Debate
+		if err := rows.Scan(
+			&i.ID,
+			&i.MatchID,
+			&i.DebateType,
+			&i.Headline,
+			&i.Description,
+			&i.AiGenerated,
+			&i.DeletedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.PromptTemplateVersion,
+			&i.ExperimentVariantID,
+			&i.Locale,
+			&i.CanonicalDebateID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDebateIncludingHidden = `-- name: GetDebateIncludingHidden :one
+SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at, hidden_at, prompt_template_version, experiment_variant_id, locale, canonical_debate_id FROM debates WHERE id = $1 AND deleted_at IS NULL
+`
+
+// Admin read of a debate that moderation may have hidden, for triaging reports
+func (q *Queries) GetDebateIncludingHidden(ctx context.Context, id int32) (Debate, error) {
+	row := q.db.QueryRowContext(ctx, getDebateIncludingHidden, id)
+	var i Debate
+	err := row.Scan(
+		&i.ID,
+		&i.MatchID,
+		&i.DebateType,
+		&i.Headline,
+		&i.Description,
+		&i.AiGenerated,
+		&i.DeletedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.PromptTemplateVersion,
+		&i.ExperimentVariantID,
+		&i.Locale,
+		&i.CanonicalDebateID,
+	)
+	return i, err
+}
+
+const getDebateMatchData = `-- name: GetDebateMatchData :one
+SELECT match_data FROM debate_match_data WHERE debate_id = $1
+`
+
+func (q *Queries) GetDebateMatchData(ctx context.Context, debateID int32) (json.RawMessage, error) {
+	row := q.db.QueryRowContext(ctx, getDebateMatchData, debateID)
+	var match_data json.RawMessage
+	err := row.Scan(&match_data)
+	return match_data, err
+}
+
+const getDebateTranslations = `-- name: GetDebateTranslations :many
+SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at, hidden_at, prompt_template_version, experiment_variant_id, locale, canonical_debate_id FROM debates
+WHERE canonical_debate_id = ANY($1::int[]) AND locale = ANY($2::text[])
+  AND deleted_at IS NULL AND hidden_at IS NULL
+`
+
+type GetDebateTranslationsParams struct {
+	CanonicalDebateIds []int32
+	Locales            []string
+}
+
+func (q *Queries) GetDebateTranslations(ctx context.Context, arg GetDebateTranslationsParams) ([]Debate, error) {
+	rows, err := q.db.QueryContext(ctx, getDebateTranslations, pq.Array(arg.CanonicalDebateIds), pq.Array(arg.Locales))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Debate
+	for rows.Next() {
+		var i Debate
+		if err := rows.Scan(
+			&i.ID,
+			&i.MatchID,
+			&i.DebateType,
+			&i.Headline,
+			&i.Description,
+			&i.AiGenerated,
+			&i.DeletedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.PromptTemplateVersion,
+			&i.ExperimentVariantID,
+			&i.Locale,
+			&i.CanonicalDebateID,
 		); err != nil {
 			return nil, err
 		}
@@ -436,8 +683,8 @@ func (q *Queries) GetDebateCards(ctx context.Context, debateID sql.NullInt32) ([
 }
 
 const getDebatesByMatch = `-- name: GetDebatesByMatch :many
-SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at FROM debates 
-WHERE match_id = $1 AND deleted_at IS NULL
+SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at, hidden_at, prompt_template_version, experiment_variant_id, locale, canonical_debate_id FROM debates 
+WHERE match_id = $1 AND deleted_at IS NULL AND hidden_at IS NULL
 ORDER BY created_at DESC
 `
 
@@ -460,6 +707,11 @@ func (q *Queries) GetDebatesByMatch(ctx context.Context, matchID string) ([]Deba
 			&i.DeletedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.PromptTemplateVersion,
+			&i.ExperimentVariantID,
+			&i.Locale,
+			&i.CanonicalDebateID,
 		); err != nil {
 			return nil, err
 		}
@@ -475,8 +727,8 @@ func (q *Queries) GetDebatesByMatch(ctx context.Context, matchID string) ([]Deba
 }
 
 const getDebatesByType = `-- name: GetDebatesByType :many
-SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at FROM debates 
-WHERE debate_type = $1 AND deleted_at IS NULL
+SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at, hidden_at, prompt_template_version, experiment_variant_id, locale, canonical_debate_id FROM debates 
+WHERE debate_type = $1 AND deleted_at IS NULL AND hidden_at IS NULL
 ORDER BY created_at DESC
 `
 
@@ -499,6 +751,11 @@ func (q *Queries) GetDebatesByType(ctx context.Context, debateType string) ([]De
 			&i.DeletedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.PromptTemplateVersion,
+			&i.ExperimentVariantID,
+			&i.Locale,
+			&i.CanonicalDebateID,
 		); err != nil {
 			return nil, err
 		}
@@ -515,30 +772,35 @@ func (q *Queries) GetDebatesByType(ctx context.Context, debateType string) ([]De
 
 const getTopDebates = `-- name: GetTopDebates :many
 SELECT 
-    d.id, d.match_id, d.debate_type, d.headline, d.description, d.ai_generated, d.deleted_at, d.created_at, d.updated_at,
+    d.id, d.match_id, d.debate_type, d.headline, d.description, d.ai_generated, d.deleted_at, d.created_at, d.updated_at, d.hidden_at, d.prompt_template_version, d.experiment_variant_id, d.locale, d.canonical_debate_id,
     da.total_votes,
     da.total_comments,
     da.engagement_score
 FROM debates d
 LEFT JOIN debate_analytics da ON d.id = da.debate_id
-WHERE d.deleted_at IS NULL
+WHERE d.deleted_at IS NULL AND d.hidden_at IS NULL AND d.canonical_debate_id IS NULL
 ORDER BY da.engagement_score DESC NULLS LAST
 LIMIT $1
 `
 
 type GetTopDebatesRow struct {
-	ID              int32
-	MatchID         string
-	DebateType      string
-	Headline        string
-	Description     sql.NullString
-	AiGenerated     sql.NullBool
-	DeletedAt       sql.NullTime
-	CreatedAt       sql.NullTime
-	UpdatedAt       sql.NullTime
-	TotalVotes      sql.NullInt32
-	TotalComments   sql.NullInt32
-	EngagementScore sql.NullString
+	ID                    int32
+	MatchID               string
+	DebateType            string
+	Headline              string
+	Description           sql.NullString
+	AiGenerated           sql.NullBool
+	DeletedAt             sql.NullTime
+	CreatedAt             sql.NullTime
+	UpdatedAt             sql.NullTime
+	HiddenAt              sql.NullTime
+	PromptTemplateVersion sql.NullInt32
+	ExperimentVariantID   sql.NullInt32
+	Locale                string
+	CanonicalDebateID     sql.NullInt32
+	TotalVotes            sql.NullInt32
+	TotalComments         sql.NullInt32
+	EngagementScore       sql.NullString
 }
 
 func (q *Queries) GetTopDebates(ctx context.Context, limit int32) ([]GetTopDebatesRow, error) {
@@ -560,6 +822,11 @@ func (q *Queries) GetTopDebates(ctx context.Context, limit int32) ([]GetTopDebat
 			&i.DeletedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.PromptTemplateVersion,
+			&i.ExperimentVariantID,
+			&i.Locale,
+			&i.CanonicalDebateID,
 			&i.TotalVotes,
 			&i.TotalComments,
 			&i.EngagementScore,
@@ -577,6 +844,48 @@ func (q *Queries) GetTopDebates(ctx context.Context, limit int32) ([]GetTopDebat
 	return items, nil
 }
 
+const getUserDirectionalVotes = `-- name: GetUserDirectionalVotes :many
+SELECT id, debate_card_id, user_id, vote_type, emoji, created_at FROM votes
+WHERE user_id = $1
+  AND debate_card_id = ANY($2::int[])
+  AND vote_type IN ('upvote', 'downvote')
+`
+
+type GetUserDirectionalVotesParams struct {
+	UserID  sql.NullInt32
+	CardIds []int32
+}
+
+func (q *Queries) GetUserDirectionalVotes(ctx context.Context, arg GetUserDirectionalVotesParams) ([]Vote, error) {
+	rows, err := q.db.QueryContext(ctx, getUserDirectionalVotes, arg.UserID, pq.Array(arg.CardIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Vote
+	for rows.Next() {
+		var i Vote
+		if err := rows.Scan(
+			&i.ID,
+			&i.DebateCardID,
+			&i.UserID,
+			&i.VoteType,
+			&i.Emoji,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserVote = `-- name: GetUserVote :one
 SELECT id, debate_card_id, user_id, vote_type, emoji, created_at FROM votes WHERE debate_card_id = $1 AND user_id = $2 AND vote_type = $3
 `
@@ -681,6 +990,56 @@ func (q *Queries) GetVotesByCard(ctx context.Context, debateCardID sql.NullInt32
 	return items, nil
 }
 
+const listDeletedDebates = `-- name: ListDeletedDebates :many
+SELECT id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at, hidden_at, prompt_template_version, experiment_variant_id, locale, canonical_debate_id FROM debates 
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListDeletedDebatesParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListDeletedDebates(ctx context.Context, arg ListDeletedDebatesParams) ([]Debate, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedDebates, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Debate
+	for rows.Next() {
+		var i Debate
+		if err := rows.Scan(
+			&i.ID,
+			&i.MatchID,
+			&i.DebateType,
+			&i.Headline,
+			&i.Description,
+			&i.AiGenerated,
+			&i.DeletedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.HiddenAt,
+			&i.PromptTemplateVersion,
+			&i.ExperimentVariantID,
+			&i.Locale,
+			&i.CanonicalDebateID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const restoreDebate = `-- name: RestoreDebate :exec
 UPDATE debates SET deleted_at = NULL WHERE id = $1
 `
@@ -690,6 +1049,46 @@ func (q *Queries) RestoreDebate(ctx context.Context, id int32) error {
 	return err
 }
 
+const setDebateCardHidden = `-- name: SetDebateCardHidden :execrows
+UPDATE debate_cards
+SET hidden_at = CASE WHEN $1::bool THEN CURRENT_TIMESTAMP END
+WHERE id = $2 AND (hidden_at IS NOT NULL) <> $1::bool
+`
+
+type SetDebateCardHiddenParams struct {
+	Hidden bool
+	ID     int32
+}
+
+// Hides or unhides a debate card, affecting no rows when it is already in that state
+func (q *Queries) SetDebateCardHidden(ctx context.Context, arg SetDebateCardHiddenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setDebateCardHidden, arg.Hidden, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setDebateHidden = `-- name: SetDebateHidden :execrows
+UPDATE debates
+SET hidden_at = CASE WHEN $1::bool THEN CURRENT_TIMESTAMP END
+WHERE id = $2 AND (hidden_at IS NOT NULL) <> $1::bool
+`
+
+type SetDebateHiddenParams struct {
+	Hidden bool
+	ID     int32
+}
+
+// Hides or unhides a debate, affecting no rows when it is already in that state
+func (q *Queries) SetDebateHidden(ctx context.Context, arg SetDebateHiddenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setDebateHidden, arg.Hidden, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const softDeleteDebate = `-- name: SoftDeleteDebate :exec
 UPDATE debates SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1
 `
@@ -701,18 +1100,27 @@ func (q *Queries) SoftDeleteDebate(ctx context.Context, id int32) error {
 
 const updateComment = `-- name: UpdateComment :one
 UPDATE comments 
-SET content = $2, updated_at = CURRENT_TIMESTAMP
+SET content = $2, moderation_status = $3, moderation_reason = $4,
+    hidden_at = CASE WHEN $3 = 'held' THEN COALESCE(hidden_at, CURRENT_TIMESTAMP) ELSE hidden_at END,
+    updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, debate_id, parent_comment_id, user_id, content, created_at, updated_at
+RETURNING id, debate_id, parent_comment_id, user_id, content, created_at, updated_at, deleted_at, deleted_by, hidden_at, moderation_status, moderation_reason
 `
 
 type UpdateCommentParams struct {
-	ID      int32
-	Content string
+	ID               int32
+	Content          string
+	ModerationStatus string
+	ModerationReason sql.NullString
 }
 
 func (q *Queries) UpdateComment(ctx context.Context, arg UpdateCommentParams) (Comment, error) {
-	row := q.db.QueryRowContext(ctx, updateComment, arg.ID, arg.Content)
+	row := q.db.QueryRowContext(ctx, updateComment,
+		arg.ID,
+		arg.Content,
+		arg.ModerationStatus,
+		arg.ModerationReason,
+	)
 	var i Comment
 	err := row.Scan(
 		&i.ID,
@@ -722,6 +1130,11 @@ func (q *Queries) UpdateComment(ctx context.Context, arg UpdateCommentParams) (C
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.HiddenAt,
+		&i.ModerationStatus,
+		&i.ModerationReason,
 	)
 	return i, err
 }
@@ -730,7 +1143,7 @@ const updateDebate = `-- name: UpdateDebate :one
 UPDATE debates 
 SET headline = $2, description = $3, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1 AND deleted_at IS NULL
-RETURNING id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at
+RETURNING id, match_id, debate_type, headline, description, ai_generated, deleted_at, created_at, updated_at, hidden_at, prompt_template_version, experiment_variant_id, locale, canonical_debate_id
 `
 
 type UpdateDebateParams struct {
@@ -752,6 +1165,11 @@ func (q *Queries) UpdateDebate(ctx context.Context, arg UpdateDebateParams) (Deb
 		&i.DeletedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.PromptTemplateVersion,
+		&i.ExperimentVariantID,
+		&i.Locale,
+		&i.CanonicalDebateID,
 	)
 	return i, err
 }
@@ -794,7 +1212,7 @@ const updateDebateCard = `-- name: UpdateDebateCard :one
 UPDATE debate_cards 
 SET title = $2, description = $3, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, debate_id, stance, title, description, ai_generated, created_at, updated_at
+RETURNING id, debate_id, stance, title, description, ai_generated, created_at, updated_at, hidden_at, canonical_card_id
 `
 
 type UpdateDebateCardParams struct {
@@ -815,6 +1233,8 @@ func (q *Queries) UpdateDebateCard(ctx context.Context, arg UpdateDebateCardPara
 		&i.AiGenerated,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.HiddenAt,
+		&i.CanonicalCardID,
 	)
 	return i, err
 }
@@ -6,31 +6,72 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     int32
+	Name       string
+	KeyPrefix  string
+	KeyHash    string
+	Scopes     []string
+	LastUsedAt sql.NullTime
+	ExpiresAt  sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+}
+
 type Comment struct {
+	ID               int32
+	DebateID         sql.NullInt32
+	ParentCommentID  sql.NullInt32
+	UserID           sql.NullInt32
+	Content          string
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	DeletedAt        sql.NullTime
+	DeletedBy        sql.NullInt32
+	HiddenAt         sql.NullTime
+	ModerationStatus string
+	ModerationReason sql.NullString
+}
+
+type CommentEdit struct {
 	ID              int32
-	DebateID        sql.NullInt32
-	ParentCommentID sql.NullInt32
-	UserID          sql.NullInt32
-	Content         string
-	CreatedAt       sql.NullTime
-	UpdatedAt       sql.NullTime
+	CommentID       int32
+	EditorID        sql.NullInt32
+	PreviousContent string
+	EditedAt        time.Time
+}
+
+type CommentReaction struct {
+	ID           int32
+	CommentID    int32
+	UserID       int32
+	ReactionType string
+	Emoji        sql.NullString
+	CreatedAt    time.Time
 }
 
 type Debate struct {
-	ID          int32
-	MatchID     string
-	DebateType  string
-	Headline    string
-	Description sql.NullString
-	AiGenerated sql.NullBool
-	DeletedAt   sql.NullTime
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
+	ID                    int32
+	MatchID               string
+	DebateType            string
+	Headline              string
+	Description           sql.NullString
+	AiGenerated           sql.NullBool
+	DeletedAt             sql.NullTime
+	CreatedAt             sql.NullTime
+	UpdatedAt             sql.NullTime
+	HiddenAt              sql.NullTime
+	PromptTemplateVersion sql.NullInt32
+	ExperimentVariantID   sql.NullInt32
+	Locale                string
+	CanonicalDebateID     sql.NullInt32
 }
 
 type DebateAnalytic struct {
@@ -44,14 +85,72 @@ type DebateAnalytic struct {
 }
 
 type DebateCard struct {
+	ID              int32
+	DebateID        sql.NullInt32
+	Stance          string
+	Title           string
+	Description     sql.NullString
+	AiGenerated     sql.NullBool
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	HiddenAt        sql.NullTime
+	CanonicalCardID sql.NullInt32
+}
+
+type DebateExperiment struct {
 	ID          int32
-	DebateID    sql.NullInt32
-	Stance      string
-	Title       string
+	Name        string
 	Description sql.NullString
-	AiGenerated sql.NullBool
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
+	DebateType  string
+	Status      string
+	CreatedBy   sql.NullInt32
+	CreatedAt   time.Time
+	StartedAt   sql.NullTime
+	StoppedAt   sql.NullTime
+}
+
+type DebateExperimentVariant struct {
+	ID                    int32
+	ExperimentID          int32
+	Name                  string
+	Weight                int32
+	PromptTemplateVersion sql.NullInt32
+	Model                 sql.NullString
+	Temperature           sql.NullFloat64
+	CreatedAt             time.Time
+}
+
+type DebateJob struct {
+	ID              int32
+	IdempotencyKey  string
+	MatchID         string
+	DebateType      string
+	Status          string
+	Attempts        int32
+	MaxAttempts     int32
+	RunAt           time.Time
+	LockedUntil     sql.NullTime
+	DebateID        sql.NullInt32
+	LastError       sql.NullString
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	ForceRegenerate bool
+	Locale          string
+}
+
+type DebateMatchDatum struct {
+	DebateID  int32
+	MatchData json.RawMessage
+	CreatedAt time.Time
+}
+
+type DebateStance struct {
+	ID           int32
+	DebateID     int32
+	DebateCardID int32
+	UserID       int32
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
 }
 
 type League struct {
@@ -68,6 +167,19 @@ type League struct {
 	UpdatedAt   time.Time
 }
 
+type LlmCall struct {
+	ID               int32
+	Provider         string
+	Model            string
+	Endpoint         string
+	PromptTokens     int32
+	CompletionTokens int32
+	CostUsd          string
+	LatencyMs        int32
+	Error            sql.NullString
+	CreatedAt        time.Time
+}
+
 type Medium struct {
 	ID        int32
 	MatchID   string
@@ -76,6 +188,17 @@ type Medium struct {
 	UpdatedAt time.Time
 }
 
+type ModerationAction struct {
+	ID          int32
+	ModeratorID sql.NullInt32
+	Action      string
+	TargetType  string
+	TargetID    int32
+	ReportID    sql.NullInt32
+	Notes       sql.NullString
+	CreatedAt   time.Time
+}
+
 type PlayerProfile struct {
 	ID         uuid.UUID
 	UserID     int32
@@ -96,6 +219,43 @@ type PlayerProfile struct {
 	UpdatedAt  time.Time
 }
 
+type PromptTemplate struct {
+	ID             int32
+	DebateType     string
+	Version        int32
+	SystemTemplate string
+	UserTemplate   string
+	Notes          sql.NullString
+	Active         bool
+	CreatedBy      sql.NullInt32
+	CreatedAt      time.Time
+	ActivatedAt    sql.NullTime
+}
+
+type RefreshToken struct {
+	ID         uuid.UUID
+	UserID     int32
+	FamilyID   uuid.UUID
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	ReplacedBy uuid.NullUUID
+	CreatedAt  time.Time
+}
+
+type Report struct {
+	ID         int32
+	ReporterID int32
+	TargetType string
+	TargetID   int32
+	Reason     string
+	Details    sql.NullString
+	Status     string
+	ResolvedBy sql.NullInt32
+	ResolvedAt sql.NullTime
+	CreatedAt  time.Time
+}
+
 type Team struct {
 	ID          uuid.UUID
 	Name        string
@@ -126,13 +286,14 @@ type TeamManager struct {
 }
 
 type User struct {
-	ID        int32
-	Firstname string
-	Lastname  string
-	Email     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	IsAdmin   bool
+	ID           int32
+	Firstname    string
+	Lastname     string
+	Email        string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	IsAdmin      bool
+	PasswordHash sql.NullString
 }
 
 type Verification struct {
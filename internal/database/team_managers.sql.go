@@ -118,6 +118,24 @@ func (q *Queries) GetTeamManagersByLeague(ctx context.Context, leagueID uuid.UUI
 	return items, nil
 }
 
+const isTeamManager = `-- name: IsTeamManager :one
+SELECT EXISTS (
+    SELECT 1 FROM team_managers WHERE user_id = $1 AND team_id = $2
+)
+`
+
+type IsTeamManagerParams struct {
+	UserID int32
+	TeamID uuid.NullUUID
+}
+
+func (q *Queries) IsTeamManager(ctx context.Context, arg IsTeamManagerParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isTeamManager, arg.UserID, arg.TeamID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listTeamManagers = `-- name: ListTeamManagers :many
 SELECT id, user_id, league_id, team_id, title, experience, bio, created_at, updated_at FROM team_managers 
 WHERE ($1::uuid IS NULL OR league_id = $1)
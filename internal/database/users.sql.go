@@ -7,27 +7,28 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
-const createUser = `-- name: CreateUser :one
-INSERT INTO users (firstname, lastname, email, is_admin)
+const createUserWithPassword = `-- name: CreateUserWithPassword :one
+INSERT INTO users (firstname, lastname, email, password_hash)
 VALUES ($1, $2, $3, $4)
-RETURNING id, firstname, lastname, email, created_at, updated_at, is_admin
+RETURNING id, firstname, lastname, email, created_at, updated_at, is_admin, password_hash
 `
 
-type CreateUserParams struct {
-	Firstname string
-	Lastname  string
-	Email     string
-	IsAdmin   bool
+type CreateUserWithPasswordParams struct {
+	Firstname    string
+	Lastname     string
+	Email        string
+	PasswordHash sql.NullString
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser,
+func (q *Queries) CreateUserWithPassword(ctx context.Context, arg CreateUserWithPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUserWithPassword,
 		arg.Firstname,
 		arg.Lastname,
 		arg.Email,
-		arg.IsAdmin,
+		arg.PasswordHash,
 	)
 	var i User
 	err := row.Scan(
@@ -38,6 +39,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsAdmin,
+		&i.PasswordHash,
 	)
 	return i, err
 }
@@ -52,7 +54,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id int32) error {
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, firstname, lastname, email, created_at, updated_at, is_admin FROM users WHERE id = $1
+SELECT id, firstname, lastname, email, created_at, updated_at, is_admin, password_hash FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
@@ -66,12 +68,13 @@ func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsAdmin,
+		&i.PasswordHash,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, firstname, lastname, email, created_at, updated_at, is_admin FROM users WHERE email = $1
+SELECT id, firstname, lastname, email, created_at, updated_at, is_admin, password_hash FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -85,16 +88,35 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsAdmin,
+		&i.PasswordHash,
 	)
 	return i, err
 }
 
-const listUsers = `-- name: ListUsers :many
-SELECT id, firstname, lastname, email, created_at, updated_at, is_admin FROM users ORDER BY created_at DESC
+const searchUsers = `-- name: SearchUsers :many
+SELECT id, firstname, lastname, email, created_at, updated_at, is_admin, password_hash FROM users
+WHERE $1::text = ''
+   OR firstname ILIKE $1::text || '%'
+   OR lastname ILIKE $1::text || '%'
+   OR ($2::boolean AND email ILIKE $1::text || '%')
+ORDER BY firstname, lastname, id
+LIMIT $3 OFFSET $4
 `
 
-func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, listUsers)
+type SearchUsersParams struct {
+	Search       string
+	IncludeEmail bool
+	PageSize     int32
+	PageOffset   int32
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsers,
+		arg.Search,
+		arg.IncludeEmail,
+		arg.PageSize,
+		arg.PageOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +132,7 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.IsAdmin,
+			&i.PasswordHash,
 		); err != nil {
 			return nil, err
 		}
@@ -128,7 +151,7 @@ const updateUser = `-- name: UpdateUser :one
 UPDATE users 
 SET firstname = $2, lastname = $3, email = $4, is_admin = $5, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, firstname, lastname, email, created_at, updated_at, is_admin
+RETURNING id, firstname, lastname, email, created_at, updated_at, is_admin, password_hash
 `
 
 type UpdateUserParams struct {
@@ -156,6 +179,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsAdmin,
+		&i.PasswordHash,
 	)
 	return i, err
 }
@@ -0,0 +1,82 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+)
+
+// Completer sends a chat completion request. Every ai.LLMProvider implements
+// it.
+type Completer interface {
+	Complete(ctx context.Context, request ai.CompletionRequest) (ai.Completion, error)
+}
+
+const classifierSystemPrompt = `You moderate comments on a football debate app. Passionate opinions, banter about players, teams and referees, and mild swearing are fine.
+Hold a comment for human review if it contains harassment or insults aimed at another user, hate speech, threats, sexual content, personal information or spam.
+Respond with only a JSON object: {"decision": "allow" or "hold", "reason": "a few words"}`
+
+// LLMClassifier asks a language model whether content should be held. It
+// never masks; that is left to the wordlists.
+type LLMClassifier struct {
+	client Completer
+	// Model overrides the provider's default model when set
+	Model string
+}
+
+func NewLLMClassifier(client Completer) *LLMClassifier {
+	return &LLMClassifier{client: client}
+}
+
+type classifierVerdict struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+func (c *LLMClassifier) Check(ctx context.Context, content, locale string) (Result, error) {
+	completion, err := c.client.Complete(ctx, ai.CompletionRequest{
+		Model: c.Model,
+		Messages: []ai.Message{
+			{Role: "system", Content: classifierSystemPrompt},
+			{Role: "user", Content: fmt.Sprintf("Locale: %s\nComment:\n%s", locale, content)},
+		},
+		Temperature: 0,
+		MaxTokens:   100,
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("moderation classifier failed: %w", err)
+	}
+
+	verdict, err := parseClassifierVerdict(completion.Text)
+	if err != nil {
+		return Result{}, err
+	}
+
+	switch Decision(verdict.Decision) {
+	case DecisionAllow:
+		return Result{Decision: DecisionAllow, Content: content}, nil
+	case DecisionHold:
+		return Result{Decision: DecisionHold, Content: content, Reason: "classifier: " + verdict.Reason}, nil
+	default:
+		return Result{}, fmt.Errorf("moderation classifier returned unknown decision %q", verdict.Decision)
+	}
+}
+
+// parseClassifierVerdict reads the model's JSON reply, which may be wrapped
+// in a markdown code fence
+func parseClassifierVerdict(reply string) (classifierVerdict, error) {
+	reply = strings.TrimSpace(reply)
+	reply = strings.TrimPrefix(reply, "```json")
+	reply = strings.TrimPrefix(reply, "```")
+	reply = strings.TrimSuffix(reply, "```")
+
+	var verdict classifierVerdict
+	if err := json.Unmarshal([]byte(strings.TrimSpace(reply)), &verdict); err != nil {
+		return classifierVerdict{}, fmt.Errorf("failed to parse moderation classifier reply: %w", err)
+	}
+	verdict.Decision = strings.ToLower(strings.TrimSpace(verdict.Decision))
+	return verdict, nil
+}
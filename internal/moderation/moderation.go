@@ -0,0 +1,79 @@
+// Package moderation screens user-written content before it is stored.
+//
+// A Pipeline runs its filters in order. Each filter decides to allow the
+// content, mask the offending parts or hold the content for an admin to
+// review. The strictest decision wins, masked content is passed on to later
+// filters, and a hold stops the pipeline.
+package moderation
+
+import (
+	"context"
+	"errors"
+)
+
+// Decision is what should happen to a piece of content
+type Decision string
+
+const (
+	DecisionAllow Decision = "allow"
+	DecisionMask  Decision = "mask"
+	DecisionHold  Decision = "hold"
+)
+
+// severity orders decisions from most to least permissive
+func (d Decision) severity() int {
+	switch d {
+	case DecisionMask:
+		return 1
+	case DecisionHold:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Result is a filter's verdict. Content is the text to store, with any
+// masking applied.
+type Result struct {
+	Decision Decision
+	Content  string
+	Reason   string
+}
+
+// Filter judges content written in locale
+type Filter interface {
+	Check(ctx context.Context, content, locale string) (Result, error)
+}
+
+type Pipeline struct {
+	filters []Filter
+}
+
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+// Moderate runs content through every filter. A filter that fails is skipped
+// so the others still apply; its error is returned alongside the result.
+func (p *Pipeline) Moderate(ctx context.Context, content, locale string) (Result, error) {
+	result := Result{Decision: DecisionAllow, Content: content}
+	var errs []error
+	for _, filter := range p.filters {
+		verdict, err := filter.Check(ctx, result.Content, locale)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if verdict.Decision == DecisionMask {
+			result.Content = verdict.Content
+		}
+		if verdict.Decision.severity() > result.Decision.severity() {
+			result.Decision = verdict.Decision
+			result.Reason = verdict.Reason
+		}
+		if result.Decision == DecisionHold {
+			break
+		}
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,101 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ArronJLinton/fucci-api/internal/ai"
+)
+
+func TestWordlistFilter(t *testing.T) {
+	filter := DefaultWordlistFilter()
+	ctx := context.Background()
+
+	result, _ := filter.Check(ctx, "What a goal, absolutely brilliant", "en")
+	if result.Decision != DecisionAllow || result.Content != "What a goal, absolutely brilliant" {
+		t.Errorf("Expected clean comment to be allowed, got %+v", result)
+	}
+
+	result, _ = filter.Check(ctx, "That ref is SHIT", "en")
+	if result.Decision != DecisionMask || result.Content != "That ref is ****" {
+		t.Errorf("Expected profanity to be masked, got %+v", result)
+	}
+
+	// Whole words only
+	result, _ = filter.Check(ctx, "He played in Scunthorpe", "en")
+	if result.Decision != DecisionAllow {
+		t.Errorf("Expected substring match to be ignored, got %+v", result)
+	}
+
+	result, _ = filter.Check(ctx, "just kill yourself", "en")
+	if result.Decision != DecisionHold {
+		t.Errorf("Expected threat to be held, got %+v", result)
+	}
+}
+
+func TestWordlistFilterLocales(t *testing.T) {
+	filter := DefaultWordlistFilter()
+	ctx := context.Background()
+
+	result, _ := filter.Check(ctx, "qué mierda de partido", "es-MX")
+	if result.Decision != DecisionMask || result.Content != "qué ****** de partido" {
+		t.Errorf("Expected Spanish profanity to be masked, got %+v", result)
+	}
+
+	// Other locales' words are left alone, English ones are not
+	result, _ = filter.Check(ctx, "qué mierda, shit", "en")
+	if result.Content != "qué mierda, ****" {
+		t.Errorf("Expected only English rules to apply, got %+v", result)
+	}
+	result, _ = filter.Check(ctx, "shit", "es")
+	if result.Decision != DecisionMask {
+		t.Errorf("Expected English rules to apply in every locale, got %+v", result)
+	}
+}
+
+func TestNewWordlistFilterInvalidPattern(t *testing.T) {
+	_, err := NewWordlistFilter(map[string]LocaleRules{"en": {Mask: []string{"(unclosed"}}})
+	if err == nil {
+		t.Error("Expected invalid pattern to be rejected")
+	}
+}
+
+func TestLLMClassifier(t *testing.T) {
+	ctx := context.Background()
+
+	held, err := NewLLMClassifier(&ai.FakeProvider{Reply: "```json\n{\"decision\": \"HOLD\", \"reason\": \"harassment\"}\n```"}).Check(ctx, "you again", "en")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if held.Decision != DecisionHold || held.Reason != "classifier: harassment" {
+		t.Errorf("Expected comment to be held, got %+v", held)
+	}
+
+	if _, err := NewLLMClassifier(&ai.FakeProvider{Reply: `{"decision": "maybe"}`}).Check(ctx, "hmm", "en"); err == nil {
+		t.Error("Expected unknown decision to be an error")
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+
+	// The classifier sees the masked text and a failing filter is skipped
+	failing := NewLLMClassifier(&ai.FakeProvider{Err: errors.New("timeout")})
+	result, err := NewPipeline(DefaultWordlistFilter(), failing).Moderate(ctx, "shit defending", "en")
+	if err == nil {
+		t.Error("Expected the classifier error to be returned")
+	}
+	if result.Decision != DecisionMask || result.Content != "**** defending" {
+		t.Errorf("Expected wordlist result to stand, got %+v", result)
+	}
+
+	holding := NewLLMClassifier(&ai.FakeProvider{Reply: `{"decision": "hold", "reason": "spam"}`})
+	result, err = NewPipeline(DefaultWordlistFilter(), holding).Moderate(ctx, "shit defending", "en")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Decision != DecisionHold || result.Reason != "classifier: spam" {
+		t.Errorf("Expected strictest decision to win, got %+v", result)
+	}
+}
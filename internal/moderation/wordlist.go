@@ -0,0 +1,153 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultLocale's rules apply to content in every locale, since English
+// swearing turns up whatever language a fan writes in
+const DefaultLocale = "en"
+
+// LocaleRules are the patterns checked for one language. Patterns are
+// regular expressions matched case-insensitively as whole words, so plain
+// words work as they are.
+type LocaleRules struct {
+	Mask []string `json:"mask"` // Matches are starred out
+	Hold []string `json:"hold"` // Any match holds the content for review
+}
+
+// DefaultRules is the built-in wordlist: common profanity is masked and
+// threats of self-harm or violence are held. Deployments extend it with
+// LoadRules.
+func DefaultRules() map[string]LocaleRules {
+	return map[string]LocaleRules{
+		"en": {
+			Mask: []string{`f+u+c+k\w*`, `shit\w*`, `bitch\w*`, `bastards?`, `assholes?`, `dickheads?`, `wank\w*`, `twats?`, `cunts?`, `bollocks`},
+			Hold: []string{`kill\s+yoursel(?:f|ves)`, `kys`, `hope\s+you\s+die`, `i\s+will\s+find\s+you`},
+		},
+		"es": {
+			Mask: []string{`mierda`, `puta\w*`, `gilipollas`, `cabr[oó]n\w*`, `pendej\w*`, `joder`, `co[ñn]o`},
+			Hold: []string{`m[aá]tate`, `ojal[aá]\s+te\s+mueras`},
+		},
+		"pt": {
+			Mask: []string{`porra`, `caralho`, `merda`, `puta\w*`, `fdp`},
+			Hold: []string{`se\s+mata`},
+		},
+		"fr": {
+			Mask: []string{`merde`, `putain`, `connard\w*`, `salope\w*`},
+			Hold: []string{`va\s+te\s+tuer`, `cr[eè]ve`},
+		},
+	}
+}
+
+// LoadRules reads a JSON file mapping locales to LocaleRules. Each locale in
+// the file replaces that locale's built-in rules; other locales keep them.
+func LoadRules(path string) (map[string]LocaleRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation wordlist: %w", err)
+	}
+	var overrides map[string]LocaleRules
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse moderation wordlist: %w", err)
+	}
+
+	rules := DefaultRules()
+	for locale, localeRules := range overrides {
+		rules[normalizeLocale(locale)] = localeRules
+	}
+	return rules, nil
+}
+
+type compiledRules struct {
+	mask *regexp.Regexp
+	hold *regexp.Regexp
+}
+
+// WordlistFilter masks or holds content matching its per-locale patterns
+type WordlistFilter struct {
+	rules map[string]compiledRules
+}
+
+func NewWordlistFilter(rules map[string]LocaleRules) (*WordlistFilter, error) {
+	filter := &WordlistFilter{rules: make(map[string]compiledRules, len(rules))}
+	for locale, localeRules := range rules {
+		mask, err := compilePatterns(localeRules.Mask)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mask pattern for locale %q: %w", locale, err)
+		}
+		hold, err := compilePatterns(localeRules.Hold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hold pattern for locale %q: %w", locale, err)
+		}
+		filter.rules[normalizeLocale(locale)] = compiledRules{mask: mask, hold: hold}
+	}
+	return filter, nil
+}
+
+// DefaultWordlistFilter builds a filter from DefaultRules, which are known to
+// compile
+func DefaultWordlistFilter() *WordlistFilter {
+	filter, err := NewWordlistFilter(DefaultRules())
+	if err != nil {
+		panic(err)
+	}
+	return filter
+}
+
+// compilePatterns joins patterns into one case-insensitive, whole-word
+// expression, or returns nil when there are none
+func compilePatterns(patterns []string) (*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	groups := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+		groups[i] = "(?:" + pattern + ")"
+	}
+	return regexp.Compile(`(?i)\b(?:` + strings.Join(groups, "|") + `)\b`)
+}
+
+// normalizeLocale reduces a language tag such as "es-MX" to its language
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+func (f *WordlistFilter) Check(ctx context.Context, content, locale string) (Result, error) {
+	locales := []string{DefaultLocale}
+	if locale = normalizeLocale(locale); locale != "" && locale != DefaultLocale {
+		locales = append(locales, locale)
+	}
+
+	result := Result{Decision: DecisionAllow, Content: content}
+	for _, l := range locales {
+		rules, ok := f.rules[l]
+		if !ok {
+			continue
+		}
+		if rules.hold != nil && rules.hold.MatchString(result.Content) {
+			return Result{Decision: DecisionHold, Content: content, Reason: "wordlist: held phrase"}, nil
+		}
+		if rules.mask != nil && rules.mask.MatchString(result.Content) {
+			result.Content = rules.mask.ReplaceAllStringFunc(result.Content, func(match string) string {
+				return strings.Repeat("*", utf8.RuneCountInString(match))
+			})
+			result.Decision = DecisionMask
+			result.Reason = "wordlist: profanity"
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,240 @@
+// Package policy decides who may create, update and delete user accounts,
+// leagues, teams, team managers, player profiles and verifications.
+//
+// The rules are:
+//   - admins (users.is_admin) may do anything
+//   - any user may create a league; only its owner may update or delete it
+//   - a league's owner creates, updates and deletes its teams and managers
+//   - a team's manager may update that team, and managers may update their
+//     own manager record
+//   - only a team's league owner may put a manager or player on the team,
+//     since a team's manager may then update it
+//   - users manage their own account, player profile, comments and the
+//     verifications they gave
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/google/uuid"
+)
+
+// Action is an operation being authorized
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// ErrForbidden is matched by every error returned for a denied action
+var ErrForbidden = errors.New("insufficient permissions")
+
+// DeniedError explains why an action was denied
+type DeniedError struct {
+	Action   Action
+	Resource string
+	Reason   string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("cannot %s %s: %s", e.Action, e.Resource, e.Reason)
+}
+
+func (e *DeniedError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+// NotFoundError is returned when a record a rule depends on does not exist.
+// It wraps sql.ErrNoRows.
+type NotFoundError struct {
+	Resource string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Resource + " not found"
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return sql.ErrNoRows
+}
+
+// Store is the subset of database.Queries the engine reads from
+type Store interface {
+	GetLeague(ctx context.Context, id uuid.UUID) (database.League, error)
+	GetUser(ctx context.Context, id int32) (database.User, error)
+	IsTeamManager(ctx context.Context, arg database.IsTeamManagerParams) (bool, error)
+}
+
+// Engine evaluates the authorization rules against the database
+type Engine struct {
+	store Store
+}
+
+// New creates a policy engine backed by store
+func New(store Store) *Engine {
+	return &Engine{store: store}
+}
+
+// IsAdmin reports whether the user with the given ID is an admin
+func (e *Engine) IsAdmin(ctx context.Context, userID int32) (bool, error) {
+	user, err := e.store.GetUser(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return user.IsAdmin, nil
+}
+
+// AuthorizeLeague checks league create/update/delete rights. leagueID is
+// ignored for ActionCreate.
+func (e *Engine) AuthorizeLeague(ctx context.Context, user database.User, leagueID uuid.UUID, action Action) error {
+	if user.IsAdmin || action == ActionCreate {
+		return nil
+	}
+
+	isOwner, err := e.isLeagueOwner(ctx, user, leagueID, "league")
+	if err != nil {
+		return err
+	}
+	if !isOwner {
+		return &DeniedError{Action: action, Resource: "league", Reason: "only the league owner or an admin may do this"}
+	}
+	return nil
+}
+
+// AuthorizeTeam checks team rights within a league. teamID is uuid.Nil when
+// creating a team.
+func (e *Engine) AuthorizeTeam(ctx context.Context, user database.User, leagueID uuid.NullUUID, teamID uuid.UUID, action Action) error {
+	if user.IsAdmin {
+		return nil
+	}
+	denied := &DeniedError{Action: action, Resource: "team", Reason: "only the league owner or an admin may do this"}
+	if !leagueID.Valid {
+		denied.Reason = "teams outside a league are managed by admins"
+		return denied
+	}
+
+	isOwner, err := e.isLeagueOwner(ctx, user, leagueID.UUID, "team's league")
+	if err != nil {
+		return err
+	}
+	if isOwner {
+		return nil
+	}
+
+	if action == ActionUpdate {
+		// A user may manage several teams in a league
+		isManager, err := e.store.IsTeamManager(ctx, database.IsTeamManagerParams{
+			UserID: user.ID,
+			TeamID: uuid.NullUUID{UUID: teamID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if isManager {
+			return nil
+		}
+		denied.Reason = "only the team's manager, the league owner or an admin may do this"
+	}
+	return denied
+}
+
+// AuthorizeManager checks team manager rights within a league. managerUserID
+// is the user the manager record belongs to.
+func (e *Engine) AuthorizeManager(ctx context.Context, user database.User, leagueID uuid.UUID, managerUserID int32, action Action) error {
+	if user.IsAdmin {
+		return nil
+	}
+	if action == ActionUpdate && managerUserID == user.ID {
+		return nil
+	}
+
+	isOwner, err := e.isLeagueOwner(ctx, user, leagueID, "team manager's league")
+	if err != nil {
+		return err
+	}
+	if !isOwner {
+		return &DeniedError{Action: action, Resource: "team manager", Reason: "only the league owner or an admin may do this"}
+	}
+	return nil
+}
+
+// AuthorizeTeamAssignment checks rights to put a manager or player on a team.
+// leagueID is the team's league and resource names what is being assigned.
+// Managers may update their own record and players their own profile, but
+// neither may move themselves onto a team.
+func (e *Engine) AuthorizeTeamAssignment(ctx context.Context, user database.User, leagueID uuid.NullUUID, resource string) error {
+	if user.IsAdmin {
+		return nil
+	}
+	denied := &DeniedError{Action: ActionUpdate, Resource: resource, Reason: "only the team's league owner or an admin may assign it a team"}
+	if !leagueID.Valid {
+		denied.Reason = "teams outside a league are assigned by admins"
+		return denied
+	}
+
+	isOwner, err := e.isLeagueOwner(ctx, user, leagueID.UUID, "team's league")
+	if err != nil {
+		return err
+	}
+	if !isOwner {
+		return denied
+	}
+	return nil
+}
+
+// AuthorizeUser checks rights over the account with the given ID
+func (e *Engine) AuthorizeUser(user database.User, accountID int32, action Action) error {
+	if user.IsAdmin || user.ID == accountID {
+		return nil
+	}
+	return &DeniedError{Action: action, Resource: "user", Reason: "users may only manage their own account"}
+}
+
+// AuthorizePlayerProfile checks player profile rights. ownerID is the user
+// the profile belongs to.
+func (e *Engine) AuthorizePlayerProfile(user database.User, ownerID int32, action Action) error {
+	if user.IsAdmin || user.ID == ownerID {
+		return nil
+	}
+	return &DeniedError{Action: action, Resource: "player profile", Reason: "users may only manage their own profile"}
+}
+
+// AuthorizeVerification checks verification rights. verifierID is the user
+// vouching for the player.
+func (e *Engine) AuthorizeVerification(user database.User, verifierID int32, action Action) error {
+	if user.IsAdmin || user.ID == verifierID {
+		return nil
+	}
+	return &DeniedError{Action: action, Resource: "verification", Reason: "users may only manage their own verifications"}
+}
+
+// AuthorizeComment checks comment rights. authorID is the user who wrote the
+// comment.
+func (e *Engine) AuthorizeComment(user database.User, authorID int32, action Action) error {
+	if user.IsAdmin || user.ID == authorID {
+		return nil
+	}
+	return &DeniedError{Action: action, Resource: "comment", Reason: "users may only manage their own comments"}
+}
+
+// isLeagueOwner reports whether user owns the league, returning a
+// NotFoundError naming resource when the league does not exist
+func (e *Engine) isLeagueOwner(ctx context.Context, user database.User, leagueID uuid.UUID, resource string) (bool, error) {
+	league, err := e.store.GetLeague(ctx, leagueID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, &NotFoundError{Resource: resource}
+		}
+		return false, err
+	}
+	return league.OwnerID == user.ID, nil
+}
@@ -0,0 +1,192 @@
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) GetLeague(ctx context.Context, id uuid.UUID) (database.League, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.League), args.Error(1)
+}
+
+func (m *MockStore) GetUser(ctx context.Context, id int32) (database.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func (m *MockStore) IsTeamManager(ctx context.Context, arg database.IsTeamManagerParams) (bool, error) {
+	args := m.Called(ctx, arg)
+	return args.Bool(0), args.Error(1)
+}
+
+var (
+	owner   = database.User{ID: 1}
+	manager = database.User{ID: 2}
+	other   = database.User{ID: 3}
+	admin   = database.User{ID: 4, IsAdmin: true}
+
+	secondTeamID = uuid.New()
+)
+
+// setupLeague creates a league owned by owner with two teams, both managed
+// by manager
+func setupLeague(store *MockStore) (uuid.UUID, uuid.UUID) {
+	leagueID := uuid.New()
+	teamID := uuid.New()
+	store.On("GetLeague", mock.Anything, leagueID).Return(database.League{ID: leagueID, OwnerID: owner.ID}, nil)
+	store.On("IsTeamManager", mock.Anything, database.IsTeamManagerParams{UserID: manager.ID, TeamID: uuid.NullUUID{UUID: teamID, Valid: true}}).Return(true, nil)
+	store.On("IsTeamManager", mock.Anything, database.IsTeamManagerParams{UserID: manager.ID, TeamID: uuid.NullUUID{UUID: secondTeamID, Valid: true}}).Return(true, nil)
+	store.On("IsTeamManager", mock.Anything, mock.Anything).Return(false, nil)
+	return leagueID, teamID
+}
+
+func TestAuthorizeLeague(t *testing.T) {
+	store := &MockStore{}
+	engine := New(store)
+	leagueID, _ := setupLeague(store)
+	ctx := context.Background()
+
+	assert.NoError(t, engine.AuthorizeLeague(ctx, other, uuid.Nil, ActionCreate))
+	assert.NoError(t, engine.AuthorizeLeague(ctx, owner, leagueID, ActionUpdate))
+	assert.NoError(t, engine.AuthorizeLeague(ctx, owner, leagueID, ActionDelete))
+	assert.NoError(t, engine.AuthorizeLeague(ctx, admin, leagueID, ActionDelete))
+
+	err := engine.AuthorizeLeague(ctx, other, leagueID, ActionUpdate)
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, engine.AuthorizeLeague(ctx, manager, leagueID, ActionDelete), ErrForbidden)
+}
+
+func TestAuthorizeLeagueNotFound(t *testing.T) {
+	store := &MockStore{}
+	engine := New(store)
+	leagueID := uuid.New()
+	store.On("GetLeague", mock.Anything, leagueID).Return(database.League{}, sql.ErrNoRows)
+
+	err := engine.AuthorizeLeague(context.Background(), other, leagueID, ActionUpdate)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.False(t, errors.Is(err, ErrForbidden))
+	assert.EqualError(t, err, "league not found")
+
+	err = engine.AuthorizeTeam(context.Background(), other, uuid.NullUUID{UUID: leagueID, Valid: true}, uuid.New(), ActionUpdate)
+	assert.EqualError(t, err, "team's league not found")
+}
+
+func TestAuthorizeTeam(t *testing.T) {
+	store := &MockStore{}
+	engine := New(store)
+	leagueID, teamID := setupLeague(store)
+	league := uuid.NullUUID{UUID: leagueID, Valid: true}
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		user    database.User
+		league  uuid.NullUUID
+		team    uuid.UUID
+		action  Action
+		allowed bool
+	}{
+		{"owner creates", owner, league, uuid.Nil, ActionCreate, true},
+		{"other creates", other, league, uuid.Nil, ActionCreate, false},
+		{"manager creates", manager, league, uuid.Nil, ActionCreate, false},
+		{"manager updates own team", manager, league, teamID, ActionUpdate, true},
+		{"manager updates second own team", manager, league, secondTeamID, ActionUpdate, true},
+		{"manager updates another team", manager, league, uuid.New(), ActionUpdate, false},
+		{"manager deletes own team", manager, league, teamID, ActionDelete, false},
+		{"owner deletes", owner, league, teamID, ActionDelete, true},
+		{"admin deletes", admin, league, teamID, ActionDelete, true},
+		{"owner updates team without league", owner, uuid.NullUUID{}, teamID, ActionUpdate, false},
+		{"admin updates team without league", admin, uuid.NullUUID{}, teamID, ActionUpdate, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.AuthorizeTeam(ctx, tt.user, tt.league, tt.team, tt.action)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestAuthorizeManager(t *testing.T) {
+	store := &MockStore{}
+	engine := New(store)
+	leagueID, _ := setupLeague(store)
+	ctx := context.Background()
+
+	assert.NoError(t, engine.AuthorizeManager(ctx, owner, leagueID, manager.ID, ActionCreate))
+	assert.NoError(t, engine.AuthorizeManager(ctx, manager, leagueID, manager.ID, ActionUpdate))
+	assert.NoError(t, engine.AuthorizeManager(ctx, admin, leagueID, manager.ID, ActionDelete))
+	assert.ErrorIs(t, engine.AuthorizeManager(ctx, manager, leagueID, manager.ID, ActionDelete), ErrForbidden)
+	assert.ErrorIs(t, engine.AuthorizeManager(ctx, other, leagueID, manager.ID, ActionUpdate), ErrForbidden)
+	assert.ErrorIs(t, engine.AuthorizeManager(ctx, other, leagueID, other.ID, ActionCreate), ErrForbidden)
+}
+
+func TestAuthorizeTeamAssignment(t *testing.T) {
+	store := &MockStore{}
+	engine := New(store)
+	leagueID, _ := setupLeague(store)
+	league := uuid.NullUUID{UUID: leagueID, Valid: true}
+	ctx := context.Background()
+
+	assert.NoError(t, engine.AuthorizeTeamAssignment(ctx, owner, league, "team manager"))
+	assert.NoError(t, engine.AuthorizeTeamAssignment(ctx, admin, league, "team manager"))
+	assert.NoError(t, engine.AuthorizeTeamAssignment(ctx, admin, uuid.NullUUID{}, "player profile"))
+
+	// A manager may update their own record, but not move it onto a team,
+	// which would let them update that team
+	assert.NoError(t, engine.AuthorizeManager(ctx, manager, leagueID, manager.ID, ActionUpdate))
+	assert.ErrorIs(t, engine.AuthorizeTeamAssignment(ctx, manager, league, "team manager"), ErrForbidden)
+	assert.ErrorIs(t, engine.AuthorizeTeamAssignment(ctx, other, league, "player profile"), ErrForbidden)
+	assert.ErrorIs(t, engine.AuthorizeTeamAssignment(ctx, owner, uuid.NullUUID{}, "player profile"), ErrForbidden)
+}
+
+func TestAuthorizeOwnedResources(t *testing.T) {
+	engine := New(&MockStore{})
+
+	assert.NoError(t, engine.AuthorizePlayerProfile(other, other.ID, ActionUpdate))
+	assert.NoError(t, engine.AuthorizePlayerProfile(admin, other.ID, ActionDelete))
+	assert.ErrorIs(t, engine.AuthorizePlayerProfile(owner, other.ID, ActionUpdate), ErrForbidden)
+
+	assert.NoError(t, engine.AuthorizeVerification(other, other.ID, ActionCreate))
+	assert.ErrorIs(t, engine.AuthorizeVerification(other, owner.ID, ActionCreate), ErrForbidden)
+
+	assert.NoError(t, engine.AuthorizeComment(other, other.ID, ActionUpdate))
+	assert.NoError(t, engine.AuthorizeComment(admin, other.ID, ActionDelete))
+	assert.ErrorIs(t, engine.AuthorizeComment(owner, other.ID, ActionDelete), ErrForbidden)
+}
+
+func TestIsAdmin(t *testing.T) {
+	store := &MockStore{}
+	engine := New(store)
+	store.On("GetUser", mock.Anything, admin.ID).Return(admin, nil)
+	store.On("GetUser", mock.Anything, other.ID).Return(other, nil)
+	store.On("GetUser", mock.Anything, int32(99)).Return(database.User{}, sql.ErrNoRows)
+
+	isAdmin, err := engine.IsAdmin(context.Background(), admin.ID)
+	assert.NoError(t, err)
+	assert.True(t, isAdmin)
+
+	isAdmin, err = engine.IsAdmin(context.Background(), other.ID)
+	assert.NoError(t, err)
+	assert.False(t, isAdmin)
+
+	isAdmin, err = engine.IsAdmin(context.Background(), 99)
+	assert.NoError(t, err)
+	assert.False(t, isAdmin)
+}
@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/ArronJLinton/fucci-api/internal/ai"
 	"github.com/ArronJLinton/fucci-api/internal/api"
+	"github.com/ArronJLinton/fucci-api/internal/auth"
 	"github.com/ArronJLinton/fucci-api/internal/cache"
 	"github.com/ArronJLinton/fucci-api/internal/config"
 	"github.com/ArronJLinton/fucci-api/internal/database"
+	"github.com/ArronJLinton/fucci-api/internal/moderation"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 	_ "github.com/lib/pq"
@@ -26,6 +36,10 @@ var (
 	version = "dev"
 )
 
+// serverShutdownTimeout is how long in-flight requests get to finish once
+// the server is asked to stop
+const serverShutdownTimeout = 15 * time.Second
+
 func main() {
 	// Initialize the logger
 	zlog, _ := zap.NewProduction(
@@ -51,6 +65,86 @@ func main() {
 		log.Fatal("Failed to connect to Redis - ", err)
 	}
 
+	// Initialize JWT validation; without it every authenticated route returns 401
+	var jwtValidator *auth.JWTValidator
+	var tokenIssuer *auth.TokenIssuer
+	if c.JWT_SECRET != "" || c.JWT_JWKS_FILE != "" {
+		jwtValidator, err = auth.NewJWTValidator(auth.JWTConfig{
+			Secret:   c.JWT_SECRET,
+			JWKSFile: c.JWT_JWKS_FILE,
+			Issuer:   c.JWT_ISSUER,
+			Audience: c.JWT_AUDIENCE,
+		})
+		if err != nil {
+			log.Fatal("Failed to initialize JWT validation - ", err)
+		}
+		// Only the shared secret can sign tokens for email/password sessions
+		if c.JWT_SECRET != "" {
+			tokenIssuer, err = auth.NewTokenIssuer(c.JWT_SECRET, c.JWT_ISSUER, c.JWT_AUDIENCE)
+			if err != nil {
+				log.Fatal("Failed to initialize token issuer - ", err)
+			}
+		}
+	} else {
+		logger.Warn("JWT_SECRET and JWT_JWKS_FILE are not set, authenticated routes are disabled")
+	}
+
+	// Proxies whose X-Forwarded-For headers identify clients for rate limiting
+	trustedProxies, err := api.ParseTrustedProxies(c.TRUSTED_PROXIES)
+	if err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES - ", err)
+	}
+
+	// Initialize comment moderation wordlists, with per-locale overrides from
+	// MODERATION_WORDLIST_FILE when set
+	moderationRules := moderation.DefaultRules()
+	if c.MODERATION_WORDLIST_FILE != "" {
+		moderationRules, err = moderation.LoadRules(c.MODERATION_WORDLIST_FILE)
+		if err != nil {
+			log.Fatal("Failed to load moderation wordlist - ", err)
+		}
+	}
+	moderationWordlist, err := moderation.NewWordlistFilter(moderationRules)
+	if err != nil {
+		log.Fatal("Failed to initialize moderation wordlist - ", err)
+	}
+
+	// Initialize the LLM provider behind debate generation and comment
+	// classification, selected by LLM_PROVIDER
+	llmConfig := ai.ProviderConfig{Provider: c.LLM_PROVIDER, Model: c.LLM_MODEL}
+	switch c.LLM_PROVIDER {
+	case ai.ProviderOpenAI:
+		llmConfig.APIKey, llmConfig.BaseURL = c.OPENAI_API_KEY, c.OPENAI_BASE_URL
+		llmConfig.StructuredOutput = c.LLM_STRUCTURED_OUTPUT
+	case ai.ProviderAnthropic:
+		llmConfig.APIKey, llmConfig.BaseURL = c.ANTHROPIC_API_KEY, c.ANTHROPIC_BASE_URL
+	case ai.ProviderOllama:
+		llmConfig.BaseURL = c.OLLAMA_BASE_URL
+	}
+	llmProvider, err := ai.NewLLMProvider(llmConfig)
+	if errors.Is(err, ai.ErrNoAPIKey) {
+		logger.Warn("No API key set for LLM_PROVIDER, AI debate generation is disabled")
+	} else if err != nil {
+		log.Fatal("Invalid LLM_PROVIDER - ", err)
+	}
+	llmPrices, err := ai.ParseModelPrices(c.LLM_MODEL_PRICES)
+	if err != nil {
+		log.Fatal("Invalid LLM_MODEL_PRICES - ", err)
+	}
+
+	// Leagues whose fixtures get debates generated automatically
+	var schedulerLeagues []int
+	for _, id := range strings.Split(c.DEBATE_SCHEDULER_LEAGUES, ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		leagueID, err := strconv.Atoi(id)
+		if err != nil {
+			log.Fatal("Invalid DEBATE_SCHEDULER_LEAGUES - ", err)
+		}
+		schedulerLeagues = append(schedulerLeagues, leagueID)
+	}
+
 	router := chi.NewRouter()
 	// Tells browsers how this api can be used
 	router.Use(cors.Handler(cors.Options{
@@ -65,15 +159,30 @@ func main() {
 	v1Router := chi.NewRouter()
 	dbQueries := database.New(conn)
 	apiCfg := api.Config{
-		DB:             dbQueries,
-		DBConn:         conn,
-		FootballAPIKey: c.FOOTBALL_API_KEY,
-		RapidAPIKey:    c.RAPID_API_KEY,
-		Cache:          redisCache,
-		OpenAIKey:      c.OPENAI_API_KEY,
-		OpenAIBaseURL:  c.OPENAI_BASE_URL,
+		DB:                     dbQueries,
+		DBConn:                 conn,
+		FootballAPIKey:         c.FOOTBALL_API_KEY,
+		RapidAPIKey:            c.RAPID_API_KEY,
+		Cache:                  redisCache,
+		LLMProvider:            llmProvider,
+		LLMPrices:              llmPrices,
+		LLMMonthlyBudget:       c.LLM_MONTHLY_BUDGET_USD,
+		JWTValidator:           jwtValidator,
+		TokenIssuer:            tokenIssuer,
+		TrustedProxies:         trustedProxies,
+		ReportHideThreshold:    c.REPORT_HIDE_THRESHOLD,
+		ModerationWordlist:     moderationWordlist,
+		ModerationLLM:          c.MODERATION_LLM_ENABLED,
+		LiveMatchPollInterval:  time.Duration(c.LIVE_MATCH_POLL_SECONDS) * time.Second,
+		DebateSchedulerLeagues: schedulerLeagues,
+		PreMatchDebateLead:     time.Duration(c.PRE_MATCH_DEBATE_HOURS) * time.Hour,
 	}
-	apiRouter := api.New(apiCfg)
+	// Cancelled on shutdown, which stops the background debate workers and
+	// scheduler
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	apiRouter := api.New(ctx, apiCfg)
 	v1Router.Mount("/api", apiRouter)
 	router.Mount("/v1", v1Router)
 
@@ -93,8 +202,21 @@ func main() {
 		Addr:    serverAddr,
 	}
 
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			fmt.Printf("Failed to shut down server: %v\n", err)
+		}
+	}()
+
 	err = server.ListenAndServe()
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal(err)
 	}
+	<-shutdownDone
+	fmt.Println("Server stopped")
 }
@@ -0,0 +1,36 @@
+-- name: CreateCommentLike :exec
+INSERT INTO comment_reactions (comment_id, user_id, reaction_type)
+VALUES ($1, $2, 'like')
+ON CONFLICT (comment_id, user_id) WHERE reaction_type = 'like'
+DO NOTHING;
+
+-- name: DeleteCommentLike :execrows
+DELETE FROM comment_reactions
+WHERE comment_id = $1 AND user_id = $2 AND reaction_type = 'like';
+
+-- name: CreateCommentEmojiReaction :exec
+INSERT INTO comment_reactions (comment_id, user_id, reaction_type, emoji)
+VALUES ($1, $2, 'emoji', $3)
+ON CONFLICT (comment_id, user_id, emoji) WHERE reaction_type = 'emoji'
+DO NOTHING;
+
+-- name: DeleteCommentEmojiReaction :execrows
+DELETE FROM comment_reactions
+WHERE comment_id = $1 AND user_id = $2 AND reaction_type = 'emoji' AND emoji = $3;
+
+-- name: GetCommentReactionCounts :many
+SELECT 
+    comment_id,
+    reaction_type,
+    emoji,
+    COUNT(*) as count
+FROM comment_reactions
+WHERE comment_id = ANY(@comment_ids::int[])
+GROUP BY comment_id, reaction_type, emoji;
+
+-- name: GetDebateCommentReactionCount :one
+-- Reactions on a debate's live comments, for the engagement score
+SELECT COUNT(*)
+FROM comment_reactions cr
+JOIN comments c ON c.id = cr.comment_id
+WHERE c.debate_id = $1 AND c.deleted_at IS NULL;
@@ -0,0 +1,103 @@
+-- name: ListRootComments :many
+-- Keyset page of a debate's top-level comments. @sort is 'newest', 'oldest'
+-- or 'most_liked'; the cursor is the last row of the previous page, or a zero
+-- @cursor_id for the first page.
+WITH roots AS (
+    SELECT 
+        c.id, c.debate_id, c.parent_comment_id, c.user_id, c.content, c.created_at, c.updated_at, c.deleted_at, c.deleted_by, c.hidden_at,
+        u.firstname,
+        u.lastname,
+        (SELECT COUNT(*) FROM comments r WHERE r.parent_comment_id = c.id) AS reply_count,
+        (SELECT COUNT(*) FROM comment_reactions cr WHERE cr.comment_id = c.id AND cr.reaction_type = 'like') AS like_count
+    FROM comments c
+    JOIN users u ON c.user_id = u.id
+    WHERE c.debate_id = @debate_id
+      AND c.parent_comment_id IS NULL
+)
+SELECT id, debate_id, parent_comment_id, user_id, content, created_at, updated_at, deleted_at, deleted_by, hidden_at, firstname, lastname, reply_count, like_count
+FROM roots
+WHERE @cursor_id::int = 0
+   OR (@sort::text = 'newest' AND (created_at, id) < (@cursor_created_at::timestamp, @cursor_id::int))
+   OR (@sort::text = 'oldest' AND (created_at, id) > (@cursor_created_at::timestamp, @cursor_id::int))
+   OR (@sort::text = 'most_liked' AND (like_count, id) < (@cursor_likes::bigint, @cursor_id::int))
+ORDER BY
+    CASE WHEN @sort::text = 'most_liked' THEN like_count END DESC,
+    CASE WHEN @sort::text = 'most_liked' THEN id END DESC,
+    CASE WHEN @sort::text = 'oldest' THEN created_at END ASC,
+    CASE WHEN @sort::text = 'oldest' THEN id END ASC,
+    created_at DESC,
+    id DESC
+LIMIT @page_size;
+
+-- name: ListCommentReplies :many
+-- Replies below the given comments, down to @max_depth levels.
+WITH RECURSIVE thread AS (
+    SELECT c.id, c.debate_id, c.parent_comment_id, c.user_id, c.content, c.created_at, c.updated_at, c.deleted_at, c.deleted_by, c.hidden_at, 1 AS depth
+    FROM comments c
+    WHERE c.parent_comment_id = ANY(@root_ids::int[])
+    UNION ALL
+    SELECT c.id, c.debate_id, c.parent_comment_id, c.user_id, c.content, c.created_at, c.updated_at, c.deleted_at, c.deleted_by, c.hidden_at, t.depth + 1
+    FROM comments c
+    JOIN thread t ON c.parent_comment_id = t.id
+    WHERE t.depth < @max_depth::int
+)
+SELECT 
+    t.id, t.debate_id, t.parent_comment_id, t.user_id, t.content, t.created_at, t.updated_at, t.deleted_at, t.deleted_by, t.hidden_at,
+    t.depth::int AS depth,
+    u.firstname,
+    u.lastname,
+    (SELECT COUNT(*) FROM comments r WHERE r.parent_comment_id = t.id) AS reply_count
+FROM thread t
+JOIN users u ON t.user_id = u.id
+ORDER BY t.created_at ASC, t.id ASC;
+
+-- name: SoftDeleteComment :execrows
+UPDATE comments
+SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $2
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreComment :execrows
+UPDATE comments
+SET deleted_at = NULL, deleted_by = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: ApproveHeldComment :execrows
+-- Publishes a comment that moderation held for review
+UPDATE comments
+SET moderation_status = 'approved', hidden_at = NULL
+WHERE id = $1 AND moderation_status = 'held' AND deleted_at IS NULL;
+
+-- name: SetCommentHidden :execrows
+-- Hides or unhides a comment, affecting no rows when it is already in that state
+UPDATE comments
+SET hidden_at = CASE WHEN @hidden::bool THEN CURRENT_TIMESTAMP END
+WHERE id = @id AND (hidden_at IS NOT NULL) <> @hidden::bool;
+
+-- name: CreateCommentEdit :one
+INSERT INTO comment_edits (comment_id, editor_id, previous_content)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListCommentEdits :many
+SELECT * FROM comment_edits
+WHERE comment_id = $1
+ORDER BY edited_at DESC, id DESC;
+
+-- name: ListModerationComments :many
+-- Moderation queue. @status is 'removed' for soft-deleted comments, 'edited'
+-- for live comments that have been edited, 'hidden' for comments hidden by
+-- moderation or 'held' for new comments awaiting review, most recent first.
+SELECT 
+    c.id, c.debate_id, c.parent_comment_id, c.user_id, c.content, c.created_at, c.updated_at, c.deleted_at, c.deleted_by, c.hidden_at, c.moderation_status, c.moderation_reason,
+    u.firstname,
+    u.lastname,
+    (SELECT COUNT(*) FROM comment_edits e WHERE e.comment_id = c.id) AS edit_count
+FROM comments c
+JOIN users u ON c.user_id = u.id
+WHERE (@status::text = 'removed' AND c.deleted_at IS NOT NULL)
+   OR (@status::text = 'edited' AND c.deleted_at IS NULL
+       AND EXISTS (SELECT 1 FROM comment_edits e WHERE e.comment_id = c.id))
+   OR (@status::text = 'hidden' AND c.hidden_at IS NOT NULL)
+   OR (@status::text = 'held' AND c.moderation_status = 'held' AND c.deleted_at IS NULL)
+ORDER BY COALESCE(c.deleted_at, c.hidden_at, c.updated_at) DESC, c.id DESC
+LIMIT @page_size OFFSET @page_offset;
@@ -0,0 +1,58 @@
+-- name: CreateDebateExperiment :one
+INSERT INTO debate_experiments (name, description, debate_type, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: CreateDebateExperimentVariant :one
+INSERT INTO debate_experiment_variants (experiment_id, name, weight, prompt_template_version, model, temperature)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetDebateExperiment :one
+SELECT * FROM debate_experiments WHERE id = $1;
+
+-- name: ListDebateExperiments :many
+SELECT * FROM debate_experiments ORDER BY created_at DESC, id DESC;
+
+-- name: ListDebateExperimentVariants :many
+SELECT * FROM debate_experiment_variants WHERE experiment_id = $1 ORDER BY id;
+
+-- name: GetRunningExperimentVariants :many
+-- The variants of the experiment running for a debate type, if any
+SELECT v.id, v.experiment_id, v.name, v.weight, v.prompt_template_version, v.model, v.temperature, v.created_at
+FROM debate_experiment_variants v
+JOIN debate_experiments e ON e.id = v.experiment_id
+WHERE e.debate_type = $1 AND e.status = 'running'
+ORDER BY v.id;
+
+-- name: StartDebateExperiment :one
+UPDATE debate_experiments
+SET status = 'running', started_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'draft'
+RETURNING *;
+
+-- name: StopDebateExperiment :one
+UPDATE debate_experiments
+SET status = 'stopped', stopped_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'running'
+RETURNING *;
+
+-- name: GetDebateExperimentReport :many
+-- Engagement per variant of an experiment. Debates without analytics count
+-- as no engagement; the standard deviations give the confidence intervals.
+SELECT
+    v.id AS variant_id,
+    v.name,
+    COUNT(d.id)::int AS debates,
+    COALESCE(AVG(COALESCE(da.engagement_score, 0)), 0)::float8 AS avg_engagement_score,
+    COALESCE(STDDEV_SAMP(COALESCE(da.engagement_score, 0)), 0)::float8 AS stddev_engagement_score,
+    COALESCE(AVG(COALESCE(da.total_votes, 0)), 0)::float8 AS avg_votes,
+    COALESCE(STDDEV_SAMP(COALESCE(da.total_votes, 0)), 0)::float8 AS stddev_votes,
+    COALESCE(AVG(COALESCE(da.total_comments, 0)), 0)::float8 AS avg_comments,
+    COALESCE(STDDEV_SAMP(COALESCE(da.total_comments, 0)), 0)::float8 AS stddev_comments
+FROM debate_experiment_variants v
+LEFT JOIN debates d ON d.experiment_variant_id = v.id AND d.deleted_at IS NULL
+LEFT JOIN debate_analytics da ON da.debate_id = d.id
+WHERE v.experiment_id = $1
+GROUP BY v.id, v.name
+ORDER BY v.id;
@@ -0,0 +1,56 @@
+-- name: EnqueueDebateJob :one
+-- Returns no rows when a job with the same idempotency key already exists, or
+-- a job for the same debate is still queued or running
+INSERT INTO debate_jobs (idempotency_key, match_id, debate_type, max_attempts, run_at, force_regenerate, locale)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetDebateJob :one
+SELECT * FROM debate_jobs WHERE id = $1;
+
+-- name: GetActiveDebateJob :one
+SELECT * FROM debate_jobs
+WHERE match_id = $1 AND debate_type = $2 AND locale = $3 AND status IN ('pending', 'running');
+
+-- name: ClaimDueDebateJobs :many
+-- Marks up to @batch_size due jobs as running until @locked_until. Pending
+-- jobs are due at run_at; running jobs whose lease has expired are due again
+-- if they have attempts left. SKIP LOCKED lets several instances claim jobs
+-- at once without overlap.
+UPDATE debate_jobs
+SET status = 'running', attempts = attempts + 1, locked_until = @locked_until, updated_at = CURRENT_TIMESTAMP
+WHERE id IN (
+    SELECT j.id FROM debate_jobs j
+    WHERE (j.status = 'pending' AND j.run_at <= CURRENT_TIMESTAMP)
+       OR (j.status = 'running' AND j.locked_until < CURRENT_TIMESTAMP AND j.attempts < j.max_attempts)
+    ORDER BY j.run_at ASC, j.id ASC
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteDebateJob :execrows
+-- Affects no rows unless the job is still running under the lease that ends
+-- at locked_until, i.e. no other worker has since claimed it
+UPDATE debate_jobs
+SET status = 'succeeded', debate_id = $2, locked_until = NULL, last_error = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'running' AND locked_until = $3;
+
+-- name: RetryDebateJob :exec
+-- Puts a failed attempt back in the queue to run again at run_at
+UPDATE debate_jobs
+SET status = 'pending', run_at = $2, last_error = $3, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: FailDebateJob :exec
+UPDATE debate_jobs
+SET status = 'failed', last_error = $2, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: FailExpiredDebateJobs :execrows
+-- Fails running jobs whose lease expired on their last attempt, which
+-- ClaimDueDebateJobs leaves behind
+UPDATE debate_jobs
+SET status = 'failed', last_error = 'lease expired on the last attempt', locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE status = 'running' AND locked_until < CURRENT_TIMESTAMP AND attempts >= max_attempts;
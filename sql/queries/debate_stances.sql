@@ -0,0 +1,27 @@
+-- name: UpsertDebateStance :one
+-- Only cards belonging to the debate can be picked; no row is returned otherwise.
+INSERT INTO debate_stances (debate_id, debate_card_id, user_id)
+SELECT @debate_id::int, dc.id, @user_id::int
+FROM debate_cards dc
+WHERE dc.id = @debate_card_id AND dc.debate_id = @debate_id::int
+ON CONFLICT (debate_id, user_id)
+DO UPDATE SET debate_card_id = EXCLUDED.debate_card_id, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetUserDebateStance :one
+SELECT * FROM debate_stances WHERE debate_id = $1 AND user_id = $2;
+
+-- name: DeleteDebateStance :execrows
+DELETE FROM debate_stances WHERE debate_id = $1 AND user_id = $2;
+
+-- name: GetDebateStanceTallies :many
+SELECT 
+    dc.debate_id,
+    dc.id AS debate_card_id,
+    dc.stance,
+    COUNT(ds.id) AS count
+FROM debate_cards dc
+LEFT JOIN debate_stances ds ON ds.debate_card_id = dc.id
+WHERE dc.debate_id = ANY(@debate_ids::int[])
+GROUP BY dc.debate_id, dc.id, dc.stance
+ORDER BY dc.debate_id, dc.stance;
@@ -1,19 +1,40 @@
 -- name: CreateDebate :one
-INSERT INTO debates (match_id, debate_type, headline, description, ai_generated)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO debates (match_id, debate_type, headline, description, ai_generated, prompt_template_version, experiment_variant_id, locale, canonical_debate_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
+-- name: CreateDebateMatchData :exec
+INSERT INTO debate_match_data (debate_id, match_data) VALUES ($1, $2);
+
+-- name: GetDebateMatchData :one
+SELECT match_data FROM debate_match_data WHERE debate_id = $1;
+
 -- name: GetDebate :one
+SELECT * FROM debates WHERE id = $1 AND deleted_at IS NULL AND hidden_at IS NULL;
+
+-- name: GetDebateIncludingHidden :one
+-- Admin read of a debate that moderation may have hidden, for triaging reports
 SELECT * FROM debates WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetDebatesByMatch :many
 SELECT * FROM debates 
-WHERE match_id = $1 AND deleted_at IS NULL
+WHERE match_id = $1 AND deleted_at IS NULL AND hidden_at IS NULL
 ORDER BY created_at DESC;
 
+-- name: GetDebateFamily :many
+-- A canonical debate and its translations
+SELECT * FROM debates
+WHERE (id = $1 OR canonical_debate_id = $1) AND deleted_at IS NULL AND hidden_at IS NULL
+ORDER BY id;
+
+-- name: GetDebateTranslations :many
+SELECT * FROM debates
+WHERE canonical_debate_id = ANY(@canonical_debate_ids::int[]) AND locale = ANY(@locales::text[])
+  AND deleted_at IS NULL AND hidden_at IS NULL;
+
 -- name: GetDebatesByType :many
 SELECT * FROM debates 
-WHERE debate_type = $1 AND deleted_at IS NULL
+WHERE debate_type = $1 AND deleted_at IS NULL AND hidden_at IS NULL
 ORDER BY created_at DESC;
 
 -- name: UpdateDebate :one
@@ -31,13 +52,25 @@ UPDATE debates SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1;
 -- name: RestoreDebate :exec
 UPDATE debates SET deleted_at = NULL WHERE id = $1;
 
+-- name: SetDebateHidden :execrows
+-- Hides or unhides a debate, affecting no rows when it is already in that state
+UPDATE debates
+SET hidden_at = CASE WHEN @hidden::bool THEN CURRENT_TIMESTAMP END
+WHERE id = @id AND (hidden_at IS NOT NULL) <> @hidden::bool;
+
+-- name: ListDeletedDebates :many
+SELECT * FROM debates 
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+LIMIT $1 OFFSET $2;
+
 -- name: CreateDebateCard :one
-INSERT INTO debate_cards (debate_id, stance, title, description, ai_generated)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO debate_cards (debate_id, stance, title, description, ai_generated, canonical_card_id)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetDebateCards :many
-SELECT * FROM debate_cards WHERE debate_id = $1 ORDER BY stance;
+SELECT * FROM debate_cards WHERE debate_id = $1 AND hidden_at IS NULL ORDER BY stance;
 
 -- name: GetDebateCard :one
 SELECT * FROM debate_cards WHERE id = $1;
@@ -48,6 +81,12 @@ SET title = $2, description = $3, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
 RETURNING *;
 
+-- name: SetDebateCardHidden :execrows
+-- Hides or unhides a debate card, affecting no rows when it is already in that state
+UPDATE debate_cards
+SET hidden_at = CASE WHEN @hidden::bool THEN CURRENT_TIMESTAMP END
+WHERE id = @id AND (hidden_at IS NOT NULL) <> @hidden::bool;
+
 -- name: DeleteDebateCard :exec
 DELETE FROM debate_cards WHERE id = $1;
 
@@ -58,6 +97,13 @@ ON CONFLICT (debate_card_id, user_id, vote_type, emoji)
 DO UPDATE SET emoji = $4, created_at = CURRENT_TIMESTAMP
 RETURNING *;
 
+-- name: CastDirectionalVote :one
+INSERT INTO votes (debate_card_id, user_id, vote_type)
+VALUES ($1, $2, $3)
+ON CONFLICT (debate_card_id, user_id) WHERE vote_type IN ('upvote', 'downvote')
+DO UPDATE SET vote_type = EXCLUDED.vote_type, created_at = CURRENT_TIMESTAMP
+RETURNING *;
+
 -- name: GetVotesByCard :many
 SELECT * FROM votes WHERE debate_card_id = $1;
 
@@ -67,6 +113,15 @@ SELECT * FROM votes WHERE debate_card_id = $1 AND user_id = $2 AND vote_type = $
 -- name: DeleteVote :exec
 DELETE FROM votes WHERE debate_card_id = $1 AND user_id = $2 AND vote_type = $3;
 
+-- name: DeleteDirectionalVote :execrows
+DELETE FROM votes WHERE debate_card_id = $1 AND user_id = $2 AND vote_type IN ('upvote', 'downvote');
+
+-- name: GetUserDirectionalVotes :many
+SELECT * FROM votes
+WHERE user_id = @user_id
+  AND debate_card_id = ANY(@card_ids::int[])
+  AND vote_type IN ('upvote', 'downvote');
+
 -- name: GetVoteCounts :many
 SELECT 
     debate_card_id,
@@ -78,8 +133,8 @@ WHERE debate_card_id = ANY($1::int[])
 GROUP BY debate_card_id, vote_type, emoji;
 
 -- name: CreateComment :one
-INSERT INTO comments (debate_id, parent_comment_id, user_id, content)
-VALUES ($1, $2, $3, $4)
+INSERT INTO comments (debate_id, parent_comment_id, user_id, content, moderation_status, moderation_reason, hidden_at)
+VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $5 = 'held' THEN CURRENT_TIMESTAMP END)
 RETURNING *;
 
 -- name: GetComments :many
@@ -103,7 +158,9 @@ WHERE c.id = $1;
 
 -- name: UpdateComment :one
 UPDATE comments 
-SET content = $2, updated_at = CURRENT_TIMESTAMP
+SET content = $2, moderation_status = $3, moderation_reason = $4,
+    hidden_at = CASE WHEN $3 = 'held' THEN COALESCE(hidden_at, CURRENT_TIMESTAMP) ELSE hidden_at END,
+    updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
 RETURNING *;
 
@@ -111,7 +168,7 @@ RETURNING *;
 DELETE FROM comments WHERE id = $1;
 
 -- name: GetCommentCount :one
-SELECT COUNT(*) FROM comments WHERE debate_id = $1;
+SELECT COUNT(*) FROM comments WHERE debate_id = $1 AND deleted_at IS NULL;
 
 -- name: CreateDebateAnalytics :one
 INSERT INTO debate_analytics (debate_id, total_votes, total_comments, engagement_score)
@@ -141,6 +198,6 @@ SELECT
     da.engagement_score
 FROM debates d
 LEFT JOIN debate_analytics da ON d.id = da.debate_id
-WHERE d.deleted_at IS NULL
+WHERE d.deleted_at IS NULL AND d.hidden_at IS NULL AND d.canonical_debate_id IS NULL
 ORDER BY da.engagement_score DESC NULLS LAST
 LIMIT $1; 
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- name: CreateLLMCall :exec
+INSERT INTO llm_calls (provider, model, endpoint, prompt_tokens, completion_tokens, cost_usd, latency_ms, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: GetLLMSpendSince :one
+SELECT COALESCE(SUM(cost_usd), 0)::float8 AS cost_usd
+FROM llm_calls
+WHERE created_at >= $1;
+
+-- name: GetLLMUsageReport :many
+-- Calls between @since and @until grouped by @period ('day' or 'month'),
+-- model and endpoint, with periods starting at UTC midnight
+SELECT
+    date_trunc(@period::text, created_at AT TIME ZONE 'UTC')::timestamp AS period_start,
+    model,
+    endpoint,
+    COUNT(*) AS calls,
+    COUNT(error) AS failed_calls,
+    COALESCE(SUM(prompt_tokens), 0)::bigint AS prompt_tokens,
+    COALESCE(SUM(completion_tokens), 0)::bigint AS completion_tokens,
+    COALESCE(SUM(cost_usd), 0)::float8 AS cost_usd,
+    AVG(latency_ms)::float8 AS avg_latency_ms
+FROM llm_calls
+WHERE created_at >= @since AND created_at < @until
+GROUP BY period_start, model, endpoint
+ORDER BY period_start DESC, cost_usd DESC, model, endpoint;
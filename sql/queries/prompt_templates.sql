@@ -0,0 +1,32 @@
+-- name: CreatePromptTemplate :one
+-- Saves an inactive template numbered one after the debate type's latest
+-- version
+INSERT INTO prompt_templates (debate_type, version, system_template, user_template, notes, created_by)
+SELECT @debate_type::text, COALESCE(MAX(version), 0) + 1, @system_template::text, @user_template::text, sqlc.narg(notes)::text, sqlc.narg(created_by)::int
+FROM prompt_templates
+WHERE debate_type = @debate_type::text
+RETURNING *;
+
+-- name: GetPromptTemplate :one
+SELECT * FROM prompt_templates WHERE id = $1;
+
+-- name: GetPromptTemplateByVersion :one
+SELECT * FROM prompt_templates WHERE debate_type = $1 AND version = $2;
+
+-- name: GetActivePromptTemplate :one
+SELECT * FROM prompt_templates WHERE debate_type = $1 AND active;
+
+-- name: ListPromptTemplates :many
+SELECT * FROM prompt_templates ORDER BY debate_type, version DESC;
+
+-- name: DeactivatePromptTemplates :exec
+-- Reverts a debate type to the built-in template
+UPDATE prompt_templates SET active = false WHERE debate_type = $1 AND active;
+
+-- name: ActivatePromptTemplate :one
+-- Run after DeactivatePromptTemplates in the same transaction, as only one
+-- template per debate type may be active
+UPDATE prompt_templates
+SET active = true, activated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
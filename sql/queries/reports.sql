@@ -0,0 +1,44 @@
+-- name: CreateReport :one
+-- Returns no rows when the reporter has already reported the target
+INSERT INTO reports (reporter_id, target_type, target_id, reason, details)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (reporter_id, target_type, target_id) DO NOTHING
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports WHERE id = $1;
+
+-- name: CountOpenReports :one
+SELECT COUNT(*) FROM reports
+WHERE target_type = $1 AND target_id = $2 AND status = 'open';
+
+-- name: ListReports :many
+-- Triage queue, oldest first. Empty filters match every report.
+SELECT 
+    r.id, r.reporter_id, r.target_type, r.target_id, r.reason, r.details, r.status, r.resolved_by, r.resolved_at, r.created_at,
+    (SELECT COUNT(*) FROM reports o
+     WHERE o.target_type = r.target_type AND o.target_id = r.target_id AND o.status = 'open') AS open_reports
+FROM reports r
+WHERE (@status::text = '' OR r.status = @status::text)
+  AND (@target_type::text = '' OR r.target_type = @target_type::text)
+ORDER BY r.created_at ASC, r.id ASC
+LIMIT @page_size OFFSET @page_offset;
+
+-- name: ResolveReportsForTarget :execrows
+-- Closes every open report on a target
+UPDATE reports
+SET status = $3, resolved_by = $4, resolved_at = CURRENT_TIMESTAMP
+WHERE target_type = $1 AND target_id = $2 AND status = 'open';
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (moderator_id, action, target_type, target_id, report_id, notes)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListModerationActions :many
+-- Audit trail, newest first. An empty @target_type matches every target.
+SELECT * FROM moderation_actions
+WHERE (@target_type::text = '' OR target_type = @target_type::text)
+  AND (@target_id::int = 0 OR target_id = @target_id::int)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size OFFSET @page_offset;
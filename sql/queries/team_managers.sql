@@ -6,6 +6,11 @@ RETURNING *;
 -- name: GetTeamManager :one
 SELECT * FROM team_managers WHERE id = $1;
 
+-- name: IsTeamManager :one
+SELECT EXISTS (
+    SELECT 1 FROM team_managers WHERE user_id = $1 AND team_id = $2
+);
+
 -- name: ListTeamManagers :many
 SELECT * FROM team_managers 
 WHERE ($1::uuid IS NULL OR league_id = $1)
@@ -1,5 +1,5 @@
--- name: CreateUser :one
-INSERT INTO users (firstname, lastname, email, is_admin)
+-- name: CreateUserWithPassword :one
+INSERT INTO users (firstname, lastname, email, password_hash)
 VALUES ($1, $2, $3, $4)
 RETURNING *;
 
@@ -9,8 +9,14 @@ SELECT * FROM users WHERE id = $1;
 -- name: GetUserByEmail :one
 SELECT * FROM users WHERE email = $1;
 
--- name: ListUsers :many
-SELECT * FROM users ORDER BY created_at DESC;
+-- name: SearchUsers :many
+SELECT * FROM users
+WHERE @search::text = ''
+   OR firstname ILIKE @search::text || '%'
+   OR lastname ILIKE @search::text || '%'
+   OR (@include_email::boolean AND email ILIKE @search::text || '%')
+ORDER BY firstname, lastname, id
+LIMIT @page_size OFFSET @page_offset;
 
 -- name: UpdateUser :one
 UPDATE users 
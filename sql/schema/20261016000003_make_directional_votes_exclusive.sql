@@ -0,0 +1,17 @@
+-- +goose Up
+-- A user holds at most one upvote or downvote per card. Emoji votes are unaffected.
+-- Keep only the latest directional vote where duplicates already exist.
+DELETE FROM votes older
+USING votes newer
+WHERE older.debate_card_id = newer.debate_card_id
+  AND older.user_id = newer.user_id
+  AND older.vote_type IN ('upvote', 'downvote')
+  AND newer.vote_type IN ('upvote', 'downvote')
+  AND older.id < newer.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_votes_one_direction_per_user
+    ON votes(debate_card_id, user_id)
+    WHERE vote_type IN ('upvote', 'downvote');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_votes_one_direction_per_user;
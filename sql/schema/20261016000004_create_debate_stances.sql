@@ -0,0 +1,18 @@
+-- +goose Up
+-- Create debate_stances table for the per-debate poll. Each user picks one
+-- card per debate; picking another card moves their pick.
+CREATE TABLE IF NOT EXISTS debate_stances (
+    id SERIAL PRIMARY KEY,
+    debate_id INTEGER NOT NULL REFERENCES debates(id) ON DELETE CASCADE,
+    debate_card_id INTEGER NOT NULL REFERENCES debate_cards(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(debate_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_debate_stances_debate_card_id ON debate_stances(debate_card_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debate_stances_debate_card_id;
+DROP TABLE IF EXISTS debate_stances;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Supports keyset pagination of a debate's top-level comments
+CREATE INDEX IF NOT EXISTS idx_comments_debate_root_created
+    ON comments(debate_id, created_at, id)
+    WHERE parent_comment_id IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_debate_root_created;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Create comment_reactions table for likes and emoji reactions on comments
+CREATE TABLE IF NOT EXISTS comment_reactions (
+    id SERIAL PRIMARY KEY,
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reaction_type VARCHAR(10) NOT NULL CHECK (reaction_type IN ('like', 'emoji')),
+    emoji VARCHAR(10), -- For emoji reactions
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((reaction_type = 'emoji') = (emoji IS NOT NULL))
+);
+
+-- One like per user per comment, and one of each emoji
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comment_reactions_one_like
+    ON comment_reactions(comment_id, user_id)
+    WHERE reaction_type = 'like';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comment_reactions_one_emoji
+    ON comment_reactions(comment_id, user_id, emoji)
+    WHERE reaction_type = 'emoji';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comment_reactions_one_emoji;
+DROP INDEX IF EXISTS idx_comment_reactions_one_like;
+DROP TABLE IF EXISTS comment_reactions;
@@ -0,0 +1,50 @@
+-- +goose Up
+-- Content hidden by moderation, either by an admin or automatically once
+-- enough users report it. Hidden debates and cards drop out of public
+-- responses; hidden comments render as placeholders.
+ALTER TABLE debates ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP NULL;
+ALTER TABLE debate_cards ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP NULL;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP NULL;
+
+-- Create reports table for user abuse flags
+CREATE TABLE IF NOT EXISTS reports (
+    id SERIAL PRIMARY KEY,
+    reporter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('debate', 'debate_card', 'comment')),
+    target_id INTEGER NOT NULL,
+    reason VARCHAR(30) NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate_speech', 'misinformation', 'off_topic', 'other')),
+    details TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'actioned', 'dismissed')),
+    resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(reporter_id, target_type, target_id) -- One report per user per target
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_target ON reports(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status);
+
+-- Create moderation_actions table auditing every moderation decision.
+-- moderator_id is NULL for automatic actions.
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id SERIAL PRIMARY KEY,
+    moderator_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(30) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id INTEGER NOT NULL,
+    report_id INTEGER REFERENCES reports(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_target ON moderation_actions(target_type, target_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_moderation_actions_target;
+DROP TABLE IF EXISTS moderation_actions;
+DROP INDEX IF EXISTS idx_reports_status;
+DROP INDEX IF EXISTS idx_reports_target;
+DROP TABLE IF EXISTS reports;
+ALTER TABLE comments DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE debate_cards DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE debates DROP COLUMN IF EXISTS hidden_at;
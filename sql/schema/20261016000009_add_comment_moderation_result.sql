@@ -0,0 +1,14 @@
+-- +goose Up
+-- Outcome of the automatic moderation run before a comment is stored:
+-- 'allowed' as written, 'masked' with offending words starred out, 'held'
+-- hidden until an admin reviews it, or 'approved' by that admin.
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS moderation_status VARCHAR(20) NOT NULL DEFAULT 'allowed'
+    CHECK (moderation_status IN ('allowed', 'masked', 'held', 'approved'));
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS moderation_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_comments_held ON comments(created_at) WHERE moderation_status = 'held';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_comments_held;
+ALTER TABLE comments DROP COLUMN IF EXISTS moderation_reason;
+ALTER TABLE comments DROP COLUMN IF EXISTS moderation_status;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Queue of debate generation jobs. The idempotency key makes enqueueing the
+-- same job twice a no-op, so rescans and redeploys never duplicate debates.
+-- Workers claim due jobs by setting status 'running' with a lease in
+-- locked_until; a job whose lease expires is claimed again.
+CREATE TABLE IF NOT EXISTS debate_jobs (
+    id SERIAL PRIMARY KEY,
+    idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+    match_id VARCHAR(50) NOT NULL,
+    debate_type VARCHAR(20) NOT NULL CHECK (debate_type IN ('pre_match', 'post_match')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    run_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP NULL,
+    debate_id INTEGER REFERENCES debates(id) ON DELETE SET NULL,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_debate_jobs_due ON debate_jobs(run_at) WHERE status IN ('pending', 'running');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debate_jobs_due;
+DROP TABLE IF EXISTS debate_jobs;
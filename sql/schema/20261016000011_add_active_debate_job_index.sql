@@ -0,0 +1,8 @@
+-- +goose Up
+-- At most one queued or running job per debate, so concurrent requests to
+-- generate the same debate share one job instead of racing to create two.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_debate_jobs_active ON debate_jobs(match_id, debate_type)
+    WHERE status IN ('pending', 'running');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debate_jobs_active;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- A forced job replaces the match's existing debate of its type. The old
+-- debate is retired in the same transaction that stores the new one.
+ALTER TABLE debate_jobs ADD COLUMN IF NOT EXISTS force_regenerate BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE debate_jobs DROP COLUMN IF EXISTS force_regenerate;
@@ -0,0 +1,37 @@
+-- +goose Up
+-- Admin-edited versions of the debate prompt templates built into the API.
+-- Versions are numbered from 1 per debate type; version 0 is the built-in
+-- template. At most one version per debate type is active; with none active
+-- the built-in template is used.
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id SERIAL PRIMARY KEY,
+    debate_type VARCHAR(20) NOT NULL CHECK (debate_type IN ('pre_match', 'post_match')),
+    version INTEGER NOT NULL CHECK (version > 0),
+    system_template TEXT NOT NULL,
+    user_template TEXT NOT NULL,
+    notes TEXT,
+    active BOOLEAN NOT NULL DEFAULT false,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activated_at TIMESTAMP NULL,
+    UNIQUE (debate_type, version)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_prompt_templates_active ON prompt_templates(debate_type) WHERE active;
+
+-- The template version that generated each AI debate
+ALTER TABLE debates ADD COLUMN IF NOT EXISTS prompt_template_version INTEGER;
+
+-- The match data each AI debate was generated from, for previewing templates
+-- against real matches
+CREATE TABLE IF NOT EXISTS debate_match_data (
+    debate_id INTEGER PRIMARY KEY REFERENCES debates(id) ON DELETE CASCADE,
+    match_data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS debate_match_data;
+ALTER TABLE debates DROP COLUMN IF EXISTS prompt_template_version;
+DROP INDEX IF EXISTS idx_prompt_templates_active;
+DROP TABLE IF EXISTS prompt_templates;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- A/B experiments over how AI debates are generated. While an experiment is
+-- running, each generated debate of its type is assigned one of its variants
+-- by weight.
+CREATE TABLE IF NOT EXISTS debate_experiments (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    debate_type VARCHAR(20) NOT NULL CHECK (debate_type IN ('pre_match', 'post_match')),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'running', 'stopped')),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    stopped_at TIMESTAMP NULL
+);
+
+-- One running experiment per debate type
+CREATE UNIQUE INDEX IF NOT EXISTS idx_debate_experiments_running ON debate_experiments(debate_type) WHERE status = 'running';
+
+-- A variant's NULL settings fall back to what generation uses outside
+-- experiments: the active prompt template, the configured model and the
+-- default temperature
+CREATE TABLE IF NOT EXISTS debate_experiment_variants (
+    id SERIAL PRIMARY KEY,
+    experiment_id INTEGER NOT NULL REFERENCES debate_experiments(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    weight INTEGER NOT NULL DEFAULT 1 CHECK (weight > 0),
+    prompt_template_version INTEGER CHECK (prompt_template_version >= 0),
+    model VARCHAR(100),
+    temperature DOUBLE PRECISION CHECK (temperature >= 0 AND temperature <= 2),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (experiment_id, name)
+);
+
+ALTER TABLE debates ADD COLUMN IF NOT EXISTS experiment_variant_id INTEGER REFERENCES debate_experiment_variants(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_debates_experiment_variant_id ON debates(experiment_variant_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debates_experiment_variant_id;
+ALTER TABLE debates DROP COLUMN IF EXISTS experiment_variant_id;
+DROP TABLE IF EXISTS debate_experiment_variants;
+DROP INDEX IF EXISTS idx_debate_experiments_running;
+DROP TABLE IF EXISTS debate_experiments;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- Debates are generated per language. The first debate for a match and type
+-- is canonical; debates in other languages are translations of it and link
+-- to it, as do their cards to the canonical cards. Votes and stances are
+-- recorded against the canonical debate so they add up across languages.
+ALTER TABLE debates ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT 'en';
+ALTER TABLE debates ADD COLUMN IF NOT EXISTS canonical_debate_id INTEGER REFERENCES debates(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_debates_canonical_debate_id ON debates(canonical_debate_id);
+
+-- One live translation per language
+CREATE UNIQUE INDEX IF NOT EXISTS idx_debates_translation_locale ON debates(canonical_debate_id, locale)
+    WHERE canonical_debate_id IS NOT NULL AND deleted_at IS NULL;
+
+ALTER TABLE debate_cards ADD COLUMN IF NOT EXISTS canonical_card_id INTEGER REFERENCES debate_cards(id) ON DELETE CASCADE;
+
+-- Jobs generate a debate in one language, so a job per language may be active
+ALTER TABLE debate_jobs ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT 'en';
+DROP INDEX IF EXISTS idx_debate_jobs_active;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_debate_jobs_active ON debate_jobs(match_id, debate_type, locale)
+    WHERE status IN ('pending', 'running');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debate_jobs_active;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_debate_jobs_active ON debate_jobs(match_id, debate_type)
+    WHERE status IN ('pending', 'running');
+ALTER TABLE debate_jobs DROP COLUMN IF EXISTS locale;
+ALTER TABLE debate_cards DROP COLUMN IF EXISTS canonical_card_id;
+DROP INDEX IF EXISTS idx_debates_translation_locale;
+DROP INDEX IF EXISTS idx_debates_canonical_debate_id;
+ALTER TABLE debates DROP COLUMN IF EXISTS canonical_debate_id;
+ALTER TABLE debates DROP COLUMN IF EXISTS locale;
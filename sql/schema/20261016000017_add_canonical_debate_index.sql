@@ -0,0 +1,29 @@
+-- +goose Up
+-- One live canonical debate per match and type, so jobs generating the same
+-- debate in two languages cannot both store a canonical debate; the one
+-- that loses stores a translation instead. Where duplicates already exist,
+-- keep the newest, which is the one that has been served.
+UPDATE debates older
+SET deleted_at = CURRENT_TIMESTAMP
+FROM debates newer
+WHERE older.match_id = newer.match_id
+  AND older.debate_type = newer.debate_type
+  AND older.canonical_debate_id IS NULL
+  AND newer.canonical_debate_id IS NULL
+  AND older.deleted_at IS NULL
+  AND newer.deleted_at IS NULL
+  AND (older.created_at, older.id) < (newer.created_at, newer.id);
+
+-- Along with their translations
+UPDATE debates translation
+SET deleted_at = canonical.deleted_at
+FROM debates canonical
+WHERE translation.canonical_debate_id = canonical.id
+  AND translation.deleted_at IS NULL
+  AND canonical.deleted_at IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_debates_canonical_match_type ON debates(match_id, debate_type)
+    WHERE canonical_debate_id IS NULL AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debates_canonical_match_type;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- created_at was a TIMESTAMP in the database's time zone, while the monthly
+-- budget and usage reports compare it with UTC times. Store an absolute time
+-- instead, reading the existing rows as UTC.
+ALTER TABLE llm_calls ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+-- +goose Down
+ALTER TABLE llm_calls ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
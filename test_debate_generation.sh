@@ -19,13 +19,27 @@ curl -s "$BASE_URL/debates/generate?match_id=1321727&type=pre_match" | jq '.'
 # Test 2: Generate Complete Debate (POST)
 echo -e "\n2. Testing POST /debates/generate (Complete debate)"
 echo "----------------------------------------------------"
-curl -s -X POST "$BASE_URL/debates/generate" \
+RESPONSE=$(curl -s -X POST "$BASE_URL/debates/generate" \
   -H "Content-Type: application/json" \
   -d '{
     "match_id": "1321727",
     "debate_type": "pre_match",
     "force_regenerate": false
-  }' | jq '.'
+  }')
+echo "$RESPONSE" | jq '.'
+
+# Generation runs as a job; poll it until it finishes
+JOB_ID=$(echo "$RESPONSE" | jq -r '.job.id // empty')
+if [ -n "$JOB_ID" ]; then
+  for i in $(seq 1 30); do
+    STATUS=$(curl -s "$BASE_URL/debates/jobs/$JOB_ID" | jq -r '.status')
+    if [ "$STATUS" = "succeeded" ] || [ "$STATUS" = "failed" ]; then
+      break
+    fi
+    sleep 2
+  done
+  curl -s "$BASE_URL/debates/jobs/$JOB_ID" | jq '.'
+fi
 
 # Test 3: Generate Post-Match Debate
 echo -e "\n3. Testing POST /debates/generate (Post-match)"